@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"subscritracker/pkg/currency"
+	"subscritracker/pkg/utils"
+)
+
+/*
+Loads exchange rates from a local CSV or ECB XML file into the exchange_rates table
+Usage: go run cmd/rates/main.go load <path>
+*/
+func main() {
+	if len(os.Args) < 3 || os.Args[1] != "load" {
+		log.Fatal("Usage: go run cmd/rates/main.go load <path to .csv or .xml>")
+	}
+	path := os.Args[2]
+
+	rates, err := currency.LoadRatesFromFile(path)
+	if err != nil {
+		log.Fatalf("Failed to parse exchange rates from %s: %v", path, err)
+	}
+
+	db, databaseErr := utils.NewDatabase()
+	if databaseErr != nil {
+		log.Fatalf("Failed to setup the database: %v\n", databaseErr)
+	}
+	defer db.Close()
+
+	if err := currency.ImportRates(context.Background(), db, rates); err != nil {
+		log.Fatalf("Failed to import exchange rates: %v", err)
+	}
+
+	log.Printf("Loaded %d exchange rates from %s", len(rates), path)
+}
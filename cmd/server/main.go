@@ -5,11 +5,31 @@ import (
 	"log"
 	"subscritracker/pkg/account"
 	analysis "subscritracker/pkg/analysis"
+	"subscritracker/pkg/analysis/budget_report"
 	"subscritracker/pkg/application"
 	"subscritracker/pkg/auth"
+	"subscritracker/pkg/budgets"
+	"subscritracker/pkg/calendar"
+	"subscritracker/pkg/categories"
+	"subscritracker/pkg/digest"
+	duedates "subscritracker/pkg/due-dates"
+	"subscritracker/pkg/inbox"
+	"subscritracker/pkg/notifications"
+	"subscritracker/pkg/outbox"
+	"subscritracker/pkg/payments"
+	pricehistory "subscritracker/pkg/price-history"
+	"subscritracker/pkg/reminders"
+	securitylog "subscritracker/pkg/security-log"
+	"subscritracker/pkg/statements"
+	"subscritracker/pkg/stream"
 	subscription_channels "subscritracker/pkg/subscription-channels"
 	subscription_details "subscritracker/pkg/subscription-details"
 	subscription_events "subscritracker/pkg/subscription-events"
+	subscription_versions "subscritracker/pkg/subscription-versions"
+	"subscritracker/pkg/tags"
+	"subscritracker/pkg/webhooks"
+	"time"
+	_ "time/tzdata"
 
 	"github.com/labstack/echo/v4"
 )
@@ -33,6 +53,9 @@ func main() {
 		log.Fatalf("Failed to register routes: %v", err)
 	}
 
+	// Start background workers
+	startWorkers(ctx, app)
+
 	// Start server
 	if err := app.Echo.Start(":8080"); err != nil {
 		log.Fatalf("Server failed: %v", err)
@@ -59,7 +82,49 @@ func registerRoutes(app *application.App) error {
 	subscription_channels.RegisterRoutes(app)
 	subscription_details.RegisterRoutes(app)
 	subscription_events.RegisterRoutes(app)
+	subscription_versions.RegisterRoutes(app)
+	pricehistory.RegisterRoutes(app)
+	categories.RegisterRoutes(app)
+	tags.RegisterRoutes(app)
+	statements.RegisterRoutes(app)
+	calendar.RegisterRoutes(app)
+	reminders.RegisterRoutes(app)
+	notifications.RegisterRoutes(app)
+	inbox.RegisterRoutes(app)
+	digest.RegisterRoutes(app)
+	payments.RegisterRoutes(app)
+	stream.RegisterRoutes(app)
+	webhooks.RegisterRoutes(app)
+	securitylog.RegisterRoutes(app)
+	budgets.RegisterRoutes(app)
 	analysis.RegisterRoutes(app)
 
 	return nil
 }
+
+/*
+Start background workers function
+*/
+func startWorkers(ctx context.Context, app *application.App) {
+	log.Println("Starting background workers!")
+	stream.StartHub(ctx, app)
+	duedates.StartDueDateWorker(ctx, app, time.Hour)
+	pricehistory.StartScheduledPriceChangeWorker(ctx, app, time.Hour)
+	reminders.StartReminderWorker(ctx, app, time.Minute, notifications.ReminderDeliverer{App: app})
+	notifications.StartTrialEndingWorker(ctx, app, time.Hour)
+	digest.StartDigestWorker(ctx, app, 5*time.Minute)
+	payments.StartLedgerWorker(ctx, app, time.Hour)
+	budget_report.StartBudgetAlertWorker(ctx, app, time.Hour)
+	notifications.StartDispatchWorker(ctx, app, 30*time.Second, notifications.NewTransports(app.Config.Notifications))
+	webhooks.StartDeliveryWorker(ctx, app, 15*time.Second)
+
+	publisher, err := outbox.NewPublisher(app.Config.Outbox)
+	if err != nil {
+		log.Fatalf("Failed to create outbox publisher: %v", err)
+	}
+	relay := outbox.NewRelay(publisher)
+	relay.Subscribe(outbox.TopicSubscriptionEventRecorded, "webhooks", webhooks.EnqueueSubscriptionEvent)
+	relay.Subscribe(outbox.TopicSubscriptionEventRecorded, "account_subscription_count", account.RefreshSubscriptionCount)
+	relay.Subscribe(outbox.TopicSubscriptionEventRecorded, "price_change_notifications", notifications.PriceChangeNotifier{App: app}.NotifyPriceChange)
+	outbox.StartRelayWorker(ctx, app, 5*time.Second, relay)
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"subscritracker/pkg/notifications"
+)
+
+/*
+Generates a VAPID key pair for Web Push notifications
+Usage: go run cmd/vapid/main.go
+*/
+func main() {
+	publicKey, privateKey, err := notifications.GenerateVAPIDKeys()
+	if err != nil {
+		log.Fatalf("Failed to generate VAPID keys: %v", err)
+	}
+
+	fmt.Printf("VAPID_PUBLIC_KEY=%s\n", publicKey)
+	fmt.Printf("VAPID_PRIVATE_KEY=%s\n", privateKey)
+}
@@ -6,15 +6,66 @@ import (
 )
 
 type Config struct {
-	Database   DatabaseConfig
-	GoogleAuth GoogleAuthConfig
-	Frontend   FrontendConfig
+	Database      DatabaseConfig
+	GoogleAuth    GoogleAuthConfig
+	Frontend      FrontendConfig
+	API           APIConfig
+	Notifications NotificationsConfig
+	Outbox        OutboxConfig
+	Admin         AdminConfig
+	Proxy         ProxyConfig
 }
 
 type FrontendConfig struct {
 	URL string
 }
 
+// APIConfig holds the public URL of this server, used for links that are opened outside the frontend
+type APIConfig struct {
+	URL string
+}
+
+// NotificationsConfig holds the credentials of the notification transports.
+// With UseFakeTransports set, notifications are recorded and logged instead of being sent.
+type NotificationsConfig struct {
+	SMTP              SMTPConfig
+	VAPID             VAPIDConfig
+	UseFakeTransports bool
+}
+
+// AdminConfig lists the accounts, by email, allowed to use the admin endpoints
+type AdminConfig struct {
+	Emails []string
+}
+
+// ProxyConfig lists the reverse proxies, as IP ranges, whose X-Forwarded-For header is trusted.
+// Without any, the client address is the one the connection comes from.
+type ProxyConfig struct {
+	TrustedProxies []string
+}
+
+// OutboxConfig selects the broker outbox messages are also published to, besides in-process
+// consumers. PublisherURL is a redis:// or nats:// URL; empty publishes to nothing else.
+type OutboxConfig struct {
+	PublisherURL string
+	TopicPrefix  string
+}
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// VAPIDConfig identifies this server to Web Push services. Keys are base64url encoded P-256 keys.
+type VAPIDConfig struct {
+	PublicKey  string
+	PrivateKey string
+	Subject    string
+}
+
 type GoogleAuthConfig struct {
 	ClientID     string
 	ClientSecret string
@@ -57,6 +108,67 @@ func GetConfig() *Config {
 			cfg.Frontend.URL = "http://localhost:3000" // Default fallback
 		}
 
+		// Public API URL
+		cfg.API.URL = os.Getenv("API_URL")
+		if cfg.API.URL == "" {
+			cfg.API.URL = "http://localhost:8080" // Default fallback
+		}
+
+		// Notification transports
+		loadNotificationsConfig(cfg)
+		cfg.Notifications.UseFakeTransports = os.Getenv("NOTIFICATIONS_FAKE") == "true"
+
+		// Outbox broker
+		loadOutboxConfig(cfg)
+
+		// Admin accounts
+		loadAdminConfig(cfg)
+
+		// Reverse proxies
+		loadProxyConfig(cfg)
+
 		return cfg
 	}
 }
+
+func loadNotificationsConfig(cfg *Config) {
+	cfg.Notifications.SMTP.Host = os.Getenv("SMTP_HOST")
+	cfg.Notifications.SMTP.Port = os.Getenv("SMTP_PORT")
+	if cfg.Notifications.SMTP.Port == "" {
+		cfg.Notifications.SMTP.Port = "587"
+	}
+	cfg.Notifications.SMTP.Username = os.Getenv("SMTP_USERNAME")
+	cfg.Notifications.SMTP.Password = os.Getenv("SMTP_PASSWORD")
+	cfg.Notifications.SMTP.From = os.Getenv("SMTP_FROM")
+
+	cfg.Notifications.VAPID.PublicKey = os.Getenv("VAPID_PUBLIC_KEY")
+	cfg.Notifications.VAPID.PrivateKey = os.Getenv("VAPID_PRIVATE_KEY")
+	cfg.Notifications.VAPID.Subject = os.Getenv("VAPID_SUBJECT")
+	if cfg.Notifications.VAPID.Subject == "" {
+		cfg.Notifications.VAPID.Subject = "mailto:admin@localhost"
+	}
+}
+
+func loadOutboxConfig(cfg *Config) {
+	cfg.Outbox.PublisherURL = os.Getenv("OUTBOX_PUBLISHER_URL")
+	cfg.Outbox.TopicPrefix = os.Getenv("OUTBOX_TOPIC_PREFIX")
+	if cfg.Outbox.TopicPrefix == "" {
+		cfg.Outbox.TopicPrefix = "subscritracker."
+	}
+}
+
+func loadAdminConfig(cfg *Config) {
+	for _, email := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			cfg.Admin.Emails = append(cfg.Admin.Emails, email)
+		}
+	}
+}
+
+func loadProxyConfig(cfg *Config) {
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			cfg.Proxy.TrustedProxies = append(cfg.Proxy.TrustedProxies, proxy)
+		}
+	}
+}
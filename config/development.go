@@ -23,5 +23,24 @@ func GetDevelopmentConfig() *Config {
 		cfg.Frontend.URL = "http://localhost:3000" // Default for development
 	}
 
+	// Public API URL
+	cfg.API.URL = os.Getenv("API_URL")
+	if cfg.API.URL == "" {
+		cfg.API.URL = "http://localhost:8080" // Default for development
+	}
+
+	// Notification transports are faked in development unless explicitly turned off
+	loadNotificationsConfig(cfg)
+	cfg.Notifications.UseFakeTransports = os.Getenv("NOTIFICATIONS_FAKE") != "false"
+
+	// Outbox messages only go to in-process consumers unless a broker is set
+	loadOutboxConfig(cfg)
+
+	// Admin accounts
+	loadAdminConfig(cfg)
+
+	// Reverse proxies
+	loadProxyConfig(cfg)
+
 	return cfg
 }
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_subscription_details_trial_end_date;
+
+ALTER TABLE subscription_details
+DROP COLUMN IF EXISTS intro_price_until;
+
+ALTER TABLE subscription_details
+DROP COLUMN IF EXISTS intro_price;
+
+ALTER TABLE subscription_details
+DROP COLUMN IF EXISTS trial_end_date;
@@ -0,0 +1,14 @@
+-- Add free trial and introductory pricing fields to subscription_details
+-- trial_end_date: the first real charge happens on this date, nothing is billed before it
+-- intro_price / intro_price_until: promo price billed until the cutoff, monthly_bill after
+
+ALTER TABLE subscription_details
+ADD COLUMN IF NOT EXISTS trial_end_date DATE;
+
+ALTER TABLE subscription_details
+ADD COLUMN IF NOT EXISTS intro_price NUMERIC(10, 2);
+
+ALTER TABLE subscription_details
+ADD COLUMN IF NOT EXISTS intro_price_until DATE;
+
+CREATE INDEX IF NOT EXISTS idx_subscription_details_trial_end_date ON subscription_details(trial_end_date);
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS subscription_price_history (
+    id SERIAL PRIMARY KEY,
+    subscription_details_id INT NOT NULL REFERENCES subscription_details(id) ON DELETE CASCADE,
+    account_id INT NOT NULL REFERENCES account(id),
+    monthly_bill NUMERIC(10, 2) NOT NULL,
+    effective_date DATE NOT NULL,
+
+    -- Timestamps
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    -- One price per subscription per effective date
+    CONSTRAINT unique_subscription_price_effective_date UNIQUE (subscription_details_id, effective_date)
+);
+
+CREATE INDEX idx_subscription_price_history_subscription_details_id ON subscription_price_history(subscription_details_id);
+CREATE INDEX idx_subscription_price_history_account_id ON subscription_price_history(account_id);
+CREATE INDEX idx_subscription_price_history_effective_date ON subscription_price_history(effective_date);
+
+-- Seed the history with the current price of every existing subscription
+INSERT INTO subscription_price_history (subscription_details_id, account_id, monthly_bill, effective_date)
+SELECT id, account_id, monthly_bill, COALESCE(start_date, created_at::date)
+FROM subscription_details
+WHERE monthly_bill IS NOT NULL
+ON CONFLICT DO NOTHING;
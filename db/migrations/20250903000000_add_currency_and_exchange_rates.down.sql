@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_subscription_details_currency;
+
+DROP TABLE IF EXISTS exchange_rates;
+
+ALTER TABLE account
+DROP COLUMN IF EXISTS default_currency;
+
+ALTER TABLE subscription_details
+DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,26 @@
+-- Add ISO-4217 currency to subscription amounts and a default currency per account
+ALTER TABLE subscription_details
+ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+ALTER TABLE account
+ADD COLUMN IF NOT EXISTS default_currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+-- Exchange rates loaded from local CSV / ECB XML files
+-- 1 base_currency = rate quote_currency on rate_date
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    id SERIAL PRIMARY KEY,
+    base_currency CHAR(3) NOT NULL,
+    quote_currency CHAR(3) NOT NULL,
+    rate NUMERIC(20, 10) NOT NULL CHECK (rate > 0),
+    rate_date DATE NOT NULL,
+    source VARCHAR(50) NOT NULL DEFAULT 'manual',
+
+    -- Timestamps
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_exchange_rate_pair_date UNIQUE (base_currency, quote_currency, rate_date)
+);
+
+CREATE INDEX idx_exchange_rates_pair_date ON exchange_rates(base_currency, quote_currency, rate_date DESC);
+CREATE INDEX idx_subscription_details_currency ON subscription_details(currency);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_subscription_details_account_id_id;
+DROP INDEX IF EXISTS idx_subscription_details_notes_fts;
+DROP INDEX IF EXISTS idx_subscription_details_notes_trgm;
+DROP INDEX IF EXISTS idx_subscription_channels_channel_name_trgm;
+
+ALTER TABLE subscription_details
+DROP COLUMN IF EXISTS notes;
@@ -0,0 +1,12 @@
+-- Free-text notes on subscriptions and indexes for full-text / trigram search
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE subscription_details
+ADD COLUMN IF NOT EXISTS notes TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_subscription_channels_channel_name_trgm ON subscription_channels USING GIN (channel_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_subscription_details_notes_trgm ON subscription_details USING GIN (notes gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_subscription_details_notes_fts ON subscription_details USING GIN (to_tsvector('simple', COALESCE(notes, '')));
+
+-- Keyset pagination always breaks ties on id within an account
+CREATE INDEX IF NOT EXISTS idx_subscription_details_account_id_id ON subscription_details(account_id, id);
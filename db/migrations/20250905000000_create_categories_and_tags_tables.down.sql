@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS subscription_details_tags;
+DROP TABLE IF EXISTS tags;
+
+DROP INDEX IF EXISTS idx_subscription_details_category_id;
+ALTER TABLE subscription_details
+DROP COLUMN IF EXISTS category_id;
+
+DROP TABLE IF EXISTS categories;
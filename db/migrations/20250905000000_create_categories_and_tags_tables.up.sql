@@ -0,0 +1,45 @@
+-- Per-account categories (one per subscription) and tags (many per subscription)
+CREATE TABLE IF NOT EXISTS categories (
+    id SERIAL PRIMARY KEY,
+    account_id INT NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    color VARCHAR(7),
+
+    -- Timestamps
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_account_category_name UNIQUE (account_id, name)
+);
+
+CREATE INDEX idx_categories_account_id ON categories(account_id);
+
+ALTER TABLE subscription_details
+ADD COLUMN IF NOT EXISTS category_id INT REFERENCES categories(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_subscription_details_category_id ON subscription_details(category_id);
+
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    account_id INT NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    color VARCHAR(7),
+
+    -- Timestamps
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_account_tag_name UNIQUE (account_id, name)
+);
+
+CREATE INDEX idx_tags_account_id ON tags(account_id);
+
+CREATE TABLE IF NOT EXISTS subscription_details_tags (
+    subscription_details_id INT NOT NULL REFERENCES subscription_details(id) ON DELETE CASCADE,
+    tag_id INT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (subscription_details_id, tag_id)
+);
+
+CREATE INDEX idx_subscription_details_tags_tag_id ON subscription_details_tags(tag_id);
@@ -0,0 +1,15 @@
+-- Private calendar feed tokens. Only the SHA-256 of the token is stored, the token itself is shown once.
+CREATE TABLE IF NOT EXISTS calendar_feed_tokens (
+    id SERIAL PRIMARY KEY,
+    account_id INT NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+
+    -- Timestamps
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- At most one active token per account
+CREATE UNIQUE INDEX idx_calendar_feed_tokens_active_account ON calendar_feed_tokens(account_id) WHERE revoked_at IS NULL;
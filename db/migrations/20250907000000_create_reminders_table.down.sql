@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS reminders;
+
+ALTER TABLE subscription_details
+DROP COLUMN IF EXISTS reminder_days_before;
+
+ALTER TABLE account
+DROP COLUMN IF EXISTS timezone;
@@ -0,0 +1,31 @@
+-- IANA timezone used to fire reminders at the user's local time
+ALTER TABLE account
+ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+
+-- Relative reminders, e.g. 3 days before each due date
+ALTER TABLE subscription_details
+ADD COLUMN IF NOT EXISTS reminder_days_before INT CHECK (reminder_days_before BETWEEN 0 AND 365);
+
+-- One row per reminder to send. The unique key makes enqueueing idempotent across restarts and replicas.
+CREATE TABLE IF NOT EXISTS reminders (
+    id SERIAL PRIMARY KEY,
+    account_id INT NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+    subscription_details_id INT NOT NULL REFERENCES subscription_details(id) ON DELETE CASCADE,
+    due_date DATE NOT NULL,
+    remind_at TIMESTAMPTZ NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'sent', 'failed', 'skipped')),
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    locked_at TIMESTAMPTZ,
+    sent_at TIMESTAMPTZ,
+    last_error TEXT,
+
+    -- Timestamps
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_reminder_per_due_date UNIQUE (subscription_details_id, due_date)
+);
+
+CREATE INDEX idx_reminders_due ON reminders(next_attempt_at) WHERE status IN ('pending', 'processing');
+CREATE INDEX idx_reminders_account_id ON reminders(account_id, remind_at DESC);
@@ -0,0 +1,59 @@
+-- Destinations a user receives notifications on: an email address, a webhook, a browser push subscription
+-- or a Slack/Discord incoming webhook. config holds the type specific address and keys.
+CREATE TABLE IF NOT EXISTS notification_channels (
+    id SERIAL PRIMARY KEY,
+    account_id INT NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('email', 'webhook', 'web_push', 'slack', 'discord')),
+    name VARCHAR(100) NOT NULL,
+    config JSONB NOT NULL DEFAULT '{}',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+
+    -- Timestamps
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_notification_channels_account_id ON notification_channels(account_id);
+
+-- Which channels an event type goes to. No row means every enabled channel, an empty list mutes the event.
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    account_id INT NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    channel_ids INT[] NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (account_id, event_type)
+);
+
+-- Quiet hours in the account's timezone. Notifications due inside them are held until they end.
+CREATE TABLE IF NOT EXISTS notification_settings (
+    account_id INT PRIMARY KEY REFERENCES account(id) ON DELETE CASCADE,
+    quiet_hours_start TIME,
+    quiet_hours_end TIME,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Every delivery attempt to a channel. The dedupe key stops one event from being sent twice to a channel.
+CREATE TABLE IF NOT EXISTS notification_dispatches (
+    id SERIAL PRIMARY KEY,
+    account_id INT NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+    notification_channel_id INT NOT NULL REFERENCES notification_channels(id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    dedupe_key VARCHAR(200) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sending', 'sent', 'failed')),
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    locked_at TIMESTAMPTZ,
+    sent_at TIMESTAMPTZ,
+    last_error TEXT,
+
+    -- Timestamps
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_dispatch_per_channel UNIQUE (dedupe_key, notification_channel_id)
+);
+
+CREATE INDEX idx_notification_dispatches_due ON notification_dispatches(next_attempt_at) WHERE status IN ('pending', 'sending');
+CREATE INDEX idx_notification_dispatches_account_id ON notification_dispatches(account_id, id DESC);
@@ -0,0 +1,22 @@
+-- In-app inbox. Every notification an account receives is kept here, whichever channels it was also sent to.
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    account_id INT NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+    subscription_details_id INT REFERENCES subscription_details(id) ON DELETE SET NULL,
+    type VARCHAR(50) NOT NULL,
+    dedupe_key VARCHAR(200) NOT NULL,
+    title VARCHAR(200) NOT NULL,
+    body TEXT NOT NULL,
+    url TEXT,
+    data JSONB NOT NULL DEFAULT '{}',
+    read_at TIMESTAMPTZ,
+
+    -- Timestamps
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_notification_per_account UNIQUE (account_id, dedupe_key)
+);
+
+CREATE INDEX idx_notifications_account_id ON notifications(account_id, id DESC);
+CREATE INDEX idx_notifications_unread ON notifications(account_id) WHERE read_at IS NULL;
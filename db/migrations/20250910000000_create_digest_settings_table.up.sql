@@ -0,0 +1,17 @@
+-- Opt-in spending digest. next_send_at is the next local send time in UTC, kept by the digest worker.
+CREATE TABLE IF NOT EXISTS digest_settings (
+    account_id INT PRIMARY KEY REFERENCES account(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    frequency VARCHAR(10) NOT NULL DEFAULT 'weekly' CHECK (frequency IN ('weekly', 'monthly')),
+    send_time TIME NOT NULL DEFAULT '08:00',
+    -- Day of the week weekly digests are sent on, 0 = Sunday
+    send_weekday SMALLINT NOT NULL DEFAULT 1 CHECK (send_weekday BETWEEN 0 AND 6),
+    next_send_at TIMESTAMPTZ,
+    last_sent_at TIMESTAMPTZ,
+
+    -- Timestamps
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_digest_settings_next_send_at ON digest_settings(next_send_at) WHERE enabled;
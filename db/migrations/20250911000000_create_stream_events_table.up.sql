@@ -0,0 +1,15 @@
+-- Change feed behind the /v1/stream endpoint. Every row is announced on the stream_events
+-- channel with pg_notify so each API instance can push it to its connected clients, and
+-- the id doubles as the SSE event id clients resume from. Rows are kept for a day.
+CREATE TABLE IF NOT EXISTS stream_events (
+    id BIGSERIAL PRIMARY KEY,
+    account_id INT NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+
+    -- Timestamps
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_stream_events_account_id ON stream_events(account_id, id);
+CREATE INDEX idx_stream_events_created_at ON stream_events(created_at);
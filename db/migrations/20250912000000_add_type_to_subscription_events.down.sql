@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_subscription_events_account_id_id;
+DROP INDEX IF EXISTS idx_subscription_events_subscription_details_id_id;
+
+ALTER TABLE subscription_events
+    DROP COLUMN IF EXISTS actor,
+    DROP COLUMN IF EXISTS payload,
+    DROP COLUMN IF EXISTS type;
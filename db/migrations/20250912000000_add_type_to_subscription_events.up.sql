@@ -0,0 +1,12 @@
+-- Typed subscription events. Events recorded before they had a type are kept as 'created'.
+ALTER TABLE subscription_events
+    ADD COLUMN type VARCHAR(30) NOT NULL DEFAULT 'created'
+        CHECK (type IN ('created', 'price_changed', 'paused', 'resumed', 'cancelled', 'charged', 'reminder_sent')),
+    ADD COLUMN payload JSONB NOT NULL DEFAULT '{}',
+    -- Who caused the event: the user through the API, or a background worker
+    ADD COLUMN actor VARCHAR(20) NOT NULL DEFAULT 'user' CHECK (actor IN ('user', 'system'));
+
+ALTER TABLE subscription_events ALTER COLUMN type DROP DEFAULT;
+
+CREATE INDEX idx_subscription_events_subscription_details_id_id ON subscription_events(subscription_details_id, id DESC);
+CREATE INDEX idx_subscription_events_account_id_id ON subscription_events(account_id, id DESC);
@@ -0,0 +1,45 @@
+-- Payment ledger. expected_charges holds one row per billing date that has come due, with the
+-- price in effect on that date, and payments what the user says was actually paid. A payment is
+-- linked to the expected charge it settles, so reconciliation can flag missed charges, duplicates
+-- and amounts that differ from the expected price.
+CREATE TABLE IF NOT EXISTS expected_charges (
+    id SERIAL PRIMARY KEY,
+    account_id INT NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+    subscription_details_id INT NOT NULL REFERENCES subscription_details(id) ON DELETE CASCADE,
+    due_date DATE NOT NULL,
+    amount NUMERIC(10, 2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+
+    -- Timestamps
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_expected_charge_per_cycle UNIQUE (subscription_details_id, due_date)
+);
+
+CREATE INDEX idx_expected_charges_account_id_due_date ON expected_charges(account_id, due_date);
+
+CREATE TABLE IF NOT EXISTS payments (
+    id SERIAL PRIMARY KEY,
+    account_id INT NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+    subscription_details_id INT NOT NULL REFERENCES subscription_details(id) ON DELETE CASCADE,
+    expected_charge_id INT REFERENCES expected_charges(id) ON DELETE SET NULL,
+    amount NUMERIC(10, 2) NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    paid_at TIMESTAMPTZ NOT NULL,
+    method VARCHAR(20) NOT NULL DEFAULT 'other'
+        CHECK (method IN ('card', 'bank_transfer', 'direct_debit', 'paypal', 'cash', 'other')),
+    receipt_reference VARCHAR(200),
+    notes TEXT,
+    -- Voided payments stay in the ledger but no longer count
+    voided_at TIMESTAMPTZ,
+    void_reason VARCHAR(500),
+
+    -- Timestamps
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_payments_account_id ON payments(account_id, id DESC);
+CREATE INDEX idx_payments_subscription_details_id ON payments(subscription_details_id, paid_at);
+CREATE INDEX idx_payments_expected_charge_id ON payments(expected_charge_id) WHERE voided_at IS NULL;
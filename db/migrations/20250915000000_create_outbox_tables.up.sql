@@ -0,0 +1,36 @@
+-- Transactional outbox. Messages are written in the same transaction as the change they describe and
+-- relayed to in-process consumers, and to an external broker when one is configured, at least once.
+CREATE TABLE IF NOT EXISTS outbox_messages (
+    id BIGSERIAL PRIMARY KEY,
+    account_id INT REFERENCES account(id) ON DELETE CASCADE,
+    topic VARCHAR(100) NOT NULL,
+    key VARCHAR(100),
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'relaying', 'published', 'dead')),
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_at TIMESTAMPTZ,
+    published_at TIMESTAMPTZ,
+    last_error TEXT,
+
+    -- Timestamps
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_outbox_messages_due ON outbox_messages(next_attempt_at) WHERE status IN ('pending', 'relaying');
+CREATE INDEX idx_outbox_messages_published_at ON outbox_messages(published_at) WHERE status = 'published';
+
+-- A row per message a consumer has handled, written in the consumer's transaction, so a message
+-- relayed again after a crash or a failing sibling consumer is not handled twice
+CREATE TABLE IF NOT EXISTS outbox_consumptions (
+    consumer VARCHAR(100) NOT NULL,
+    outbox_message_id BIGINT NOT NULL REFERENCES outbox_messages(id) ON DELETE CASCADE,
+    consumed_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (consumer, outbox_message_id)
+);
+
+-- The counter was never maintained; it is kept up to date by an outbox consumer from now on
+UPDATE account SET subscription_count = (
+    SELECT COUNT(*) FROM subscription_details WHERE subscription_details.account_id = account.id
+);
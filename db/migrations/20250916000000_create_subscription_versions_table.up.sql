@@ -0,0 +1,18 @@
+-- Every stored state of a subscription. Rows are only ever inserted: before is NULL for the version that
+-- created the subscription, changes maps each changed field to its old and new value.
+CREATE TABLE IF NOT EXISTS subscription_versions (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_details_id INT NOT NULL REFERENCES subscription_details(id) ON DELETE CASCADE,
+    account_id INT NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    operation VARCHAR(20) NOT NULL CHECK (operation IN ('created', 'updated')),
+    before JSONB,
+    after JSONB NOT NULL,
+    changes JSONB NOT NULL DEFAULT '{}',
+    actor VARCHAR(20) NOT NULL CHECK (actor IN ('user', 'system')),
+    request_id VARCHAR(100),
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (subscription_details_id, version)
+);
+
+CREATE INDEX idx_subscription_versions_account_recorded_at ON subscription_versions(account_id, recorded_at);
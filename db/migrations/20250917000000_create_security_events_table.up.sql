@@ -0,0 +1,30 @@
+-- Append-only log of authentication and account events. account_id has no foreign key so entries
+-- outlive the account they belong to; email is what was entered, also for unknown accounts.
+CREATE TABLE IF NOT EXISTS security_events (
+    id BIGSERIAL PRIMARY KEY,
+    account_id INT,
+    email VARCHAR(255),
+    event_type VARCHAR(50) NOT NULL
+        CHECK (event_type IN ('signup', 'login', 'oauth_login', 'oauth_linked', 'logout', 'email_verified', 'password_reset_requested', 'account_updated')),
+    outcome VARCHAR(20) NOT NULL CHECK (outcome IN ('success', 'failure')),
+    reason VARCHAR(100),
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    request_id VARCHAR(100),
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_security_events_account_id ON security_events(account_id, id DESC);
+CREATE INDEX idx_security_events_created_at ON security_events(created_at);
+CREATE INDEX idx_security_events_ip_address ON security_events(ip_address, created_at);
+
+CREATE OR REPLACE FUNCTION reject_security_event_changes() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'security_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER security_events_append_only
+    BEFORE UPDATE OR DELETE ON security_events
+    FOR EACH ROW EXECUTE FUNCTION reject_security_event_changes();
@@ -0,0 +1,24 @@
+-- Monthly spending budgets, for all of an account's subscriptions or those of one category or tag.
+-- near_threshold_percent is the share of the amount from which spend counts as near the budget.
+CREATE TABLE IF NOT EXISTS budgets (
+    id SERIAL PRIMARY KEY,
+    account_id INT NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+    scope VARCHAR(20) NOT NULL CHECK (scope IN ('overall', 'category', 'tag')),
+    category_id INT REFERENCES categories(id) ON DELETE CASCADE,
+    tag_id INT REFERENCES tags(id) ON DELETE CASCADE,
+    amount NUMERIC(10, 2) NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    near_threshold_percent INT NOT NULL DEFAULT 80 CHECK (near_threshold_percent BETWEEN 1 AND 100),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (
+        (scope = 'overall' AND category_id IS NULL AND tag_id IS NULL)
+        OR (scope = 'category' AND category_id IS NOT NULL AND tag_id IS NULL)
+        OR (scope = 'tag' AND tag_id IS NOT NULL AND category_id IS NULL)
+    )
+);
+
+-- One budget per scope and target
+CREATE UNIQUE INDEX idx_budgets_overall ON budgets(account_id) WHERE scope = 'overall';
+CREATE UNIQUE INDEX idx_budgets_category ON budgets(account_id, category_id) WHERE scope = 'category';
+CREATE UNIQUE INDEX idx_budgets_tag ON budgets(account_id, tag_id) WHERE scope = 'tag';
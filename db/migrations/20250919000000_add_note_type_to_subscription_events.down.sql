@@ -0,0 +1,5 @@
+DELETE FROM subscription_events WHERE type = 'note';
+
+ALTER TABLE subscription_events DROP CONSTRAINT subscription_events_type_check;
+ALTER TABLE subscription_events ADD CONSTRAINT subscription_events_type_check
+    CHECK (type IN ('created', 'price_changed', 'paused', 'resumed', 'cancelled', 'charged', 'reminder_sent'));
@@ -0,0 +1,4 @@
+-- Users can only post notes; every other event type is recorded by the system
+ALTER TABLE subscription_events DROP CONSTRAINT subscription_events_type_check;
+ALTER TABLE subscription_events ADD CONSTRAINT subscription_events_type_check
+    CHECK (type IN ('created', 'price_changed', 'paused', 'resumed', 'cancelled', 'charged', 'reminder_sent', 'note'));
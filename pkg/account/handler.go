@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"subscritracker/pkg/application"
 	"subscritracker/pkg/models"
+	securitylog "subscritracker/pkg/security-log"
+	"subscritracker/pkg/validator"
 
 	"github.com/labstack/echo/v4"
 )
@@ -60,13 +62,29 @@ func UpdateAccountHandler(c echo.Context) error {
 	}
 
 	app := c.Get("app").(*application.App)
+	accountId := c.Get("user_id").(int)
 
-	err := UpdateAccount(app, &account)
+	// The caller can only update their own account, whatever id the body carries
+	stored, err := GetAccountById(app, accountId)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, err.Error())
 	}
+	account.ID = accountId
 
-	return c.JSON(http.StatusOK, account)
+	err = UpdateAccount(app, &account)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	stored.UpdatedAt = account.UpdatedAt
+	securitylog.Record(c, securitylog.Entry{
+		AccountID: stored.ID,
+		Email:     stored.Email,
+		EventType: securitylog.EventAccountUpdated,
+		Outcome:   securitylog.OutcomeSuccess,
+		Metadata:  map[string]interface{}{"fields": []string{"account"}},
+	})
+
+	return c.JSON(http.StatusOK, stored)
 }
 
 func GetAccountStatsHandler(c echo.Context) error {
@@ -91,6 +109,60 @@ func GetAccountStatsHandler(c echo.Context) error {
 	return c.JSON(http.StatusOK, stats)
 }
 
+// UpdateDefaultCurrencyHandler sets the currency the user's reports and stats are shown in
+func UpdateDefaultCurrencyHandler(c echo.Context) error {
+	accountId := c.Get("user_id").(int)
+
+	currencyCode, err := validator.ValidateDefaultCurrencyRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	app := c.Get("app").(*application.App)
+
+	account, err := UpdateAccountDefaultCurrency(app, accountId, currencyCode)
+	if err != nil {
+		log.Println("Error updating default currency:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update default currency"})
+	}
+	securitylog.Record(c, securitylog.Entry{
+		AccountID: accountId,
+		Email:     account.Email,
+		EventType: securitylog.EventAccountUpdated,
+		Outcome:   securitylog.OutcomeSuccess,
+		Metadata:  map[string]interface{}{"fields": []string{"default_currency"}},
+	})
+
+	return c.JSON(http.StatusOK, account)
+}
+
+// UpdateTimezoneHandler sets the timezone the user's reminders fire in
+func UpdateTimezoneHandler(c echo.Context) error {
+	accountId := c.Get("user_id").(int)
+
+	timezone, err := validator.ValidateTimezoneRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	app := c.Get("app").(*application.App)
+
+	account, err := UpdateAccountTimezone(app, accountId, timezone)
+	if err != nil {
+		log.Println("Error updating timezone:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update timezone"})
+	}
+	securitylog.Record(c, securitylog.Entry{
+		AccountID: accountId,
+		Email:     account.Email,
+		EventType: securitylog.EventAccountUpdated,
+		Outcome:   securitylog.OutcomeSuccess,
+		Metadata:  map[string]interface{}{"fields": []string{"timezone"}},
+	})
+
+	return c.JSON(http.StatusOK, account)
+}
+
 func CreateAccountHandler(c echo.Context) error {
 	var account models.Account
 
@@ -103,6 +175,13 @@ func CreateAccountHandler(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, err.Error())
 	}
+	securitylog.Record(c, securitylog.Entry{
+		AccountID: account.ID,
+		Email:     account.Email,
+		EventType: securitylog.EventSignup,
+		Outcome:   securitylog.OutcomeSuccess,
+		Metadata:  map[string]interface{}{"method": "api"},
+	})
 
 	return c.JSON(http.StatusOK, account)
 }
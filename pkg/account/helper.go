@@ -4,11 +4,19 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"log"
+	"math/big"
 	"subscritracker/pkg/application"
+	"subscritracker/pkg/currency"
 	"subscritracker/pkg/models"
+	"subscritracker/pkg/money"
+	pricehistory "subscritracker/pkg/price-history"
+	subscriptionevents "subscritracker/pkg/subscription-events"
 	"time"
+
+	"github.com/uptrace/bun"
 )
 
 func GetAccountById(app *application.App, id int) (*models.Account, error) {
@@ -88,18 +96,106 @@ func DeleteAccount(app *application.App, account *models.Account) error {
 	return err
 }
 
+// GetAccountStats returns subscription counts and the monthly spend converted to the account's currency.
+// monthly_spend normalizes every active subscription to a monthly amount based on its due type.
 func GetAccountStats(app *application.App, accountId int) (map[string]interface{}, error) {
+	account, err := GetAccountById(app, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions := []models.Subscription_Details{}
+	err = app.Database.NewSelect().
+		Model(&subscriptions).
+		Where("account_id = ?", accountId).
+		Scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	priceHistory, err := pricehistory.GetPriceHistoryForAccount(app, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	converter := currency.NewConverter(app.Database)
+	ratesUsed := currency.NewRatesUsed()
+	activeSubscriptions := 0
+	var monthlySpend money.Amount
+
+	for _, subscription := range subscriptions {
+		if subscription.Status != "active" {
+			continue
+		}
+		activeSubscriptions++
+
+		bill := pricehistory.ResolvePrice(subscription, priceHistory[subscription.ID], now)
+		converted, rate, err := converter.Convert(context.Background(), MonthlyEquivalent(bill, subscription.DueType), subscription.Currency, account.DefaultCurrency, now)
+		if err != nil {
+			return nil, err
+		}
+		ratesUsed.Add(rate)
+		monthlySpend += converted
+	}
+
 	stats := map[string]interface{}{
-		"total_subscriptions":  0,
-		"active_subscriptions": 0,
-		"monthly_spend":        0.00,
-		"tier":                 "free",
+		"total_subscriptions":  len(subscriptions),
+		"active_subscriptions": activeSubscriptions,
+		"monthly_spend":        monthlySpend,
+		"currency":             account.DefaultCurrency,
+		"rates_used":           ratesUsed.Rates,
+		"tier":                 account.Tier,
 		"features_used":        []string{"basic_tracking"},
 	}
 
 	return stats, nil
 }
 
+// MonthlyEquivalent converts the amount billed per cycle into an average monthly amount
+func MonthlyEquivalent(amount money.Amount, dueType string) money.Amount {
+	switch dueType {
+	case "yearly":
+		return amount.MulRat(big.NewRat(1, 12))
+	case "weekly":
+		return amount.MulRat(big.NewRat(52, 12))
+	case "daily":
+		return amount.MulRat(big.NewRat(365, 12))
+	default:
+		return amount
+	}
+}
+
+// UpdateAccountDefaultCurrency sets the currency reports and stats are converted to
+func UpdateAccountDefaultCurrency(app *application.App, accountId int, currencyCode string) (*models.Account, error) {
+	_, err := app.Database.NewUpdate().
+		Model((*models.Account)(nil)).
+		Set("default_currency = ?", currencyCode).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", accountId).
+		Exec(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return GetAccountById(app, accountId)
+}
+
+// UpdateAccountTimezone sets the IANA timezone reminders are scheduled in
+func UpdateAccountTimezone(app *application.App, accountId int, timezone string) (*models.Account, error) {
+	_, err := app.Database.NewUpdate().
+		Model((*models.Account)(nil)).
+		Set("timezone = ?", timezone).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", accountId).
+		Exec(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return GetAccountById(app, accountId)
+}
+
 // GetAccountByEmail retrieves an account by email
 func GetAccountByEmail(app *application.App, email string) (*models.Account, error) {
 	account := &models.Account{}
@@ -142,3 +238,22 @@ func GetAccountByVerificationToken(app *application.App, token string) (*models.
 
 	return account, nil
 }
+
+// RefreshSubscriptionCount is the outbox consumer that keeps subscription_count in step with the
+// account's subscriptions. It counts instead of incrementing so a missed message heals on the next one.
+func RefreshSubscriptionCount(ctx context.Context, tx bun.Tx, message models.Outbox_Message) error {
+	var event models.Subscription_Event
+	if err := json.Unmarshal(message.Payload, &event); err != nil {
+		return err
+	}
+	if event.Type != subscriptionevents.EventCreated {
+		return nil
+	}
+
+	_, err := tx.NewRaw(`
+		UPDATE account
+		SET subscription_count = (SELECT COUNT(*) FROM subscription_details WHERE account_id = ?)
+		WHERE id = ?
+	`, event.AccountID, event.AccountID).Exec(ctx)
+	return err
+}
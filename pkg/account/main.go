@@ -10,5 +10,7 @@ func RegisterRoutes(app *application.App) {
 	app.Echo.GET("/v1/account", GetAccountHandler, utils.AuthMiddleware)
 	app.Echo.PUT("/v1/account", UpdateAccountHandler, utils.AuthMiddleware)
 	app.Echo.GET("/v1/account/stats", GetAccountStatsHandler, utils.AuthMiddleware)
+	app.Echo.PUT("/v1/account/default-currency", UpdateDefaultCurrencyHandler, utils.AuthMiddleware)
+	app.Echo.PUT("/v1/account/timezone", UpdateTimezoneHandler, utils.AuthMiddleware)
 	app.Echo.POST("/v1/account", CreateAccountHandler)
 }
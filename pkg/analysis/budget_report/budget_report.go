@@ -0,0 +1,83 @@
+package budget_report
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	accountpkg "subscritracker/pkg/account"
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/validator"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetBudgetReportHandler lists the status of every budget of the user for a month, the current one by default
+func GetBudgetReportHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	month, today, response := monthFromQuery(c, app, accountID)
+	if month == nil {
+		return response
+	}
+
+	statuses, err := GetBudgetReport(context.Background(), app, accountID, 0, month.ParsedMonth, today)
+	if err != nil {
+		log.Printf("Error getting budget report: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get budget report"})
+	}
+
+	return c.JSON(http.StatusOK, BudgetReport{
+		Month:    month.ParsedMonth.Format("2006-01"),
+		Timezone: month.Location.String(),
+		Budgets:  statuses,
+	})
+}
+
+// GetBudgetStatusHandler returns the status of one budget for a month, the current one by default
+func GetBudgetStatusHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	budgetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid budget ID"})
+	}
+
+	month, today, response := monthFromQuery(c, app, accountID)
+	if month == nil {
+		return response
+	}
+
+	statuses, err := GetBudgetReport(context.Background(), app, accountID, budgetID, month.ParsedMonth, today)
+	if err != nil {
+		log.Printf("Error getting budget status: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get budget status"})
+	}
+	if len(statuses) == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "budget not found"})
+	}
+
+	return c.JSON(http.StatusOK, statuses[0])
+}
+
+// monthFromQuery parses the month and timezone of a budget report, defaulting to the account's timezone,
+// and returns today there. When the month is nil the error response has been written.
+func monthFromQuery(c echo.Context, app *application.App, accountID int) (*validator.MonthRequest, time.Time, error) {
+	accountDetails, err := accountpkg.GetAccountById(app, accountID)
+	if err != nil {
+		log.Printf("Error getting account: %v", err)
+		return nil, time.Time{}, c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get account"})
+	}
+
+	month, err := validator.ValidateMonthRequest(c, accountDetails.Timezone)
+	if err != nil {
+		return nil, time.Time{}, c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	now := time.Now().In(month.Location)
+	return month, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), nil
+}
@@ -0,0 +1,325 @@
+package budget_report
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"subscritracker/pkg/analysis/monthly_report"
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/currency"
+	"subscritracker/pkg/models"
+	"subscritracker/pkg/money"
+	"subscritracker/pkg/notifications"
+	pricehistory "subscritracker/pkg/price-history"
+	"subscritracker/pkg/recurrence"
+
+	"github.com/uptrace/bun"
+)
+
+/*
+**
+GetBudgetReport compares the account's budgets, or the one with budgetID when it is not 0, with the
+spend of the month starting at monthStart. today decides which charges have happened; for past months
+every charge has and the forecast is what was spent.
+**
+*/
+func GetBudgetReport(ctx context.Context, app *application.App, accountID, budgetID int, monthStart, today time.Time) ([]BudgetStatus, error) {
+	budgets := []models.Budget{}
+	query := app.Database.NewSelect().
+		Model(&budgets).
+		Where("account_id = ?", accountID)
+	if budgetID != 0 {
+		query = query.Where("id = ?", budgetID)
+	}
+	if err := query.OrderExpr("scope = 'overall' DESC, id ASC").Scan(ctx); err != nil {
+		return nil, err
+	}
+	if len(budgets) == 0 {
+		return []BudgetStatus{}, nil
+	}
+
+	names, err := budgetNames(ctx, app.Database, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	charges, err := monthCharges(ctx, app, accountID, monthStart)
+	if err != nil {
+		return nil, err
+	}
+
+	converter := currency.NewConverter(app.Database)
+	statuses := []BudgetStatus{}
+	for _, budget := range budgets {
+		status, err := evaluate(ctx, converter, budget, charges, today)
+		if err != nil {
+			return nil, err
+		}
+		status.Name = OverallName
+		switch {
+		case budget.CategoryID != nil:
+			status.Name = names["category"][*budget.CategoryID]
+		case budget.TagID != nil:
+			status.Name = names["tag"][*budget.TagID]
+		}
+		statuses = append(statuses, *status)
+	}
+
+	return statuses, nil
+}
+
+// evaluate totals the charges a budget covers in its currency and works out its status
+func evaluate(ctx context.Context, converter *currency.Converter, budget models.Budget, charges []charge, today time.Time) (*BudgetStatus, error) {
+	status := &BudgetStatus{Budget: budget}
+	ratesUsed := currency.NewRatesUsed()
+
+	for _, charge := range charges {
+		if !covers(budget, charge) {
+			continue
+		}
+
+		amount, rate, err := converter.Convert(ctx, charge.Amount, charge.Currency, budget.Currency, charge.Date)
+		if err != nil {
+			return nil, err
+		}
+		ratesUsed.Add(rate)
+
+		status.Charges++
+		status.ForecastSpend += amount
+		if !charge.Date.After(today) {
+			status.ActualSpend += amount
+		}
+	}
+
+	status.Remaining = budget.Amount - status.ForecastSpend
+	status.ActualPercent = percentOf(status.ActualSpend, budget)
+	status.ForecastPercent = percentOf(status.ForecastSpend, budget)
+	status.ActualStatus = statusFor(status.ActualPercent, budget)
+	status.Status = statusFor(status.ForecastPercent, budget)
+	status.RatesUsed = ratesUsed.Rates
+
+	return status, nil
+}
+
+// covers reports whether a charge counts against a budget
+func covers(budget models.Budget, charge charge) bool {
+	switch {
+	case budget.CategoryID != nil:
+		return charge.CategoryID != nil && *charge.CategoryID == *budget.CategoryID
+	case budget.TagID != nil:
+		return charge.TagIDs[*budget.TagID]
+	default:
+		return true
+	}
+}
+
+func percentOf(spend money.Amount, budget models.Budget) float64 {
+	if budget.Amount <= 0 {
+		return 0
+	}
+	return math.Round(float64(spend)/float64(budget.Amount)*10000) / 100
+}
+
+// statusFor is over past the budget, near from its near threshold on, and under before that
+func statusFor(percent float64, budget models.Budget) string {
+	switch {
+	case percent > 100:
+		return StatusOver
+	case percent >= float64(budget.NearThresholdPercent):
+		return StatusNear
+	default:
+		return StatusUnder
+	}
+}
+
+/*
+**
+monthCharges expands every charge of the account's subscriptions in the month starting at monthStart,
+at the price effective on its date. Active subscriptions bill throughout; stopped ones up to the
+day they stopped.
+**
+*/
+func monthCharges(ctx context.Context, app *application.App, accountID int, monthStart time.Time) ([]charge, error) {
+	monthEnd := monthStart.AddDate(0, 1, -1)
+
+	sources, err := monthly_report.LoadChargeSources(app, accountID)
+	if err != nil {
+		return nil, err
+	}
+	subscriptions := sources.Subscriptions
+	if len(subscriptions) == 0 {
+		return []charge{}, nil
+	}
+
+	subscriptionIDs := make([]int, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		subscriptionIDs = append(subscriptionIDs, subscription.ID)
+	}
+	subscriptionTags := []models.Subscription_Details_Tag{}
+	err = app.Database.NewSelect().
+		Model(&subscriptionTags).
+		Where("subscription_details_id IN (?)", bun.In(subscriptionIDs)).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tagsBySubscription := map[int]map[int]bool{}
+	for _, subscriptionTag := range subscriptionTags {
+		if tagsBySubscription[subscriptionTag.SubscriptionDetailsID] == nil {
+			tagsBySubscription[subscriptionTag.SubscriptionDetailsID] = map[int]bool{}
+		}
+		tagsBySubscription[subscriptionTag.SubscriptionDetailsID][subscriptionTag.TagID] = true
+	}
+
+	charges := []charge{}
+	for _, subscription := range subscriptions {
+		for _, date := range recurrence.BilledOccurrences(subscription, sources.StopEvents[subscription.ID], monthStart, monthEnd) {
+			charges = append(charges, charge{
+				SubscriptionDetailsID: subscription.ID,
+				CategoryID:            subscription.CategoryID,
+				TagIDs:                tagsBySubscription[subscription.ID],
+				Date:                  date,
+				Amount:                pricehistory.ResolvePrice(subscription, sources.PriceHistory[subscription.ID], date),
+				Currency:              subscription.Currency,
+			})
+		}
+	}
+
+	return charges, nil
+}
+
+// budgetNames returns the names of the account's categories and tags, by kind and id
+func budgetNames(ctx context.Context, db bun.IDB, accountID int) (map[string]map[int]string, error) {
+	rows := []struct {
+		Kind string `bun:"kind"`
+		ID   int    `bun:"id"`
+		Name string `bun:"name"`
+	}{}
+	err := db.NewRaw(`
+		SELECT 'category' AS kind, id, name FROM categories WHERE account_id = ?
+		UNION ALL
+		SELECT 'tag' AS kind, id, name FROM tags WHERE account_id = ?
+	`, accountID, accountID).Scan(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]map[int]string{"category": {}, "tag": {}}
+	for _, row := range rows {
+		names[row.Kind][row.ID] = row.Name
+	}
+	return names, nil
+}
+
+/*
+**
+NotifyBudgetAlerts checks the budgets of every account against the current month in the account's
+timezone and sends a budget_alert when a budget's forecast is near or over it. Each budget is notified
+once per month and status, so one that goes from near to over is notified again.
+It returns how many budgets were notified.
+**
+*/
+func NotifyBudgetAlerts(ctx context.Context, app *application.App, now time.Time) (int, error) {
+	accounts := []budgetAccount{}
+	err := app.Database.NewRaw(`
+		SELECT DISTINCT b.account_id, a.timezone
+		FROM budgets b
+		JOIN account a ON a.id = b.account_id
+	`).Scan(ctx, &accounts)
+	if err != nil {
+		return 0, err
+	}
+
+	notified := 0
+	for _, account := range accounts {
+		location, err := time.LoadLocation(account.Timezone)
+		if err != nil {
+			location = time.UTC
+		}
+		local := now.In(location)
+		today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+		monthStart := time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+		statuses, err := GetBudgetReport(ctx, app, account.AccountID, 0, monthStart, today)
+		if err != nil {
+			log.Printf("Error checking budgets of account %d: %v", account.AccountID, err)
+			continue
+		}
+
+		for _, status := range statuses {
+			if status.Status == StatusUnder {
+				continue
+			}
+			if _, err := notifications.Notify(ctx, app.Database, account.AccountID, budgetAlert(status, monthStart, app.Config.Frontend.URL), now); err != nil {
+				log.Printf("Error notifying budget %d: %v", status.Budget.ID, err)
+				continue
+			}
+			notified++
+		}
+	}
+
+	return notified, nil
+}
+
+func budgetAlert(status BudgetStatus, monthStart time.Time, frontendURL string) notifications.Event {
+	budget := status.Budget
+	month := monthStart.Format("January")
+
+	var title, body string
+	switch {
+	case status.ActualStatus == StatusOver:
+		title = fmt.Sprintf("%s budget exceeded", status.Name)
+		body = fmt.Sprintf("You have spent %s %s of your %s %s %s budget for %s.",
+			status.ActualSpend.String(), budget.Currency, budget.Amount.String(), budget.Currency, status.Name, month)
+	case status.Status == StatusOver:
+		title = fmt.Sprintf("%s budget will be exceeded", status.Name)
+		body = fmt.Sprintf("Your subscriptions will charge %s %s in %s, over your %s %s %s budget.",
+			status.ForecastSpend.String(), budget.Currency, month, budget.Amount.String(), budget.Currency, status.Name)
+	default:
+		title = fmt.Sprintf("%s budget almost reached", status.Name)
+		body = fmt.Sprintf("Your subscriptions will charge %s %s in %s, %.0f%% of your %s %s %s budget.",
+			status.ForecastSpend.String(), budget.Currency, month, status.ForecastPercent, budget.Amount.String(), budget.Currency, status.Name)
+	}
+
+	return notifications.Event{
+		Type:      notifications.EventBudgetAlert,
+		DedupeKey: fmt.Sprintf("budget_alert:%d:%s:%s", budget.ID, monthStart.Format("2006-01"), status.Status),
+		Title:     title,
+		Body:      body,
+		URL:       frontendURL,
+		Data: map[string]interface{}{
+			"budget_id":      budget.ID,
+			"month":          monthStart.Format("2006-01"),
+			"status":         status.Status,
+			"actual_spend":   status.ActualSpend,
+			"forecast_spend": status.ForecastSpend,
+			"amount":         budget.Amount,
+			"currency":       budget.Currency,
+		},
+	}
+}
+
+// StartBudgetAlertWorker checks budgets once at startup and then every interval
+func StartBudgetAlertWorker(ctx context.Context, app *application.App, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if notified, err := NotifyBudgetAlerts(ctx, app, time.Now()); err != nil {
+				log.Printf("Error checking budget alerts: %v", err)
+			} else if notified > 0 {
+				log.Printf("Sent %d budget alerts", notified)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,58 @@
+package budget_report
+
+import (
+	"time"
+
+	"subscritracker/pkg/currency"
+	"subscritracker/pkg/models"
+	"subscritracker/pkg/money"
+)
+
+// Budget statuses
+const (
+	StatusUnder = "under"
+	StatusNear  = "near"
+	StatusOver  = "over"
+)
+
+// OverallName names the budget that covers every subscription
+const OverallName = "Overall"
+
+// BudgetStatus compares a budget with the month's spend in the budget's currency. ActualSpend is what
+// was charged up to today, ForecastSpend what the whole month will charge; Status is the forecast's,
+// so a budget that will be crossed before the month ends is already near or over.
+type BudgetStatus struct {
+	Budget          models.Budget          `json:"budget"`
+	Name            string                 `json:"name"`
+	ActualSpend     money.Amount           `json:"actual_spend"`
+	ForecastSpend   money.Amount           `json:"forecast_spend"`
+	Remaining       money.Amount           `json:"remaining"`
+	ActualPercent   float64                `json:"actual_percent"`
+	ForecastPercent float64                `json:"forecast_percent"`
+	ActualStatus    string                 `json:"actual_status"`
+	Status          string                 `json:"status"`
+	Charges         int                    `json:"charges"`
+	RatesUsed       []currency.AppliedRate `json:"rates_used"`
+}
+
+type BudgetReport struct {
+	Month    string         `json:"month"`
+	Timezone string         `json:"timezone"`
+	Budgets  []BudgetStatus `json:"budgets"`
+}
+
+// charge is one billing of a subscription in the month, in the subscription's currency
+type charge struct {
+	SubscriptionDetailsID int
+	CategoryID            *int
+	TagIDs                map[int]bool
+	Date                  time.Time
+	Amount                money.Amount
+	Currency              string
+}
+
+// budgetAccount is an account with budgets, for the alert worker
+type budgetAccount struct {
+	AccountID int    `bun:"account_id"`
+	Timezone  string `bun:"timezone"`
+}
@@ -0,0 +1,37 @@
+package category_report
+
+import (
+	"log"
+	"net/http"
+	accountpkg "subscritracker/pkg/account"
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/validator"
+
+	"github.com/labstack/echo/v4"
+)
+
+func GetSpendByCategoryHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	targetCurrency, err := validator.ValidateReportCurrency(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if targetCurrency == "" {
+		accountDetails, err := accountpkg.GetAccountById(app, accountID)
+		if err != nil {
+			log.Printf("Error getting account: %v", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get account"})
+		}
+		targetCurrency = accountDetails.DefaultCurrency
+	}
+
+	report, err := GetSpendByCategory(app, accountID, targetCurrency)
+	if err != nil {
+		log.Printf("Error getting spend by category: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get spend by category"})
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
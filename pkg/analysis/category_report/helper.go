@@ -0,0 +1,105 @@
+package category_report
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	accountpkg "subscritracker/pkg/account"
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/currency"
+	"subscritracker/pkg/models"
+	pricehistory "subscritracker/pkg/price-history"
+)
+
+/*
+**
+GetSpendByCategory sums the monthly equivalent of every active subscription's current price
+per category, converted to targetCurrency. Subscriptions without a category are grouped as Uncategorized.
+**
+*/
+func GetSpendByCategory(app *application.App, accountId int, targetCurrency string) (*CategoryReport, error) {
+	subscriptions := []models.Subscription_Details{}
+	err := app.Database.NewSelect().
+		Model(&subscriptions).
+		Where("account_id = ? AND status = ?", accountId, "active").
+		Scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	categories := []models.Category{}
+	err = app.Database.NewSelect().
+		Model(&categories).
+		Where("account_id = ?", accountId).
+		Scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	priceHistory, err := pricehistory.GetPriceHistoryForAccount(app, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	converter := currency.NewConverter(app.Database)
+	ratesUsed := currency.NewRatesUsed()
+
+	spendByCategory := map[int]*CategorySpend{}
+	for _, category := range categories {
+		categoryID := category.ID
+		spendByCategory[category.ID] = &CategorySpend{
+			CategoryID:   &categoryID,
+			CategoryName: category.Name,
+			Color:        category.Color,
+		}
+	}
+	uncategorized := &CategorySpend{CategoryName: UncategorizedName}
+
+	report := &CategoryReport{Currency: targetCurrency}
+	for _, subscription := range subscriptions {
+		bill := pricehistory.ResolvePrice(subscription, priceHistory[subscription.ID], now)
+		monthly, rate, err := converter.Convert(context.Background(), accountpkg.MonthlyEquivalent(bill, subscription.DueType), subscription.Currency, targetCurrency, now)
+		if err != nil {
+			return nil, err
+		}
+		ratesUsed.Add(rate)
+
+		spend := uncategorized
+		if subscription.CategoryID != nil {
+			if categorySpend, ok := spendByCategory[*subscription.CategoryID]; ok {
+				spend = categorySpend
+			}
+		}
+		spend.SubscriptionCount++
+		spend.MonthlySpend += monthly
+		report.TotalSpend += monthly
+	}
+
+	report.Categories = []CategorySpend{}
+	for _, spend := range spendByCategory {
+		report.Categories = append(report.Categories, *spend)
+	}
+	if uncategorized.SubscriptionCount > 0 {
+		report.Categories = append(report.Categories, *uncategorized)
+	}
+
+	for i := range report.Categories {
+		if report.TotalSpend > 0 {
+			report.Categories[i].Percent = math.Round(float64(report.Categories[i].MonthlySpend)/float64(report.TotalSpend)*10000) / 100
+		}
+	}
+
+	// Highest spend first, ties by name so the order is stable
+	sort.Slice(report.Categories, func(i, j int) bool {
+		if report.Categories[i].MonthlySpend != report.Categories[j].MonthlySpend {
+			return report.Categories[i].MonthlySpend > report.Categories[j].MonthlySpend
+		}
+		return report.Categories[i].CategoryName < report.Categories[j].CategoryName
+	})
+
+	report.RatesUsed = ratesUsed.Rates
+	return report, nil
+}
@@ -0,0 +1,25 @@
+package category_report
+
+import (
+	"subscritracker/pkg/currency"
+	"subscritracker/pkg/money"
+)
+
+// UncategorizedName is used for subscriptions without a category
+const UncategorizedName = "Uncategorized"
+
+type CategorySpend struct {
+	CategoryID        *int         `json:"category_id"`
+	CategoryName      string       `json:"category_name"`
+	Color             string       `json:"color,omitempty"`
+	SubscriptionCount int          `json:"subscription_count"`
+	MonthlySpend      money.Amount `json:"monthly_spend"`
+	Percent           float64      `json:"percent"`
+}
+
+type CategoryReport struct {
+	Currency   string                 `json:"currency"`
+	TotalSpend money.Amount           `json:"total_spend"`
+	Categories []CategorySpend        `json:"categories"`
+	RatesUsed  []currency.AppliedRate `json:"rates_used"`
+}
@@ -0,0 +1,51 @@
+package comparison_report
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	accountpkg "subscritracker/pkg/account"
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/validator"
+
+	"github.com/labstack/echo/v4"
+)
+
+func GetComparisonHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	accountDetails, err := accountpkg.GetAccountById(app, accountID)
+	if err != nil {
+		log.Printf("Error getting account: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get account"})
+	}
+
+	// The current month and year are the ones of today in the user's timezone
+	location, err := time.LoadLocation(accountDetails.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	request, err := validator.ValidateComparisonRequest(c, time.Now().In(location))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	targetCurrency, err := validator.ValidateReportCurrency(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if targetCurrency == "" {
+		targetCurrency = accountDetails.DefaultCurrency
+	}
+
+	comparison, err := GetComparison(app, accountID, request, targetCurrency)
+	if err != nil {
+		log.Printf("Error comparing periods: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to compare periods"})
+	}
+
+	return c.JSON(http.StatusOK, comparison)
+}
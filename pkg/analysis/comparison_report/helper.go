@@ -0,0 +1,217 @@
+package comparison_report
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"subscritracker/pkg/analysis/monthly_report"
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/currency"
+	"subscritracker/pkg/models"
+	"subscritracker/pkg/money"
+	pricehistory "subscritracker/pkg/price-history"
+	"subscritracker/pkg/recurrence"
+	subscriptionchannels "subscritracker/pkg/subscription-channels"
+	"subscritracker/pkg/utils"
+	"subscritracker/pkg/validator"
+)
+
+// comparisonData is everything of an account the comparison is computed from
+type comparisonData struct {
+	*monthly_report.ChargeSources
+	channels map[int]models.Subscription_Channels
+}
+
+/*
+**
+GetComparison compares the account's spend and subscriptions between two periods. Charges follow each
+subscription's recurrence at the price effective on their date; subscriptions that are no longer
+active bill up to the day they stopped, as recurrence.BilledOccurrences has it.
+**
+*/
+func GetComparison(app *application.App, accountId int, request *validator.ComparisonRequest, targetCurrency string) (*Comparison, error) {
+	ctx := context.Background()
+	data, err := loadComparisonData(ctx, app, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	converter := currency.NewConverter(app.Database)
+	ratesUsed := currency.NewRatesUsed()
+
+	current, currentByChannel, err := summarize(ctx, data, converter, ratesUsed, targetCurrency, request.ParsedFrom, request.ParsedTo)
+	if err != nil {
+		return nil, err
+	}
+	previous, previousByChannel, err := summarize(ctx, data, converter, ratesUsed, targetCurrency, request.ParsedPrevFrom, request.ParsedPrevTo)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &Comparison{
+		Period:   request.Period,
+		Currency: targetCurrency,
+		Current:  *current,
+		Previous: *previous,
+		Changes: Changes{
+			TotalSpend:             current.TotalSpend - previous.TotalSpend,
+			TotalSpendPercent:      percentChange(previous.TotalSpend, current.TotalSpend),
+			ActiveSubscriptions:    current.ActiveSubscriptions - previous.ActiveSubscriptions,
+			NewSubscriptions:       current.NewSubscriptions - previous.NewSubscriptions,
+			CancelledSubscriptions: current.CancelledSubscriptions - previous.CancelledSubscriptions,
+			PriceIncreases:         len(current.PriceIncreases) - len(previous.PriceIncreases),
+		},
+		TopMovers: topMovers(data.channels, currentByChannel, previousByChannel),
+		RatesUsed: ratesUsed.Rates,
+	}
+
+	return comparison, nil
+}
+
+func loadComparisonData(ctx context.Context, app *application.App, accountId int) (*comparisonData, error) {
+	sources, err := monthly_report.LoadChargeSources(app, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	channels, err := subscriptionchannels.GetChannelsBySubscription(ctx, app.Database, sources.Subscriptions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &comparisonData{ChargeSources: sources, channels: channels}, nil
+}
+
+// summarize works out what happened between from and to, inclusive, along with the spend per channel
+func summarize(ctx context.Context, data *comparisonData, converter *currency.Converter, ratesUsed *currency.RatesUsed, targetCurrency string, from, to time.Time) (*PeriodSummary, map[int]money.Amount, error) {
+	summary := &PeriodSummary{From: from, To: to, PriceIncreases: []PriceIncrease{}}
+	byChannel := map[int]money.Amount{}
+
+	for _, subscription := range data.Subscriptions {
+		history := data.PriceHistory[subscription.ID]
+		stopEvents := data.StopEvents[subscription.ID]
+		started := utils.TruncateToDay(subscription.CreatedAt)
+		if start := utils.TruncateToDay(subscription.StartDate); !subscription.StartDate.IsZero() && start.Before(started) {
+			started = start
+		}
+		stopped, isStopped := recurrence.StoppedOn(subscription, stopEvents)
+
+		if !started.After(to) && (subscription.Status == "active" || (isStopped && !stopped.Before(from))) {
+			summary.ActiveSubscriptions++
+		}
+		if inPeriod(subscription.CreatedAt, from, to) {
+			summary.NewSubscriptions++
+		}
+		if subscription.Status == "cancelled" && isStopped && inPeriod(stopped, from, to) {
+			summary.CancelledSubscriptions++
+		}
+
+		for _, chargeDate := range recurrence.BilledOccurrences(subscription, stopEvents, from, to) {
+			original := pricehistory.ResolvePrice(subscription, history, chargeDate)
+			amount, rate, err := converter.Convert(ctx, original, subscription.Currency, targetCurrency, chargeDate)
+			if err != nil {
+				return nil, nil, err
+			}
+			ratesUsed.Add(rate)
+
+			summary.TotalSpend += amount
+			summary.Charges++
+			byChannel[subscription.SubscriptionChannelID] += amount
+		}
+
+		for _, entry := range history {
+			if !inPeriod(entry.EffectiveDate, from, to) {
+				continue
+			}
+			previous := pricehistory.RegularPriceOn(subscription, history, entry.EffectiveDate.AddDate(0, 0, -1))
+			if entry.MonthlyBill <= previous {
+				continue
+			}
+			increase := PriceIncrease{
+				SubscriptionDetailsID: subscription.ID,
+				ChannelName:           data.channels[subscription.SubscriptionChannelID].ChannelName,
+				EffectiveDate:         utils.TruncateToDay(entry.EffectiveDate),
+				PreviousAmount:        previous,
+				Amount:                entry.MonthlyBill,
+				Currency:              subscription.Currency,
+			}
+			if change := percentChange(previous, entry.MonthlyBill); change != nil {
+				increase.ChangePercent = *change
+			}
+			summary.PriceIncreases = append(summary.PriceIncreases, increase)
+		}
+	}
+
+	sort.SliceStable(summary.PriceIncreases, func(i, j int) bool {
+		return summary.PriceIncreases[i].EffectiveDate.Before(summary.PriceIncreases[j].EffectiveDate)
+	})
+
+	return summary, byChannel, nil
+}
+
+// topMovers returns the channels whose spend changed the most between the periods, in either direction
+func topMovers(channels map[int]models.Subscription_Channels, current, previous map[int]money.Amount) []ChannelMover {
+	movers := []ChannelMover{}
+	seen := map[int]bool{}
+	for _, spend := range []map[int]money.Amount{current, previous} {
+		for channelID := range spend {
+			if seen[channelID] {
+				continue
+			}
+			seen[channelID] = true
+
+			mover := ChannelMover{
+				SubscriptionChannelID: channelID,
+				ChannelName:           channels[channelID].ChannelName,
+				CurrentSpend:          current[channelID],
+				PreviousSpend:         previous[channelID],
+				Change:                current[channelID] - previous[channelID],
+				ChangePercent:         percentChange(previous[channelID], current[channelID]),
+			}
+			if mover.Change != 0 {
+				movers = append(movers, mover)
+			}
+		}
+	}
+
+	// Largest change first, ties by name so the order is stable
+	sort.Slice(movers, func(i, j int) bool {
+		left, right := absAmount(movers[i].Change), absAmount(movers[j].Change)
+		if left != right {
+			return left > right
+		}
+		if movers[i].ChannelName != movers[j].ChannelName {
+			return movers[i].ChannelName < movers[j].ChannelName
+		}
+		return movers[i].SubscriptionChannelID < movers[j].SubscriptionChannelID
+	})
+	if len(movers) > topMoversCount {
+		movers = movers[:topMoversCount]
+	}
+
+	return movers
+}
+
+// percentChange is the change from before to after in percent, or nil when before is zero
+func percentChange(before, after money.Amount) *float64 {
+	if before == 0 {
+		return nil
+	}
+	change := math.Round(float64(after-before)/float64(before)*10000) / 100
+	return &change
+}
+
+func absAmount(amount money.Amount) money.Amount {
+	if amount < 0 {
+		return -amount
+	}
+	return amount
+}
+
+// inPeriod reports whether t falls on a day between from and to, inclusive
+func inPeriod(t, from, to time.Time) bool {
+	day := utils.TruncateToDay(t)
+	return !day.Before(from) && !day.After(to)
+}
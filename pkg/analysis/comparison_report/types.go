@@ -0,0 +1,65 @@
+package comparison_report
+
+import (
+	"time"
+
+	"subscritracker/pkg/currency"
+	"subscritracker/pkg/money"
+)
+
+// topMoversCount is how many channels are listed as top movers
+const topMoversCount = 5
+
+// PriceIncrease is a regular price that went up in the period, in the subscription's currency
+type PriceIncrease struct {
+	SubscriptionDetailsID int          `json:"subscription_details_id"`
+	ChannelName           string       `json:"channel_name"`
+	EffectiveDate         time.Time    `json:"effective_date"`
+	PreviousAmount        money.Amount `json:"previous_amount"`
+	Amount                money.Amount `json:"amount"`
+	Currency              string       `json:"currency"`
+	ChangePercent         float64      `json:"change_percent"`
+}
+
+// PeriodSummary is what happened in one period. ActiveSubscriptions counts the subscriptions that were
+// running for at least part of it; New and Cancelled the ones created and cancelled in it.
+type PeriodSummary struct {
+	From                   time.Time       `json:"from"`
+	To                     time.Time       `json:"to"`
+	TotalSpend             money.Amount    `json:"total_spend"`
+	Charges                int             `json:"charges"`
+	ActiveSubscriptions    int             `json:"active_subscriptions"`
+	NewSubscriptions       int             `json:"new_subscriptions"`
+	CancelledSubscriptions int             `json:"cancelled_subscriptions"`
+	PriceIncreases         []PriceIncrease `json:"price_increases"`
+}
+
+// Changes is the current period minus the previous one. TotalSpendPercent is null when nothing was spent before.
+type Changes struct {
+	TotalSpend             money.Amount `json:"total_spend"`
+	TotalSpendPercent      *float64     `json:"total_spend_percent"`
+	ActiveSubscriptions    int          `json:"active_subscriptions"`
+	NewSubscriptions       int          `json:"new_subscriptions"`
+	CancelledSubscriptions int          `json:"cancelled_subscriptions"`
+	PriceIncreases         int          `json:"price_increases"`
+}
+
+// ChannelMover is a channel whose spend changed between the periods
+type ChannelMover struct {
+	SubscriptionChannelID int          `json:"subscription_channel_id"`
+	ChannelName           string       `json:"channel_name"`
+	CurrentSpend          money.Amount `json:"current_spend"`
+	PreviousSpend         money.Amount `json:"previous_spend"`
+	Change                money.Amount `json:"change"`
+	ChangePercent         *float64     `json:"change_percent"`
+}
+
+type Comparison struct {
+	Period    string                 `json:"period"`
+	Currency  string                 `json:"currency"`
+	Current   PeriodSummary          `json:"current"`
+	Previous  PeriodSummary          `json:"previous"`
+	Changes   Changes                `json:"changes"`
+	TopMovers []ChannelMover         `json:"top_movers"`
+	RatesUsed []currency.AppliedRate `json:"rates_used"`
+}
@@ -0,0 +1,51 @@
+package forecast_report
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	accountpkg "subscritracker/pkg/account"
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/validator"
+
+	"github.com/labstack/echo/v4"
+)
+
+func GetForecastHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	months, err := validator.ValidateForecastMonths(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	targetCurrency, err := validator.ValidateReportCurrency(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	accountDetails, err := accountpkg.GetAccountById(app, accountID)
+	if err != nil {
+		log.Printf("Error getting account: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get account"})
+	}
+	if targetCurrency == "" {
+		targetCurrency = accountDetails.DefaultCurrency
+	}
+
+	// The forecast starts today in the user's timezone
+	location, err := time.LoadLocation(accountDetails.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	forecast, err := GetForecast(app, accountID, time.Now().In(location), months, targetCurrency)
+	if err != nil {
+		log.Printf("Error getting forecast: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get forecast"})
+	}
+
+	return c.JSON(http.StatusOK, forecast)
+}
@@ -0,0 +1,242 @@
+package forecast_report
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"subscritracker/pkg/analysis/monthly_report"
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/currency"
+	"subscritracker/pkg/models"
+	"subscritracker/pkg/money"
+	pricehistory "subscritracker/pkg/price-history"
+	"subscritracker/pkg/recurrence"
+	subscriptionchannels "subscritracker/pkg/subscription-channels"
+	"subscritracker/pkg/utils"
+	"subscritracker/pkg/validator"
+)
+
+/*
+**
+GetForecast projects every charge of the account's subscriptions from today to the end of the month
+months-1 months ahead, so the first month only has what is still to come. Charges follow each
+subscription's recurrence at the price effective on their date, which accounts for scheduled price
+changes, the end of trials and intro prices, and end dates. Active subscriptions keep billing; stopped
+ones only until the day they stopped, when that is still ahead.
+**
+*/
+func GetForecast(app *application.App, accountId int, today time.Time, months int, targetCurrency string) (*Forecast, error) {
+	from := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	firstMonth := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := firstMonth.AddDate(0, months, -1)
+
+	sources, err := monthly_report.LoadChargeSources(app, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	channels, err := subscriptionchannels.GetChannelsBySubscription(context.Background(), app.Database, sources.Subscriptions)
+	if err != nil {
+		return nil, err
+	}
+
+	categories := []models.Category{}
+	err = app.Database.NewSelect().
+		Model(&categories).
+		Where("account_id = ?", accountId).
+		Scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	categoriesByID := map[int]models.Category{}
+	for _, category := range categories {
+		categoriesByID[category.ID] = category
+	}
+
+	converter := currency.NewConverter(app.Database)
+	ratesUsed := currency.NewRatesUsed()
+
+	forecast := &Forecast{
+		From:     from,
+		To:       to,
+		Currency: targetCurrency,
+		Months:   []ForecastMonth{},
+		Events:   []ForecastEvent{},
+	}
+	overall := newSpendTotals()
+	monthly := []*spendTotals{}
+	for month := firstMonth; !month.After(to); month = month.AddDate(0, 1, 0) {
+		forecast.Months = append(forecast.Months, ForecastMonth{Month: int(month.Month()), Year: month.Year()})
+		monthly = append(monthly, newSpendTotals())
+	}
+
+	for _, subscription := range sources.Subscriptions {
+		history := sources.PriceHistory[subscription.ID]
+		channelName := channels[subscription.SubscriptionChannelID].ChannelName
+
+		category := models.Category{Name: UncategorizedName}
+		if subscription.CategoryID != nil {
+			if found, ok := categoriesByID[*subscription.CategoryID]; ok {
+				category = found
+			}
+		}
+
+		for _, chargeDate := range recurrence.BilledOccurrences(subscription, sources.StopEvents[subscription.ID], from, to) {
+			original := pricehistory.ResolvePrice(subscription, history, chargeDate)
+			amount, rate, err := converter.Convert(context.Background(), original, subscription.Currency, targetCurrency, chargeDate)
+			if err != nil {
+				return nil, err
+			}
+			ratesUsed.Add(rate)
+
+			index := validator.MonthsBetween(firstMonth, chargeDate)
+			forecast.Months[index].Spend += amount
+			forecast.Months[index].Charges++
+			monthly[index].add(subscription.SubscriptionChannelID, channelName, category, amount)
+			overall.add(subscription.SubscriptionChannelID, channelName, category, amount)
+			forecast.TotalSpend += amount
+		}
+
+		// A stopped subscription only has events up to the day it stopped
+		last := to
+		if subscription.Status != "active" {
+			stopped, ok := recurrence.StoppedOn(subscription, sources.StopEvents[subscription.ID])
+			if !ok || stopped.Before(from) {
+				continue
+			}
+			if stopped.Before(last) {
+				last = stopped
+			}
+		}
+		forecast.Events = append(forecast.Events, scheduledEvents(subscription, history, channelName, from, last)...)
+	}
+
+	var cumulative money.Amount
+	for i := range forecast.Months {
+		cumulative += forecast.Months[i].Spend
+		forecast.Months[i].CumulativeSpend = cumulative
+		forecast.Months[i].Channels, forecast.Months[i].Categories = monthly[i].sorted()
+	}
+	forecast.Channels, forecast.Categories = overall.sorted()
+
+	sort.SliceStable(forecast.Events, func(i, j int) bool {
+		return forecast.Events[i].Date.Before(forecast.Events[j].Date)
+	})
+	forecast.RatesUsed = ratesUsed.Rates
+
+	return forecast, nil
+}
+
+/*
+**
+scheduledEvents lists the changes to what a subscription charges that fall between from and to:
+price changes, the end of the trial and of the intro price, and the end date
+**
+*/
+func scheduledEvents(subscription models.Subscription_Details, history []models.Subscription_Price_History, channelName string, from, to time.Time) []ForecastEvent {
+	events := []ForecastEvent{}
+	inRange := func(date time.Time) bool {
+		day := utils.TruncateToDay(date)
+		if subscription.EndDate != nil && day.After(utils.TruncateToDay(*subscription.EndDate)) {
+			return false
+		}
+		return !day.Before(from) && !day.After(to)
+	}
+	event := func(date time.Time, eventType string, previous, amount *money.Amount) ForecastEvent {
+		return ForecastEvent{
+			Date:                  utils.TruncateToDay(date),
+			Type:                  eventType,
+			SubscriptionDetailsID: subscription.ID,
+			ChannelName:           channelName,
+			PreviousAmount:        previous,
+			Amount:                amount,
+			Currency:              subscription.Currency,
+		}
+	}
+
+	for _, entry := range history {
+		if !inRange(entry.EffectiveDate) {
+			continue
+		}
+		previous := pricehistory.RegularPriceOn(subscription, history, entry.EffectiveDate.AddDate(0, 0, -1))
+		amount := entry.MonthlyBill
+		if previous != amount {
+			events = append(events, event(entry.EffectiveDate, EventPriceChange, &previous, &amount))
+		}
+	}
+
+	if subscription.TrialEndDate != nil && inRange(*subscription.TrialEndDate) {
+		amount := pricehistory.ResolvePrice(subscription, history, *subscription.TrialEndDate)
+		events = append(events, event(*subscription.TrialEndDate, EventTrialConversion, nil, &amount))
+	}
+
+	if subscription.IntroPrice != nil && subscription.IntroPriceUntil != nil && inRange(*subscription.IntroPriceUntil) {
+		previous := *subscription.IntroPrice
+		amount := pricehistory.RegularPriceOn(subscription, history, *subscription.IntroPriceUntil)
+		events = append(events, event(*subscription.IntroPriceUntil, EventIntroPriceEnd, &previous, &amount))
+	}
+
+	if subscription.EndDate != nil && inRange(*subscription.EndDate) {
+		events = append(events, event(*subscription.EndDate, EventEnd, nil, nil))
+	}
+
+	return events
+}
+
+func newSpendTotals() *spendTotals {
+	return &spendTotals{channels: map[int]*ChannelSpend{}, categories: map[int]*CategorySpend{}}
+}
+
+// add counts a charge for its channel and category. Uncategorized charges are kept under category 0.
+func (t *spendTotals) add(channelID int, channelName string, category models.Category, amount money.Amount) {
+	channel, ok := t.channels[channelID]
+	if !ok {
+		channel = &ChannelSpend{SubscriptionChannelID: channelID, ChannelName: channelName}
+		t.channels[channelID] = channel
+	}
+	channel.Charges++
+	channel.Spend += amount
+
+	categorySpend, ok := t.categories[category.ID]
+	if !ok {
+		categorySpend = &CategorySpend{CategoryName: category.Name, Color: category.Color}
+		if category.ID != 0 {
+			categoryID := category.ID
+			categorySpend.CategoryID = &categoryID
+		}
+		t.categories[category.ID] = categorySpend
+	}
+	categorySpend.Charges++
+	categorySpend.Spend += amount
+}
+
+// sorted returns the totals with the highest spend first, ties by name so the order is stable
+func (t *spendTotals) sorted() ([]ChannelSpend, []CategorySpend) {
+	channels := []ChannelSpend{}
+	for _, channel := range t.channels {
+		channels = append(channels, *channel)
+	}
+	sort.Slice(channels, func(i, j int) bool {
+		if channels[i].Spend != channels[j].Spend {
+			return channels[i].Spend > channels[j].Spend
+		}
+		if channels[i].ChannelName != channels[j].ChannelName {
+			return channels[i].ChannelName < channels[j].ChannelName
+		}
+		return channels[i].SubscriptionChannelID < channels[j].SubscriptionChannelID
+	})
+
+	categories := []CategorySpend{}
+	for _, category := range t.categories {
+		categories = append(categories, *category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Spend != categories[j].Spend {
+			return categories[i].Spend > categories[j].Spend
+		}
+		return categories[i].CategoryName < categories[j].CategoryName
+	})
+
+	return channels, categories
+}
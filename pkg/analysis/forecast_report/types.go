@@ -0,0 +1,77 @@
+package forecast_report
+
+import (
+	"time"
+
+	"subscritracker/pkg/currency"
+	"subscritracker/pkg/money"
+)
+
+// UncategorizedName is used for subscriptions without a category
+const UncategorizedName = "Uncategorized"
+
+// Event types that change what the forecast charges
+const (
+	EventPriceChange     = "price_change"
+	EventTrialConversion = "trial_conversion"
+	EventIntroPriceEnd   = "intro_price_end"
+	EventEnd             = "end"
+)
+
+type ChannelSpend struct {
+	SubscriptionChannelID int          `json:"subscription_channel_id"`
+	ChannelName           string       `json:"channel_name"`
+	Charges               int          `json:"charges"`
+	Spend                 money.Amount `json:"spend"`
+}
+
+type CategorySpend struct {
+	CategoryID   *int         `json:"category_id"`
+	CategoryName string       `json:"category_name"`
+	Color        string       `json:"color,omitempty"`
+	Charges      int          `json:"charges"`
+	Spend        money.Amount `json:"spend"`
+}
+
+// ForecastMonth is the projected outflow of one month. Month is 1 (January) to 12 (December);
+// CumulativeSpend adds up every month of the forecast up to and including this one.
+type ForecastMonth struct {
+	Month           int             `json:"month"`
+	Year            int             `json:"year"`
+	Spend           money.Amount    `json:"spend"`
+	CumulativeSpend money.Amount    `json:"cumulative_spend"`
+	Charges         int             `json:"charges"`
+	Channels        []ChannelSpend  `json:"channels"`
+	Categories      []CategorySpend `json:"categories"`
+}
+
+// ForecastEvent is a scheduled change the forecast accounts for. Amounts are what the subscription
+// charges before and after it, in the subscription's own currency.
+type ForecastEvent struct {
+	Date                  time.Time     `json:"date"`
+	Type                  string        `json:"type"`
+	SubscriptionDetailsID int           `json:"subscription_details_id"`
+	ChannelName           string        `json:"channel_name"`
+	PreviousAmount        *money.Amount `json:"previous_amount,omitempty"`
+	Amount                *money.Amount `json:"amount,omitempty"`
+	Currency              string        `json:"currency"`
+}
+
+// Forecast projects the charges from From, today in the user's timezone, to the end of the last month
+type Forecast struct {
+	From       time.Time              `json:"from"`
+	To         time.Time              `json:"to"`
+	Currency   string                 `json:"currency"`
+	TotalSpend money.Amount           `json:"total_spend"`
+	Months     []ForecastMonth        `json:"months"`
+	Channels   []ChannelSpend         `json:"channels"`
+	Categories []CategorySpend        `json:"categories"`
+	Events     []ForecastEvent        `json:"events"`
+	RatesUsed  []currency.AppliedRate `json:"rates_used"`
+}
+
+// spendTotals accumulates spend by channel and category
+type spendTotals struct {
+	channels   map[int]*ChannelSpend
+	categories map[int]*CategorySpend
+}
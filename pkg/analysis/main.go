@@ -1,6 +1,10 @@
 package analysis
 
 import (
+	"subscritracker/pkg/analysis/budget_report"
+	"subscritracker/pkg/analysis/category_report"
+	"subscritracker/pkg/analysis/comparison_report"
+	"subscritracker/pkg/analysis/forecast_report"
 	"subscritracker/pkg/analysis/month_by_month_report"
 	"subscritracker/pkg/analysis/monthly_report"
 	"subscritracker/pkg/application"
@@ -10,4 +14,9 @@ import (
 func RegisterRoutes(app *application.App) {
 	app.Echo.GET("/v1/analysis/monthly-report", monthly_report.GetMonthlyReportHandler, utils.AuthMiddleware)
 	app.Echo.GET("/v1/analysis/month-by-month-report", month_by_month_report.GetMonthByMonthHandler, utils.AuthMiddleware)
+	app.Echo.GET("/v1/analysis/spend-by-category", category_report.GetSpendByCategoryHandler, utils.AuthMiddleware)
+	app.Echo.GET("/v1/analysis/forecast", forecast_report.GetForecastHandler, utils.AuthMiddleware)
+	app.Echo.GET("/v1/analysis/budgets", budget_report.GetBudgetReportHandler, utils.AuthMiddleware)
+	app.Echo.GET("/v1/analysis/budgets/:id", budget_report.GetBudgetStatusHandler, utils.AuthMiddleware)
+	app.Echo.GET("/v1/analysis/compare", comparison_report.GetComparisonHandler, utils.AuthMiddleware)
 }
@@ -3,48 +3,164 @@ package month_by_month_report
 import (
 	"context"
 	"fmt"
+	"sort"
+	"subscritracker/pkg/analysis/monthly_report"
 	"subscritracker/pkg/application"
+	"subscritracker/pkg/currency"
 	"subscritracker/pkg/models"
+	paymentspkg "subscritracker/pkg/payments"
+	pricehistory "subscritracker/pkg/price-history"
+	"subscritracker/pkg/recurrence"
+	subscriptionchannels "subscritracker/pkg/subscription-channels"
 	"time"
 )
 
-func GetSubscriptionDetailsForMonth(app *application.App, accountID int, startDate time.Time, endDate time.Time) (*MonthlyReportResponse, error) {
+// GetSubscriptionDetailsForMonth lists every charge of the account's subscriptions in the month starting at
+// monthStart. Active subscriptions bill throughout; stopped ones up to the day they stopped. Occurrences
+// are paid only when a payment is linked to them; see Occurrence for the other statuses.
+func GetSubscriptionDetailsForMonth(app *application.App, accountID int, monthStart time.Time, location *time.Location, targetCurrency string) (*MonthlyReportResponse, error) {
+	monthEnd := monthStart.AddDate(0, 1, -1)
+	now := time.Now().In(location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
 
-	var subscriptions []models.Subscription_Details
-	var totalCost float64
+	sources, err := monthly_report.LoadChargeSources(app, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
 
-	err := app.Database.NewSelect().
-		Model(&subscriptions).
-		Where("account_id = ? AND next_due_date BETWEEN ? AND ?", accountID, startDate, endDate).
-		Scan(context.Background())
+	channels, err := subscriptionchannels.GetChannelsBySubscription(context.Background(), app.Database, sources.Subscriptions)
+	if err != nil {
+		return nil, fmt.Errorf("channel query error: %w", err)
+	}
 
+	payments, err := getRecordedPayments(app, accountID, monthStart, monthEnd)
 	if err != nil {
-		return nil, fmt.Errorf("database query error: %w", err)
+		return nil, fmt.Errorf("payment query error: %w", err)
 	}
 
-	var monthlySubscriptionData []MonthlySubscriptionData
+	converter := currency.NewConverter(app.Database)
+	ratesUsed := currency.NewRatesUsed()
+	response := &MonthlyReportResponse{
+		Month:         monthStart.Format("2006-01"),
+		Timezone:      location.String(),
+		Subscriptions: []MonthlySubscriptionData{},
+		Currency:      targetCurrency,
+	}
 
 	// Process each subscription
-	for _, subscription := range subscriptions {
-		// Create MonthlyData from the subscription
+	for _, subscription := range sources.Subscriptions {
+		occurrences := recurrence.BilledOccurrences(subscription, sources.StopEvents[subscription.ID], monthStart, monthEnd)
+		if len(occurrences) == 0 {
+			continue
+		}
+
+		channel := channels[subscription.SubscriptionChannelID]
 		data := MonthlySubscriptionData{
-			Month:                 subscription.NextDueDate.Month().String(),
-			Year:                  subscription.NextDueDate.Year(),
-			Cost:                  subscription.MonthlyBill,
+			Month:                 monthStart.Month().String(),
+			Year:                  monthStart.Year(),
+			SubscriptionDetailsID: subscription.ID,
 			SubscriptionChannelId: subscription.SubscriptionChannelID,
+			ChannelName:           channel.ChannelName,
+			ChannelImageURL:       channel.ChannelImageURL,
+			Currency:              targetCurrency,
+			OriginalCurrency:      subscription.Currency,
 			Status:                subscription.Status,
+			DueType:               subscription.DueType,
 			NextDueDate:           subscription.NextDueDate,
+			InTrial:               subscription.TrialEndDate != nil && monthStart.Before(*subscription.TrialEndDate),
+			TrialEndingSoon:       subscription.IsTrialEndingWithin(now, TrialEndingSoonDays),
+			Occurrences:           []Occurrence{},
 		}
-		totalCost += subscription.MonthlyBill
 
-		monthlySubscriptionData = append(monthlySubscriptionData, data)
-	}
+		for _, date := range occurrences {
+			// Use the price effective on the charge date, with intro price / trial pricing applied
+			originalCost := pricehistory.ResolvePrice(subscription, sources.PriceHistory[subscription.ID], date)
 
-	// Create the response with both data and total cost
-	response := &MonthlyReportResponse{
-		Subscriptions: monthlySubscriptionData,
-		TotalCost:     totalCost,
+			// Convert to the report currency with the rate of the charge date
+			cost, rate, err := converter.Convert(context.Background(), originalCost, subscription.Currency, targetCurrency, date)
+			if err != nil {
+				return nil, fmt.Errorf("currency conversion error: %w", err)
+			}
+			ratesUsed.Add(rate)
+
+			occurrence := Occurrence{
+				Date:         date,
+				Cost:         cost,
+				OriginalCost: originalCost,
+				ExchangeRate: rate,
+				Status:       OccurrenceUpcoming,
+			}
+			if paymentID, ok := payments.byCharge[paymentKey(subscription.ID, date)]; ok {
+				occurrence.PaymentID = &paymentID
+				occurrence.Status = OccurrencePaid
+			} else if date.Before(today) && payments.tracked[subscription.ID] {
+				occurrence.Status = paymentspkg.UnpaidStatus(date, today)
+			} else if date.Before(today) {
+				occurrence.Status = OccurrencePast
+			}
+
+			switch occurrence.Status {
+			case OccurrencePaid:
+				response.PaidCost += cost
+			case OccurrenceUpcoming:
+				response.UpcomingCost += cost
+			default:
+				response.UnpaidCost += cost
+			}
+			data.Cost += cost
+			data.OriginalCost += originalCost
+			data.Occurrences = append(data.Occurrences, occurrence)
+		}
+		data.ExchangeRate = data.Occurrences[0].ExchangeRate
+		response.TotalCost += data.Cost
+
+		response.Subscriptions = append(response.Subscriptions, data)
 	}
 
+	// Soonest charge first
+	sort.SliceStable(response.Subscriptions, func(i, j int) bool {
+		return response.Subscriptions[i].Occurrences[0].Date.Before(response.Subscriptions[j].Occurrences[0].Date)
+	})
+	response.RatesUsed = ratesUsed.Rates
+
 	return response, nil
 }
+
+// getRecordedPayments returns the payments recorded against the account's expected charges due in the
+// month, and which of its subscriptions have any payment recorded
+func getRecordedPayments(app *application.App, accountID int, monthStart, monthEnd time.Time) (*recordedPayments, error) {
+	rows := []recordedPayment{}
+	err := app.Database.NewRaw(`
+		SELECT ec.subscription_details_id, ec.due_date, MIN(p.id) AS payment_id
+		FROM expected_charges ec
+		JOIN payments p ON p.expected_charge_id = ec.id AND p.voided_at IS NULL
+		WHERE ec.account_id = ? AND ec.due_date BETWEEN ? AND ?
+		GROUP BY ec.subscription_details_id, ec.due_date
+	`, accountID, monthStart, monthEnd).Scan(context.Background(), &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	trackedIDs := []int{}
+	err = app.Database.NewSelect().
+		Model((*models.Payment)(nil)).
+		ColumnExpr("DISTINCT subscription_details_id").
+		Where("account_id = ? AND voided_at IS NULL", accountID).
+		Scan(context.Background(), &trackedIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	payments := &recordedPayments{byCharge: map[string]int{}, tracked: map[int]bool{}}
+	for _, row := range rows {
+		payments.byCharge[paymentKey(row.SubscriptionDetailsID, row.DueDate)] = row.PaymentID
+	}
+	for _, id := range trackedIDs {
+		payments.tracked[id] = true
+	}
+	return payments, nil
+}
+
+func paymentKey(subscriptionDetailsID int, dueDate time.Time) string {
+	return fmt.Sprintf("%d/%s", subscriptionDetailsID, dueDate.Format("2006-01-02"))
+}
@@ -1,10 +1,12 @@
 package month_by_month_report
 
 import (
+	"log"
 	"net/http"
-	"time"
 
+	accountpkg "subscritracker/pkg/account"
 	"subscritracker/pkg/application"
+	"subscritracker/pkg/validator"
 	"subscritracker/utils/account"
 
 	"github.com/labstack/echo/v4"
@@ -19,14 +21,27 @@ func GetMonthByMonthHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID format"})
 	}
 
-	currentMonth := time.Now().Month()
-	currentYear := time.Now().Year()
+	accountDetails, err := accountpkg.GetAccountById(app, accountID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get account"})
+	}
+
+	month, err := validator.ValidateMonthRequest(c, accountDetails.Timezone)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
 
-	firstDayOfMonthDateObject := time.Date(currentYear, currentMonth, 1, 0, 0, 0, 0, time.UTC)
-	lastDayOfMonthDateObject := time.Date(currentYear, currentMonth+1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+	targetCurrency, err := validator.ValidateReportCurrency(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if targetCurrency == "" {
+		targetCurrency = accountDetails.DefaultCurrency
+	}
 
-	monthlyData, err := GetSubscriptionDetailsForMonth(app, accountID, firstDayOfMonthDateObject, lastDayOfMonthDateObject)
+	monthlyData, err := GetSubscriptionDetailsForMonth(app, accountID, month.ParsedMonth, month.Location, targetCurrency)
 	if err != nil {
+		log.Printf("Error getting subscription details for month: %v", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get subscription details for month"})
 	}
 
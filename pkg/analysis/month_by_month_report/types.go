@@ -1,17 +1,80 @@
 package month_by_month_report
 
-import "time"
+import (
+	"subscritracker/pkg/currency"
+	"subscritracker/pkg/money"
+	"time"
+)
 
+// TrialEndingSoonDays is how far ahead a trial end is flagged in the report
+const TrialEndingSoonDays = 7
+
+// Occurrence statuses. Pending and missed match the reconciliation statuses of the payments ledger.
+const (
+	OccurrencePaid     = "paid"
+	OccurrencePending  = "pending"
+	OccurrenceMissed   = "missed"
+	OccurrencePast     = "past"
+	OccurrenceUpcoming = "upcoming"
+)
+
+// Occurrence is one billing of a subscription in the month. It is paid only when a payment is linked
+// to it, and PaymentID is set then. Otherwise it is upcoming until its date in the user's timezone;
+// after that it is pending or missed like an unpaid expected charge, or just past for subscriptions
+// the user does not record payments for.
+type Occurrence struct {
+	Date         time.Time            `json:"date"`
+	Cost         money.Amount         `json:"cost"`
+	OriginalCost money.Amount         `json:"original_cost"`
+	ExchangeRate currency.AppliedRate `json:"exchange_rate"`
+	Status       string               `json:"status"`
+	PaymentID    *int                 `json:"payment_id,omitempty"`
+}
+
+// MonthlySubscriptionData is what one subscription charges in the month. Cost and OriginalCost
+// total its occurrences; ExchangeRate is the rate of the first one.
 type MonthlySubscriptionData struct {
-	Month                 string    `json:"month"`
-	SubscriptionChannelId int       `json:"subscription_channel_id"`
-	Year                  int       `json:"year"`
-	Cost                  float64   `json:"cost"`
-	Status                string    `json:"status"`
-	NextDueDate           time.Time `json:"next_due_date"`
+	Month                 string               `json:"month"`
+	SubscriptionDetailsID int                  `json:"subscription_details_id"`
+	SubscriptionChannelId int                  `json:"subscription_channel_id"`
+	ChannelName           string               `json:"channel_name"`
+	ChannelImageURL       string               `json:"channel_image_url"`
+	Year                  int                  `json:"year"`
+	Cost                  money.Amount         `json:"cost"`
+	Currency              string               `json:"currency"`
+	OriginalCost          money.Amount         `json:"original_cost"`
+	OriginalCurrency      string               `json:"original_currency"`
+	ExchangeRate          currency.AppliedRate `json:"exchange_rate"`
+	Status                string               `json:"status"`
+	DueType               string               `json:"due_type"`
+	NextDueDate           time.Time            `json:"next_due_date"`
+	InTrial               bool                 `json:"in_trial"`
+	TrialEndingSoon       bool                 `json:"trial_ending_soon"`
+	Occurrences           []Occurrence         `json:"occurrences"`
 }
 
 type MonthlyReportResponse struct {
+	Month         string                    `json:"month"`
+	Timezone      string                    `json:"timezone"`
 	Subscriptions []MonthlySubscriptionData `json:"subscriptions"`
-	TotalCost     float64                   `json:"total_cost"`
+	TotalCost     money.Amount              `json:"total_cost"`
+	PaidCost      money.Amount              `json:"paid_cost"`
+	UnpaidCost    money.Amount              `json:"unpaid_cost"`
+	UpcomingCost  money.Amount              `json:"upcoming_cost"`
+	Currency      string                    `json:"currency"`
+	RatesUsed     []currency.AppliedRate    `json:"rates_used"`
+}
+
+// recordedPayment is a payment recorded against an expected charge of the month
+type recordedPayment struct {
+	SubscriptionDetailsID int       `bun:"subscription_details_id"`
+	DueDate               time.Time `bun:"due_date"`
+	PaymentID             int       `bun:"payment_id"`
+}
+
+// recordedPayments are the payments of an account: the one linked to each charge of the month by
+// subscription and due date, and the subscriptions the user records payments for at all
+type recordedPayments struct {
+	byCharge map[string]int
+	tracked  map[int]bool
 }
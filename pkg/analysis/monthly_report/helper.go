@@ -5,7 +5,13 @@ import (
 	"time"
 
 	"subscritracker/pkg/application"
+	"subscritracker/pkg/currency"
 	"subscritracker/pkg/models"
+	"subscritracker/pkg/money"
+	pricehistory "subscritracker/pkg/price-history"
+	"subscritracker/pkg/recurrence"
+	subscriptionevents "subscritracker/pkg/subscription-events"
+	"subscritracker/pkg/validator"
 )
 
 /*
@@ -29,112 +35,129 @@ func GetSubscriptionDetails(app *application.App, accountId int) ([]models.Subsc
 
 /*
 **
-ExtractMonthlyData extracts the month and year from the due date of the subscription details
-and returns a list of MonthlyData objects
-Creates an object like this:
-[
-
-	{
-		"month": "January",
-		"year": 2025,
-		"cost": 100.0
-	},
-	{
-		"month": "February",
-		"year": 2025,
-		"cost": 200.0
-	},
-	{
-		"month": "January",
-		"year": 2025,
-		"cost": 120.0
-	},
-	...
-
-]
-Same month can have multiple entries if there are multiple subscriptions
+LoadChargeSources loads everything the charges of an account are computed from: all of its subscriptions,
+whatever their status, their price history and their stop events
 **
 */
-func ExtractMonthlyData(subscriptionDetails []models.Subscription_Details) ([]MonthlyData, error) {
-	monthlyData := []MonthlyData{}
+func LoadChargeSources(app *application.App, accountId int) (*ChargeSources, error) {
+	subscriptionDetails, err := GetSubscriptionDetails(app, accountId)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, subscriptionDetail := range subscriptionDetails {
-		month, year := ExtractMonthAndYear(subscriptionDetail.NextDueDate)
-		summary := MonthlyData{}
-		summary.Month = month
-		summary.Year = year
-		summary.Cost = subscriptionDetail.MonthlyBill
+	priceHistory, err := pricehistory.GetPriceHistoryForAccount(app, accountId)
+	if err != nil {
+		return nil, err
+	}
 
-		monthlyData = append(monthlyData, summary)
+	stopEvents, err := subscriptionevents.GetStopEvents(context.Background(), app.Database, accountId)
+	if err != nil {
+		return nil, err
 	}
 
-	return monthlyData, nil
+	return &ChargeSources{
+		Subscriptions: subscriptionDetails,
+		PriceHistory:  priceHistory,
+		StopEvents:    stopEvents,
+	}, nil
 }
 
 /*
 **
-ExtractMonthAndYear extracts the month and year from the due date of the subscription details
-and returns month as string and year as int
+ExtractCharges expands the recurrence of every subscription between from and to, inclusive,
+and returns one Charge per billing date with the price effective on that date, converted to
+the report currency with the rate of that date.
+Which dates are billed follows recurrence.BilledOccurrences: active subscriptions bill throughout,
+stopped ones up to the day they stopped.
 **
 */
-func ExtractMonthAndYear(date time.Time) (string, int) {
-	month := date.Month().String()
-	year := date.Year()
-	return month, year
+func ExtractCharges(sources *ChargeSources, converter *currency.Converter, targetCurrency string, from, to time.Time) ([]Charge, error) {
+	charges := []Charge{}
+
+	for _, subscriptionDetail := range sources.Subscriptions {
+		for _, chargeDate := range recurrence.BilledOccurrences(subscriptionDetail, sources.StopEvents[subscriptionDetail.ID], from, to) {
+			// Use the price effective on the charge date, with intro price / trial pricing applied
+			original := money.New(pricehistory.ResolvePrice(subscriptionDetail, sources.PriceHistory[subscriptionDetail.ID], chargeDate), subscriptionDetail.Currency)
+
+			converted, rate, err := converter.ConvertMoney(context.Background(), original, targetCurrency, chargeDate)
+			if err != nil {
+				return nil, err
+			}
+
+			charges = append(charges, Charge{
+				SubscriptionDetailsID: subscriptionDetail.ID,
+				SubscriptionChannelID: subscriptionDetail.SubscriptionChannelID,
+				Date:                  chargeDate,
+				Cost:                  converted,
+				Original:              original,
+				Rate:                  rate,
+			})
+		}
+	}
+
+	return charges, nil
 }
 
 /*
 **
-AggregateMonthlyTotals aggregates the monthly data and returns a list of MonthlyData objects
+AggregateMonthlyTotals totals the charges of every month from the month of from to the month of to
+and returns a list of MonthlyData objects
 Creates an object like this:
 [
 
 	{
-		"month": "January",
+		"month": 1,
 		"year": 2025,
-		"cost": 100.0
+		"cost": 100.0,
+		"charges": 3
 	},
 	{
-		"month": "February",
+		"month": 2,
 		"year": 2025,
-		"cost": 200.0
+		"cost": 200.0,
+		"charges": 4
 	},
 	...
 
 ]
-Each entry is the total cost for that month so total of 12 entries
+Every month in the range has an entry, with a cost of 0 when nothing is charged in it
 **
 */
-func AggregateMonthlyTotals(subscriptionDetails []models.Subscription_Details) ([]MonthlyData, error) {
-	// First, get the monthly data to extract month/year info
-	monthlyData, err := ExtractMonthlyData(subscriptionDetails)
+func AggregateMonthlyTotals(sources *ChargeSources, converter *currency.Converter, targetCurrency string, from, to time.Time) ([]MonthlyData, error) {
+	from = time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	charges, err := ExtractCharges(sources, converter, targetCurrency, from, to.AddDate(0, 1, -1))
 	if err != nil {
 		return nil, err
 	}
 
-	// Hardcoded for now
-	year := 2025
-	months := []string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
 	monthlyBreakdown := []MonthlyData{}
+	totals := []money.Money{}
+	ratesUsed := []*currency.RatesUsed{}
+	for month := from; !month.After(to); month = month.AddDate(0, 1, 0) {
+		monthlyBreakdown = append(monthlyBreakdown, MonthlyData{
+			Month:    int(month.Month()),
+			Year:     month.Year(),
+			Currency: targetCurrency,
+		})
+		totals = append(totals, money.Zero(targetCurrency))
+		ratesUsed = append(ratesUsed, currency.NewRatesUsed())
+	}
 
-	for _, month := range months {
-		totalCost := 0.0
-
-		// Sum up costs for this month from the monthly data
-		for _, data := range monthlyData {
-			if data.Month == month && data.Year == year {
-				totalCost += data.Cost
-			}
-		}
-
-		// Create result for this month (even if cost is 0)
-		monthTotal := MonthlyData{
-			Month: month,
-			Year:  year,
-			Cost:  totalCost,
+	// Sum up every charge into the month it falls in
+	for _, charge := range charges {
+		index := validator.MonthsBetween(from, charge.Date)
+		if totals[index], err = totals[index].Add(charge.Cost); err != nil {
+			return nil, err
 		}
+		monthlyBreakdown[index].Charges++
+		ratesUsed[index].Add(charge.Rate)
+	}
 
-		monthlyBreakdown = append(monthlyBreakdown, monthTotal)
+	for i := range monthlyBreakdown {
+		monthlyBreakdown[i].Cost = totals[i].Amount
+		monthlyBreakdown[i].RatesUsed = ratesUsed[i].Rates
 	}
 
 	return monthlyBreakdown, nil
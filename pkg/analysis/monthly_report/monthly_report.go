@@ -3,7 +3,10 @@ package monthly_report
 import (
 	"log"
 	"net/http"
+	accountpkg "subscritracker/pkg/account"
 	"subscritracker/pkg/application"
+	"subscritracker/pkg/currency"
+	"subscritracker/pkg/validator"
 	"subscritracker/utils/account"
 
 	"github.com/labstack/echo/v4"
@@ -19,13 +22,31 @@ func GetMonthlyReportHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID format"})
 	}
 
-	subscriptionDetails, err := GetSubscriptionDetails(app, accountID)
+	monthRange, err := validator.ValidateMonthRange(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	sources, err := LoadChargeSources(app, accountID)
 	if err != nil {
 		log.Printf("Error getting subscription details: %v", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get subscription details"})
 	}
 
-	monthlyBreakdown, err := AggregateMonthlyTotals(subscriptionDetails)
+	targetCurrency, err := validator.ValidateReportCurrency(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if targetCurrency == "" {
+		accountDetails, err := accountpkg.GetAccountById(app, accountID)
+		if err != nil {
+			log.Printf("Error getting account: %v", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get account"})
+		}
+		targetCurrency = accountDetails.DefaultCurrency
+	}
+
+	monthlyBreakdown, err := AggregateMonthlyTotals(sources, currency.NewConverter(app.Database), targetCurrency, monthRange.ParsedFrom, monthRange.ParsedTo)
 	if err != nil {
 		log.Printf("Error aggregating monthly totals: %v", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to aggregate monthly totals"})
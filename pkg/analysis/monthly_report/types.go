@@ -1,7 +1,36 @@
 package monthly_report
 
+import (
+	"time"
+
+	"subscritracker/pkg/currency"
+	"subscritracker/pkg/models"
+	"subscritracker/pkg/money"
+)
+
+// MonthlyData is what was or will be charged in one month. Month is 1 (January) to 12 (December).
 type MonthlyData struct {
-	Month string  `json:"month"`
-	Year  int     `json:"year"`
-	Cost  float64 `json:"cost"`
+	Month     int                    `json:"month"`
+	Year      int                    `json:"year"`
+	Cost      money.Amount           `json:"cost"`
+	Charges   int                    `json:"charges"`
+	Currency  string                 `json:"currency"`
+	RatesUsed []currency.AppliedRate `json:"rates_used"`
+}
+
+// ChargeSources is what the charges of an account are computed from. PriceHistory and StopEvents are by subscription id.
+type ChargeSources struct {
+	Subscriptions []models.Subscription_Details
+	PriceHistory  map[int][]models.Subscription_Price_History
+	StopEvents    map[int][]models.Subscription_Event
+}
+
+// Charge is one billing of a subscription: Cost in the report currency, Original in the subscription's
+type Charge struct {
+	SubscriptionDetailsID int
+	SubscriptionChannelID int
+	Date                  time.Time
+	Cost                  money.Money
+	Original              money.Money
+	Rate                  currency.AppliedRate
 }
@@ -3,6 +3,8 @@ package application
 import (
 	"context"
 	"log"
+	"net"
+	"strings"
 	"subscritracker/config"
 	"subscritracker/pkg/utils"
 
@@ -31,12 +33,44 @@ func NewApp(ctx context.Context) (*App, error) {
 		Echo:     echo.New(),
 	}
 
+	app.Echo.IPExtractor = ipExtractor(app.Config.Proxy.TrustedProxies)
+
+	// Tag every request with an id, echoed in X-Request-Id, that audit records refer to
+	app.Echo.Use(middleware.RequestID())
+
 	// Add CORS middleware globally
 	app.Echo.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins:     []string{app.Config.Frontend.URL, "http://127.0.0.1:3000"}, // Keep 127.0.0.1 for local development
 		AllowMethods:     []string{echo.GET, echo.HEAD, echo.PUT, echo.PATCH, echo.POST, echo.DELETE},
 		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
+		ExposeHeaders:    []string{echo.HeaderXRequestID},
 		AllowCredentials: true,
 	}))
 	return app, nil
 }
+
+// ipExtractor takes the client address from X-Forwarded-For only when the request comes through one of
+// the trusted proxies, so clients cannot choose the address that is logged for them
+func ipExtractor(trustedProxies []string) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range trustedProxies {
+		if !strings.Contains(proxy, "/") {
+			if strings.Contains(proxy, ":") {
+				proxy += "/128"
+			} else {
+				proxy += "/32"
+			}
+		}
+		_, ipRange, err := net.ParseCIDR(proxy)
+		if err != nil {
+			log.Printf("Ignoring invalid trusted proxy %q: %v", proxy, err)
+			continue
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 	"subscritracker/pkg/account"
 	"subscritracker/pkg/application"
+	securitylog "subscritracker/pkg/security-log"
 	"subscritracker/pkg/utils"
 	"subscritracker/pkg/validator"
 	"time"
@@ -46,6 +47,7 @@ func GoogleCallBackHandler(c echo.Context) error {
 	token, err := config.Exchange(context.Background(), code)
 	if err != nil {
 		log.Println("Failed to exchange authorization code for access token:", err)
+		securitylog.Record(c, securitylog.Entry{EventType: securitylog.EventOAuthLogin, Outcome: securitylog.OutcomeFailure, Reason: securitylog.ReasonOAuthFailed})
 		log.Printf("Redirecting to login page with error %s- Failed to exchange authorization code for access token", err)
 		return c.Redirect(http.StatusTemporaryRedirect, frontendURL)
 	}
@@ -54,6 +56,7 @@ func GoogleCallBackHandler(c echo.Context) error {
 	userInfo, err := fetchGoogleUserInfo(token.AccessToken)
 	if err != nil {
 		log.Println("Failed to fetch user info:", err)
+		securitylog.Record(c, securitylog.Entry{EventType: securitylog.EventOAuthLogin, Outcome: securitylog.OutcomeFailure, Reason: securitylog.ReasonOAuthFailed})
 		log.Printf("Redirecting to login page with error %s- Failed to fetch user info", err)
 		return c.Redirect(http.StatusTemporaryRedirect, frontendURL)
 	}
@@ -82,6 +85,13 @@ func GoogleCallBackHandler(c echo.Context) error {
 				log.Printf("Redirecting to login page with error %v- Failed to marshal user data", err)
 				return c.Redirect(http.StatusTemporaryRedirect, frontendURL)
 			}
+			securitylog.Record(c, securitylog.Entry{
+				AccountID: existingUser.ID,
+				Email:     existingUser.Email,
+				EventType: securitylog.EventOAuthLogin,
+				Outcome:   securitylog.OutcomeSuccess,
+				Metadata:  map[string]interface{}{"provider": "google"},
+			})
 			redirectURL := fmt.Sprintf("%s/home?token=%s&user=%s",
 				frontendURL,
 				token,
@@ -141,6 +151,12 @@ func fetchGoogleUserInfo(accessToken string) (map[string]interface{}, error) {
 // LogoutHandler logs out the user
 // Todo: Implement this
 func LogoutHandler(c echo.Context) error {
+	securitylog.Record(c, securitylog.Entry{
+		AccountID: c.Get("user_id").(int),
+		Email:     c.Get("user_email").(string),
+		EventType: securitylog.EventLogout,
+		Outcome:   securitylog.OutcomeSuccess,
+	})
 	return c.JSON(http.StatusOK, map[string]string{
 		"message": "Logged out successfully",
 	})
@@ -206,6 +222,14 @@ func SignUpHandler(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create account"})
 	}
 
+	securitylog.Record(c, securitylog.Entry{
+		AccountID: accountBody.ID,
+		Email:     accountBody.Email,
+		EventType: securitylog.EventSignup,
+		Outcome:   securitylog.OutcomeSuccess,
+		Metadata:  map[string]interface{}{"method": "password"},
+	})
+
 	// TODO: Send verification email
 	// For now, just return success
 	return c.JSON(http.StatusCreated, map[string]interface{}{
@@ -232,11 +256,27 @@ func LoginHandler(c echo.Context) error {
 	// Get account by email
 	accountDetails, err := account.GetAccountByEmail(app, req.Email)
 	if err != nil || accountDetails == nil || !accountDetails.EmailVerified || accountDetails.Status != "active" {
+		failure := securitylog.Entry{Email: req.Email, EventType: securitylog.EventLogin, Outcome: securitylog.OutcomeFailure, Reason: securitylog.ReasonUnknownEmail}
+		if err == nil && accountDetails != nil {
+			failure.AccountID = accountDetails.ID
+			failure.Reason = securitylog.ReasonAccountInactive
+			if !accountDetails.EmailVerified {
+				failure.Reason = securitylog.ReasonEmailNotVerified
+			}
+		}
+		securitylog.Record(c, failure)
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid email or password"})
 	}
 	// Verify password
 	err = bcrypt.CompareHashAndPassword([]byte(accountDetails.PasswordHash), []byte(req.Password))
 	if err != nil {
+		securitylog.Record(c, securitylog.Entry{
+			AccountID: accountDetails.ID,
+			Email:     req.Email,
+			EventType: securitylog.EventLogin,
+			Outcome:   securitylog.OutcomeFailure,
+			Reason:    securitylog.ReasonInvalidPassword,
+		})
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid email or password"})
 	}
 
@@ -253,6 +293,12 @@ func LoginHandler(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to generate token"})
 	}
+	securitylog.Record(c, securitylog.Entry{
+		AccountID: accountDetails.ID,
+		Email:     accountDetails.Email,
+		EventType: securitylog.EventLogin,
+		Outcome:   securitylog.OutcomeSuccess,
+	})
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"token":   token,
@@ -271,6 +317,7 @@ func VerifyEmailHandler(c echo.Context) error {
 	app := c.Get("app").(*application.App)
 	accountDetails, err := account.GetAccountByVerificationToken(app, token)
 	if err != nil || accountDetails == nil {
+		securitylog.Record(c, securitylog.Entry{EventType: securitylog.EventEmailVerified, Outcome: securitylog.OutcomeFailure, Reason: securitylog.ReasonInvalidToken})
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid verification token"})
 	}
 
@@ -281,6 +328,12 @@ func VerifyEmailHandler(c echo.Context) error {
 	if err != nil {
 		log.Println("Failed to update account:", err)
 	}
+	securitylog.Record(c, securitylog.Entry{
+		AccountID: accountDetails.ID,
+		Email:     accountDetails.Email,
+		EventType: securitylog.EventEmailVerified,
+		Outcome:   securitylog.OutcomeSuccess,
+	})
 
 	return c.JSON(http.StatusOK, map[string]string{
 		"message": "Email verified successfully. You can now log in.",
@@ -299,6 +352,12 @@ func ForgotPasswordHandler(c echo.Context) error {
 	app := c.Get("app").(*application.App)
 	accountDetails, err := account.GetAccountByEmail(app, req.Email)
 	if err != nil || accountDetails == nil {
+		securitylog.Record(c, securitylog.Entry{
+			Email:     req.Email,
+			EventType: securitylog.EventPasswordResetRequested,
+			Outcome:   securitylog.OutcomeFailure,
+			Reason:    securitylog.ReasonUnknownEmail,
+		})
 		return c.JSON(http.StatusOK, map[string]string{
 			"message": "If an account with this email exists, a password reset link has been sent.",
 		})
@@ -317,6 +376,12 @@ func ForgotPasswordHandler(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to set reset token"})
 	}
+	securitylog.Record(c, securitylog.Entry{
+		AccountID: accountDetails.ID,
+		Email:     accountDetails.Email,
+		EventType: securitylog.EventPasswordResetRequested,
+		Outcome:   securitylog.OutcomeSuccess,
+	})
 
 	// TODO: Send password reset email
 	// For now, just return success
@@ -6,6 +6,7 @@ import (
 	"subscritracker/pkg/account"
 	"subscritracker/pkg/application"
 	"subscritracker/pkg/models"
+	securitylog "subscritracker/pkg/security-log"
 	"subscritracker/pkg/utils"
 	"time"
 
@@ -45,6 +46,13 @@ func SaveGoogleLoggedInUserToDb(c echo.Context, userInfo map[string]interface{})
 			if err != nil {
 				return nil, err
 			}
+			securitylog.Record(c, securitylog.Entry{
+				AccountID: existingAccount.ID,
+				Email:     existingAccount.Email,
+				EventType: securitylog.EventOAuthLinked,
+				Outcome:   securitylog.OutcomeSuccess,
+				Metadata:  map[string]interface{}{"provider": "google"},
+			})
 		}
 
 		// Generate JWT token for existing user
@@ -52,6 +60,13 @@ func SaveGoogleLoggedInUserToDb(c echo.Context, userInfo map[string]interface{})
 		if err != nil {
 			return nil, err
 		}
+		securitylog.Record(c, securitylog.Entry{
+			AccountID: existingAccount.ID,
+			Email:     existingAccount.Email,
+			EventType: securitylog.EventOAuthLogin,
+			Outcome:   securitylog.OutcomeSuccess,
+			Metadata:  map[string]interface{}{"provider": "google"},
+		})
 
 		return map[string]interface{}{
 			"token":   token,
@@ -101,6 +116,13 @@ func SaveGoogleLoggedInUserToDb(c echo.Context, userInfo map[string]interface{})
 	if err != nil {
 		return nil, err
 	}
+	securitylog.Record(c, securitylog.Entry{
+		AccountID: accountDetails.ID,
+		Email:     accountDetails.Email,
+		EventType: securitylog.EventSignup,
+		Outcome:   securitylog.OutcomeSuccess,
+		Metadata:  map[string]interface{}{"method": "google"},
+	})
 
 	// Generate JWT token
 	token, err := utils.GenerateJWT(accountDetails.ID, accountDetails.Email)
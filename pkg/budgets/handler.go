@@ -0,0 +1,130 @@
+package budgets
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	accountpkg "subscritracker/pkg/account"
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/categories"
+	"subscritracker/pkg/tags"
+	"subscritracker/pkg/validator"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetBudgetsHandler lists the user's budgets
+func GetBudgetsHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	budgets, err := GetBudgets(app, accountID)
+	if err != nil {
+		log.Println("Error getting budgets:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get budgets"})
+	}
+
+	return c.JSON(http.StatusOK, budgets)
+}
+
+// CreateBudgetHandler sets a monthly budget overall or for one of the user's categories or tags
+func CreateBudgetHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	request, err := validator.ValidateBudgetRequest(c, true)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	if request.CategoryID != nil {
+		if _, err := categories.GetCategory(app, accountID, *request.CategoryID); err != nil {
+			if errors.Is(err, categories.ErrCategoryNotFound) {
+				return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+			}
+			log.Println("Error getting category:", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get category"})
+		}
+	}
+	if request.TagID != nil {
+		if _, err := tags.GetTag(app, accountID, *request.TagID); err != nil {
+			if errors.Is(err, tags.ErrTagNotFound) {
+				return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+			}
+			log.Println("Error getting tag:", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get tag"})
+		}
+	}
+
+	accountDetails, err := accountpkg.GetAccountById(app, accountID)
+	if err != nil {
+		log.Println("Error getting account:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get account"})
+	}
+
+	budget, err := CreateBudget(app, accountID, request, accountDetails.DefaultCurrency)
+	if err != nil {
+		if errors.Is(err, ErrBudgetExists) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error creating budget:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create budget"})
+	}
+
+	return c.JSON(http.StatusCreated, budget)
+}
+
+// UpdateBudgetHandler changes the amount, currency or near threshold of a budget
+func UpdateBudgetHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	budgetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid budget ID"})
+	}
+
+	request, err := validator.ValidateBudgetRequest(c, false)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	budget, err := GetBudget(app, accountID, budgetID)
+	if err != nil {
+		if errors.Is(err, ErrBudgetNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error getting budget:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get budget"})
+	}
+
+	if err := UpdateBudget(app, budget, request); err != nil {
+		log.Println("Error updating budget:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update budget"})
+	}
+
+	return c.JSON(http.StatusOK, budget)
+}
+
+// DeleteBudgetHandler removes a budget
+func DeleteBudgetHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	budgetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid budget ID"})
+	}
+
+	if err := DeleteBudget(app, accountID, budgetID); err != nil {
+		if errors.Is(err, ErrBudgetNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error deleting budget:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete budget"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
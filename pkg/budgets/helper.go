@@ -0,0 +1,120 @@
+package budgets
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/models"
+	"subscritracker/pkg/validator"
+)
+
+// GetBudgets lists the budgets of the account, the overall one first
+func GetBudgets(app *application.App, accountID int) ([]models.Budget, error) {
+	budgets := []models.Budget{}
+	err := app.Database.NewSelect().
+		Model(&budgets).
+		Where("account_id = ?", accountID).
+		OrderExpr("scope = ? DESC, id ASC", ScopeOverall).
+		Scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return budgets, nil
+}
+
+func GetBudget(app *application.App, accountID, budgetID int) (*models.Budget, error) {
+	budget := &models.Budget{}
+	err := app.Database.NewSelect().
+		Model(budget).
+		Where("id = ? AND account_id = ?", budgetID, accountID).
+		Scan(context.Background())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBudgetNotFound
+		}
+		return nil, err
+	}
+
+	return budget, nil
+}
+
+// CreateBudget adds a budget in the request's currency, or defaultCurrency when it has none.
+// The category or tag must already be checked to belong to the account.
+func CreateBudget(app *application.App, accountID int, request *validator.ParsedBudget, defaultCurrency string) (*models.Budget, error) {
+	budget := &models.Budget{
+		AccountID:            accountID,
+		Scope:                request.Scope,
+		CategoryID:           request.CategoryID,
+		TagID:                request.TagID,
+		Amount:               *request.Amount,
+		Currency:             request.Currency,
+		NearThresholdPercent: DefaultNearThresholdPercent,
+		CreatedAt:            time.Now(),
+		UpdatedAt:            time.Now(),
+	}
+	if budget.Currency == "" {
+		budget.Currency = defaultCurrency
+	}
+	if request.NearThresholdPercent != nil {
+		budget.NearThresholdPercent = *request.NearThresholdPercent
+	}
+
+	_, err := app.Database.NewInsert().Model(budget).Exec(context.Background())
+	if err != nil {
+		return nil, mapUniqueViolation(err)
+	}
+
+	return budget, nil
+}
+
+// UpdateBudget changes the amount, currency or threshold of a budget
+func UpdateBudget(app *application.App, budget *models.Budget, request *validator.ParsedBudget) error {
+	if request.Amount != nil {
+		budget.Amount = *request.Amount
+	}
+	if request.Currency != "" {
+		budget.Currency = request.Currency
+	}
+	if request.NearThresholdPercent != nil {
+		budget.NearThresholdPercent = *request.NearThresholdPercent
+	}
+	budget.UpdatedAt = time.Now()
+
+	_, err := app.Database.NewUpdate().
+		Model(budget).
+		Column("amount", "currency", "near_threshold_percent", "updated_at").
+		WherePK().
+		Exec(context.Background())
+	return err
+}
+
+func DeleteBudget(app *application.App, accountID, budgetID int) error {
+	result, err := app.Database.NewDelete().
+		Model((*models.Budget)(nil)).
+		Where("id = ? AND account_id = ?", budgetID, accountID).
+		Exec(context.Background())
+	if err != nil {
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrBudgetNotFound
+	}
+	return nil
+}
+
+func mapUniqueViolation(err error) error {
+	if err != nil && strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
+		return ErrBudgetExists
+	}
+	return err
+}
@@ -0,0 +1,13 @@
+package budgets
+
+import (
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/utils"
+)
+
+func RegisterRoutes(app *application.App) {
+	app.Echo.GET("/v1/budgets", GetBudgetsHandler, utils.AuthMiddleware)
+	app.Echo.POST("/v1/budgets", CreateBudgetHandler, utils.AuthMiddleware)
+	app.Echo.PUT("/v1/budgets/:id", UpdateBudgetHandler, utils.AuthMiddleware)
+	app.Echo.DELETE("/v1/budgets/:id", DeleteBudgetHandler, utils.AuthMiddleware)
+}
@@ -0,0 +1,18 @@
+package budgets
+
+import "errors"
+
+// Budget scopes
+const (
+	ScopeOverall  = "overall"
+	ScopeCategory = "category"
+	ScopeTag      = "tag"
+)
+
+// DefaultNearThresholdPercent is the share of a budget from which spend counts as near it
+const DefaultNearThresholdPercent = 80
+
+var (
+	ErrBudgetNotFound = errors.New("budget not found")
+	ErrBudgetExists   = errors.New("a budget for this scope already exists")
+)
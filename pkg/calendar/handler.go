@@ -0,0 +1,79 @@
+package calendar
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/utils"
+	"subscritracker/pkg/validator"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CreateFeedTokenHandler creates the user's private calendar feed URL, replacing any previous one
+func CreateFeedTokenHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	token, feedToken, err := CreateFeedToken(app, accountID)
+	if err != nil {
+		log.Println("Error creating calendar feed token:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create calendar feed"})
+	}
+
+	return c.JSON(http.StatusCreated, FeedTokenResponse{
+		Token:     token,
+		FeedURL:   FeedURL(app, token),
+		CreatedAt: feedToken.CreatedAt,
+	})
+}
+
+// RevokeFeedTokenHandler disables the user's calendar feed URL
+func RevokeFeedTokenHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	revoked, err := RevokeFeedTokens(app, accountID)
+	if err != nil {
+		log.Println("Error revoking calendar feed token:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to revoke calendar feed"})
+	}
+	if !revoked {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": ErrFeedTokenNotFound.Error()})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetFeedHandler serves the ICS feed of upcoming charges for the token's account
+func GetFeedHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+
+	accountID, err := GetAccountIDByFeedToken(app, c.Param("token"))
+	if err != nil {
+		if errors.Is(err, ErrFeedTokenNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error getting calendar feed token:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get calendar feed"})
+	}
+
+	months, err := validator.ValidateCalendarFeedMonths(c, DefaultFeedMonths)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	today := utils.TruncateToDay(time.Now())
+	feed, err := BuildFeed(app, accountID, today.AddDate(0, 0, -feedLookbackDays), today.AddDate(0, months, 0))
+	if err != nil {
+		log.Println("Error building calendar feed:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to build calendar feed"})
+	}
+
+	c.Response().Header().Set("Cache-Control", "private, max-age=900")
+	c.Response().Header().Set("Content-Disposition", `inline; filename="subscriptions.ics"`)
+	return c.Blob(http.StatusOK, "text/calendar; charset=utf-8", feed)
+}
@@ -0,0 +1,275 @@
+package calendar
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"subscritracker/pkg/account"
+	"subscritracker/pkg/analysis/monthly_report"
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/models"
+	pricehistory "subscritracker/pkg/price-history"
+	"subscritracker/pkg/recurrence"
+	subscriptionchannels "subscritracker/pkg/subscription-channels"
+	"subscritracker/pkg/utils"
+
+	"github.com/uptrace/bun"
+)
+
+// CreateFeedToken issues a new calendar feed token for the account, revoking the previous one.
+// The token is returned once; only its hash is stored.
+func CreateFeedToken(app *application.App, accountID int) (string, *models.Calendar_Feed_Token, error) {
+	token, err := account.GenerateToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	feedToken := &models.Calendar_Feed_Token{
+		AccountID: accountID,
+		TokenHash: hashToken(token),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	err = app.Database.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := revokeFeedTokens(ctx, tx, accountID); err != nil {
+			return err
+		}
+		_, err := tx.NewInsert().
+			Model(feedToken).
+			Returning("*").
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return token, feedToken, nil
+}
+
+// RevokeFeedTokens disables the account's calendar feed. It returns false when there was no active feed.
+func RevokeFeedTokens(app *application.App, accountID int) (bool, error) {
+	revoked, err := revokeFeedTokens(context.Background(), app.Database, accountID)
+	if err != nil {
+		return false, err
+	}
+	return revoked > 0, nil
+}
+
+func revokeFeedTokens(ctx context.Context, db bun.IDB, accountID int) (int64, error) {
+	result, err := db.NewUpdate().
+		Model((*models.Calendar_Feed_Token)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Set("updated_at = ?", time.Now()).
+		Where("account_id = ? AND revoked_at IS NULL", accountID).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetAccountIDByFeedToken resolves an active feed token to its account and records that it was used
+func GetAccountIDByFeedToken(app *application.App, token string) (int, error) {
+	feedToken := &models.Calendar_Feed_Token{}
+	err := app.Database.NewSelect().
+		Model(feedToken).
+		Where("token_hash = ? AND revoked_at IS NULL", hashToken(token)).
+		Scan(context.Background())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrFeedTokenNotFound
+		}
+		return 0, err
+	}
+
+	_, err = app.Database.NewUpdate().
+		Model((*models.Calendar_Feed_Token)(nil)).
+		Set("last_used_at = ?", time.Now()).
+		Where("id = ?", feedToken.ID).
+		Exec(context.Background())
+	if err != nil {
+		log.Println("Error recording calendar feed use:", err)
+	}
+
+	return feedToken.AccountID, nil
+}
+
+// FeedURL is the address calendar apps subscribe to
+func FeedURL(app *application.App, token string) string {
+	return strings.TrimRight(app.Config.API.URL, "/") + "/v1/calendar/" + token + "/feed.ics"
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// BuildFeed renders the account's upcoming charges, trial ends and end dates between from and to as an ICS calendar.
+// Charges with a due time are placed in the account's timezone; trial ends and end dates are only listed for
+// subscriptions that are still active.
+func BuildFeed(app *application.App, accountID int, from, to time.Time) ([]byte, error) {
+	accountDetails, err := account.GetAccountById(app, accountID)
+	if err != nil {
+		return nil, err
+	}
+	location, err := time.LoadLocation(accountDetails.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	sources, err := monthly_report.LoadChargeSources(app, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	channels, err := subscriptionchannels.GetChannelsBySubscription(context.Background(), app.Database, sources.Subscriptions)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	writer := &icsWriter{}
+	writer.line("BEGIN", "VCALENDAR")
+	writer.line("VERSION", "2.0")
+	writer.line("PRODID", "-//Subscritracker//Calendar Feed//EN")
+	writer.line("CALSCALE", "GREGORIAN")
+	writer.line("METHOD", "PUBLISH")
+	writer.text("X-WR-CALNAME", "Subscriptions")
+	writer.line("REFRESH-INTERVAL;VALUE=DURATION", "PT6H")
+	writer.line("X-PUBLISHED-TTL", "PT6H")
+	writer.text("X-WR-TIMEZONE", location.String())
+	writer.timezone(location, from, to)
+
+	for _, subscription := range sources.Subscriptions {
+		channelName := channels[subscription.SubscriptionChannelID].ChannelName
+
+		// Stopped subscriptions only bill up to the day they stopped
+		for _, date := range recurrence.BilledOccurrences(subscription, sources.StopEvents[subscription.ID], from, to) {
+			writeChargeEvent(writer, chargeEvent{
+				SubscriptionDetailsID: subscription.ID,
+				ChannelName:           channelName,
+				Date:                  date,
+				Amount:                pricehistory.ResolvePrice(subscription, sources.PriceHistory[subscription.ID], date),
+				Currency:              subscription.Currency,
+				DueType:               subscription.DueType,
+				DueTime:               subscription.DueTime,
+				Alarm:                 reminderTrigger(subscription),
+			}, location, now)
+		}
+
+		if subscription.Status != "active" {
+			continue
+		}
+		if subscription.TrialEndDate != nil && inRange(*subscription.TrialEndDate, from, to) {
+			writeAllDayEvent(writer, fmt.Sprintf("trial-end-%d", subscription.ID), *subscription.TrialEndDate,
+				"Free trial ends: "+channelName, "The free trial ends and regular billing starts.", now)
+		}
+		if subscription.EndDate != nil && inRange(*subscription.EndDate, from, to) {
+			writeAllDayEvent(writer, fmt.Sprintf("end-%d", subscription.ID), *subscription.EndDate,
+				"Subscription ends: "+channelName, "", now)
+		}
+	}
+
+	writer.line("END", "VCALENDAR")
+	return writer.bytes(), nil
+}
+
+func writeChargeEvent(writer *icsWriter, event chargeEvent, location *time.Location, now time.Time) {
+	writer.line("BEGIN", "VEVENT")
+	writer.line("UID", fmt.Sprintf("charge-%d-%s@subscritracker", event.SubscriptionDetailsID, formatDate(event.Date)))
+	writer.line("DTSTAMP", formatUTCDateTime(now))
+	if event.DueTime != nil {
+		start := atClock(event.Date, *event.DueTime)
+		writer.line("DTSTART;TZID="+location.String(), formatLocalDateTime(start))
+		writer.line("DTEND;TZID="+location.String(), formatLocalDateTime(start.Add(30*time.Minute)))
+	} else {
+		writer.line("DTSTART;VALUE=DATE", formatDate(event.Date))
+		writer.line("DTEND;VALUE=DATE", formatDate(event.Date.AddDate(0, 0, 1)))
+	}
+	writer.text("SUMMARY", fmt.Sprintf("%s: %s %s", event.ChannelName, event.Amount, event.Currency))
+	writer.text("DESCRIPTION", fmt.Sprintf("%s charge of %s %s for %s.", capitalize(event.DueType), event.Amount, event.Currency, event.ChannelName))
+	writer.line("TRANSP", "TRANSPARENT")
+	if event.Alarm != nil {
+		writer.line("BEGIN", "VALARM")
+		writer.line("ACTION", "DISPLAY")
+		writer.text("DESCRIPTION", fmt.Sprintf("%s renews: %s %s", event.ChannelName, event.Amount, event.Currency))
+		writer.line("TRIGGER", formatDuration(*event.Alarm))
+		writer.line("END", "VALARM")
+	}
+	writer.line("END", "VEVENT")
+}
+
+func writeAllDayEvent(writer *icsWriter, uid string, date time.Time, summary, description string, now time.Time) {
+	writer.line("BEGIN", "VEVENT")
+	writer.line("UID", uid+"@subscritracker")
+	writer.line("DTSTAMP", formatUTCDateTime(now))
+	writer.line("DTSTART;VALUE=DATE", formatDate(date))
+	writer.line("DTEND;VALUE=DATE", formatDate(date.AddDate(0, 0, 1)))
+	writer.text("SUMMARY", summary)
+	if description != "" {
+		writer.text("DESCRIPTION", description)
+	}
+	writer.line("TRANSP", "TRANSPARENT")
+	writer.line("END", "VEVENT")
+}
+
+// reminderTrigger turns the subscription's reminder into an alarm offset from each charge's start.
+// reminder_days_before applies to every charge; a ReminderDate is taken relative to NextDueDate, so a
+// reminder 3 days before the next charge fires 3 days before every charge. ReminderTime sets the time
+// of day, defaulting to 09:00.
+func reminderTrigger(subscription models.Subscription_Details) *time.Duration {
+	if subscription.ReminderDaysBefore == nil && subscription.ReminderDate == nil && subscription.ReminderTime == nil {
+		return nil
+	}
+
+	daysBefore := 0
+	if subscription.ReminderDaysBefore != nil {
+		daysBefore = *subscription.ReminderDaysBefore
+	} else if subscription.ReminderDate != nil && !subscription.NextDueDate.IsZero() {
+		nextDue := utils.TruncateToDay(subscription.NextDueDate)
+		reminderDay := utils.TruncateToDay(*subscription.ReminderDate)
+		if !reminderDay.After(nextDue) {
+			daysBefore = int(nextDue.Sub(reminderDay).Hours() / 24)
+		}
+	}
+
+	// Offsets are computed on a reference day; only the difference matters
+	reference := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	eventStart := reference
+	if subscription.DueTime != nil {
+		eventStart = atClock(reference, *subscription.DueTime)
+	}
+	reminderAt := time.Date(2000, 1, 1, defaultReminderHour, 0, 0, 0, time.UTC).AddDate(0, 0, -daysBefore)
+	if subscription.ReminderTime != nil {
+		reminderAt = atClock(reference.AddDate(0, 0, -daysBefore), *subscription.ReminderTime)
+	}
+
+	trigger := reminderAt.Sub(eventStart)
+	return &trigger
+}
+
+// atClock sets the time of day of date from a time-of-day value
+func atClock(date, clock time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), 0, 0, time.UTC)
+}
+
+func inRange(date, from, to time.Time) bool {
+	day := utils.TruncateToDay(date)
+	return !day.Before(utils.TruncateToDay(from)) && !day.After(utils.TruncateToDay(to))
+}
+
+func capitalize(value string) string {
+	if value == "" {
+		return value
+	}
+	return strings.ToUpper(value[:1]) + value[1:]
+}
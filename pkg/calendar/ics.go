@@ -0,0 +1,156 @@
+package calendar
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// icsWriter builds an RFC 5545 document: CRLF line endings, lines folded at 75 octets and escaped text
+type icsWriter struct {
+	buffer bytes.Buffer
+}
+
+func (w *icsWriter) line(name, value string) {
+	content := name + ":" + value
+	for len(content) > 75 {
+		cut := 75
+		// Do not split a multi-byte UTF-8 character
+		for cut > 0 && content[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.buffer.WriteString(content[:cut] + "\r\n")
+		content = " " + content[cut:]
+	}
+	w.buffer.WriteString(content + "\r\n")
+}
+
+func (w *icsWriter) text(name, value string) {
+	w.line(name, escapeText(value))
+}
+
+func (w *icsWriter) bytes() []byte {
+	return w.buffer.Bytes()
+}
+
+func escapeText(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return replacer.Replace(value)
+}
+
+func formatDate(date time.Time) string {
+	return date.Format("20060102")
+}
+
+// formatLocalDateTime writes the clock time of date without a zone, for a TZID parameter to place
+func formatLocalDateTime(date time.Time) string {
+	return date.Format("20060102T150405")
+}
+
+// timezone writes a VTIMEZONE for location with an observance for the offset at from and one for every
+// change of offset up to to, so events between them can refer to it with TZID
+func (w *icsWriter) timezone(location *time.Location, from, to time.Time) {
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, location).AddDate(0, 0, -1)
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, location).AddDate(0, 0, 2)
+
+	w.line("BEGIN", "VTIMEZONE")
+	w.line("TZID", location.String())
+	_, offset := start.Zone()
+	w.observance(start, offset)
+	for _, transition := range zoneTransitions(start, end) {
+		w.observance(transition, offset)
+		_, offset = transition.Zone()
+	}
+	w.line("END", "VTIMEZONE")
+}
+
+// observance writes the STANDARD or DAYLIGHT component of the offset that takes effect at at.
+// DTSTART is the local time at which it takes effect, in the offset before it.
+func (w *icsWriter) observance(at time.Time, offsetFrom int) {
+	kind := "STANDARD"
+	if at.IsDST() {
+		kind = "DAYLIGHT"
+	}
+	name, offset := at.Zone()
+
+	w.line("BEGIN", kind)
+	w.line("DTSTART", formatLocalDateTime(at.In(time.FixedZone("", offsetFrom))))
+	w.line("TZOFFSETFROM", formatOffset(offsetFrom))
+	w.line("TZOFFSETTO", formatOffset(offset))
+	w.line("TZNAME", name)
+	w.line("END", kind)
+}
+
+// zoneTransitions returns the instants between start and end at which the offset of their location changes
+func zoneTransitions(start, end time.Time) []time.Time {
+	location := start.Location()
+	transitions := []time.Time{}
+	previous := start
+	for day := start.Add(24 * time.Hour); !previous.After(end); day = day.Add(24 * time.Hour) {
+		_, before := previous.Zone()
+		if _, after := day.Zone(); after != before {
+			// Offsets change on whole minutes: narrow down to the first minute of the new offset
+			low, high := previous.Unix()/60, day.Unix()/60
+			for high-low > 1 {
+				middle := (low + high) / 2
+				if _, offset := time.Unix(middle*60, 0).In(location).Zone(); offset == before {
+					low = middle
+				} else {
+					high = middle
+				}
+			}
+			transitions = append(transitions, time.Unix(high*60, 0).In(location))
+		}
+		previous = day
+	}
+	return transitions
+}
+
+// formatOffset writes a UTC offset in seconds like +0130 or -0500
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+	value := fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds%3600/60)
+	if seconds%60 != 0 {
+		value += fmt.Sprintf("%02d", seconds%60)
+	}
+	return value
+}
+
+func formatUTCDateTime(date time.Time) string {
+	return date.UTC().Format("20060102T150405Z")
+}
+
+// formatDuration writes a duration like -P1DT15H for alarm triggers
+func formatDuration(duration time.Duration) string {
+	sign := ""
+	if duration < 0 {
+		sign = "-"
+		duration = -duration
+	}
+
+	days := int(duration / (24 * time.Hour))
+	duration -= time.Duration(days) * 24 * time.Hour
+	hours := int(duration / time.Hour)
+	duration -= time.Duration(hours) * time.Hour
+	minutes := int(duration / time.Minute)
+
+	value := sign + "P"
+	if days > 0 {
+		value += fmt.Sprintf("%dD", days)
+	}
+	if hours > 0 || minutes > 0 || days == 0 {
+		value += "T"
+		if hours > 0 {
+			value += fmt.Sprintf("%dH", hours)
+		}
+		if minutes > 0 || hours == 0 {
+			value += fmt.Sprintf("%dM", minutes)
+		}
+	}
+	return value
+}
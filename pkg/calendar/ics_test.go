@@ -0,0 +1,105 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLineFolding(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{"short line", "Netflix", []string{"SUMMARY:Netflix"}},
+		{"exactly 75 octets", strings.Repeat("a", 67), []string{"SUMMARY:" + strings.Repeat("a", 67)}},
+		{"folded at 75 octets", strings.Repeat("a", 80), []string{"SUMMARY:" + strings.Repeat("a", 67), " " + strings.Repeat("a", 13)}},
+		{"folded twice", strings.Repeat("a", 160), []string{"SUMMARY:" + strings.Repeat("a", 67), " " + strings.Repeat("a", 74), " " + strings.Repeat("a", 19)}},
+		{"multi-byte character is not split", strings.Repeat("a", 66) + "é", []string{"SUMMARY:" + strings.Repeat("a", 66), " é"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			writer := &icsWriter{}
+			writer.line("SUMMARY", test.value)
+			got := strings.Split(strings.TrimSuffix(string(writer.bytes()), "\r\n"), "\r\n")
+			if strings.Join(got, "|") != strings.Join(test.want, "|") {
+				t.Errorf("line() = %q, want %q", got, test.want)
+			}
+			for _, line := range got {
+				if len(line) > 75 {
+					t.Errorf("line() wrote %d octets, want at most 75", len(line))
+				}
+			}
+		})
+	}
+}
+
+func TestTimezone(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no timezone database")
+	}
+
+	tests := []struct {
+		name     string
+		location *time.Location
+		from, to time.Time
+		want     []string
+	}{
+		{
+			"zone without changes",
+			time.UTC,
+			time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC),
+			[]string{
+				"BEGIN:VTIMEZONE", "TZID:UTC",
+				"BEGIN:STANDARD", "DTSTART:20251231T000000", "TZOFFSETFROM:+0000", "TZOFFSETTO:+0000", "TZNAME:UTC", "END:STANDARD",
+				"END:VTIMEZONE",
+			},
+		},
+		{
+			"daylight saving time starts and ends",
+			berlin,
+			time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
+			[]string{
+				"BEGIN:VTIMEZONE", "TZID:Europe/Berlin",
+				"BEGIN:STANDARD", "DTSTART:20260228T000000", "TZOFFSETFROM:+0100", "TZOFFSETTO:+0100", "TZNAME:CET", "END:STANDARD",
+				"BEGIN:DAYLIGHT", "DTSTART:20260329T020000", "TZOFFSETFROM:+0100", "TZOFFSETTO:+0200", "TZNAME:CEST", "END:DAYLIGHT",
+				"BEGIN:STANDARD", "DTSTART:20261025T030000", "TZOFFSETFROM:+0200", "TZOFFSETTO:+0100", "TZNAME:CET", "END:STANDARD",
+				"END:VTIMEZONE",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			writer := &icsWriter{}
+			writer.timezone(test.location, test.from, test.to)
+			got := strings.Split(strings.TrimSuffix(string(writer.bytes()), "\r\n"), "\r\n")
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("timezone() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestFormatOffset(t *testing.T) {
+	tests := []struct {
+		seconds int
+		want    string
+	}{
+		{0, "+0000"},
+		{5*3600 + 30*60, "+0530"},
+		{-5 * 3600, "-0500"},
+		{-(9*3600 + 30*60 + 15), "-093015"},
+	}
+
+	for _, test := range tests {
+		if got := formatOffset(test.seconds); got != test.want {
+			t.Errorf("formatOffset(%d) = %s, want %s", test.seconds, got, test.want)
+		}
+	}
+}
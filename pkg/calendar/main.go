@@ -0,0 +1,13 @@
+package calendar
+
+import (
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/utils"
+)
+
+func RegisterRoutes(app *application.App) {
+	app.Echo.POST("/v1/calendar/token", CreateFeedTokenHandler, utils.AuthMiddleware)
+	app.Echo.DELETE("/v1/calendar/token", RevokeFeedTokenHandler, utils.AuthMiddleware)
+	// Calendar apps cannot send Bearer headers, so the feed is authenticated by the token in its URL
+	app.Echo.GET("/v1/calendar/:token/feed.ics", GetFeedHandler)
+}
@@ -0,0 +1,36 @@
+package calendar
+
+import (
+	"errors"
+	"time"
+
+	"subscritracker/pkg/money"
+)
+
+// DefaultFeedMonths is how far ahead the feed lists charges when `months` is not given
+const DefaultFeedMonths = 12
+
+// feedLookbackDays keeps recent charges in the feed so they do not vanish from calendars the day after
+const feedLookbackDays = 30
+
+// defaultReminderHour is used for reminders that have a date but no time
+const defaultReminderHour = 9
+
+var ErrFeedTokenNotFound = errors.New("calendar feed not found")
+
+type FeedTokenResponse struct {
+	Token     string    `json:"token"`
+	FeedURL   string    `json:"feed_url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type chargeEvent struct {
+	SubscriptionDetailsID int
+	ChannelName           string
+	Date                  time.Time
+	Amount                money.Amount
+	Currency              string
+	DueType               string
+	DueTime               *time.Time
+	Alarm                 *time.Duration
+}
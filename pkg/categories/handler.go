@@ -0,0 +1,160 @@
+package categories
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/models"
+	subscriptiondetails "subscritracker/pkg/subscription-details"
+	"subscritracker/pkg/utils"
+	"subscritracker/pkg/validator"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetCategoriesHandler lists the categories of the user
+func GetCategoriesHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	categories, err := GetCategories(app, accountID)
+	if err != nil {
+		log.Println("Error getting categories:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get categories"})
+	}
+
+	return c.JSON(http.StatusOK, categories)
+}
+
+// CreateCategoryHandler creates a category for the user
+func CreateCategoryHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	request, err := validator.ValidateLabelRequest(c, "category", MaxCategoryNameLength)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	category := &models.Category{
+		AccountID: accountID,
+		Name:      request.Name,
+		Color:     request.Color,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := CreateCategory(app, category); err != nil {
+		if errors.Is(err, ErrCategoryNameTaken) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error creating category:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create category"})
+	}
+
+	return c.JSON(http.StatusCreated, category)
+}
+
+// UpdateCategoryHandler renames or recolors a category
+func UpdateCategoryHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	categoryID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid category ID"})
+	}
+
+	request, err := validator.ValidateLabelRequest(c, "category", MaxCategoryNameLength)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	category, err := GetCategory(app, accountID, categoryID)
+	if err != nil {
+		if errors.Is(err, ErrCategoryNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error getting category:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get category"})
+	}
+
+	category.Name = request.Name
+	category.Color = request.Color
+	if err := UpdateCategory(app, category); err != nil {
+		if errors.Is(err, ErrCategoryNameTaken) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error updating category:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update category"})
+	}
+
+	return c.JSON(http.StatusOK, category)
+}
+
+// DeleteCategoryHandler deletes a category, leaving its subscriptions uncategorized
+func DeleteCategoryHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	categoryID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid category ID"})
+	}
+
+	if err := DeleteCategory(utils.RequestContext(c), app, accountID, categoryID); err != nil {
+		if errors.Is(err, ErrCategoryNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error deleting category:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete category"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// SetSubscriptionCategoryHandler moves a subscription into a category, or out of any with a null category_id
+func SetSubscriptionCategoryHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	subscriptionDetailsID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid subscription details ID"})
+	}
+
+	categoryID, err := validator.ValidateSubscriptionCategoryRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	subscription, err := subscriptiondetails.GetOwnedSubscriptionDetails(app, accountID, subscriptionDetailsID)
+	if err != nil {
+		if errors.Is(err, subscriptiondetails.ErrSubscriptionDetailsNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error getting subscription details:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get subscription details"})
+	}
+
+	if categoryID != nil {
+		if _, err := GetCategory(app, accountID, *categoryID); err != nil {
+			if errors.Is(err, ErrCategoryNotFound) {
+				return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+			}
+			log.Println("Error getting category:", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get category"})
+		}
+	}
+
+	if err := SetSubscriptionCategory(utils.RequestContext(c), app, subscription, categoryID); err != nil {
+		log.Println("Error setting subscription category:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to set category"})
+	}
+
+	subscription.CategoryID = categoryID
+	return c.JSON(http.StatusOK, subscription)
+}
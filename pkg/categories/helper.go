@@ -0,0 +1,150 @@
+package categories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/models"
+	"subscritracker/pkg/stream"
+	subscriptionversions "subscritracker/pkg/subscription-versions"
+
+	"github.com/uptrace/bun"
+)
+
+// GetCategories returns the categories of the account with the number of subscriptions in each
+func GetCategories(app *application.App, accountID int) ([]CategoryWithCount, error) {
+	categories := []CategoryWithCount{}
+	err := app.Database.NewRaw(`
+		SELECT
+			c.id,
+			c.name,
+			COALESCE(c.color, '') AS color,
+			COUNT(sd.id) AS subscription_count
+		FROM categories c
+		LEFT JOIN subscription_details sd ON sd.category_id = c.id
+		WHERE c.account_id = ?
+		GROUP BY c.id
+		ORDER BY c.name ASC
+	`, accountID).Scan(context.Background(), &categories)
+	if err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+// GetCategory loads a category only if it belongs to the account
+func GetCategory(app *application.App, accountID, categoryID int) (*models.Category, error) {
+	category := &models.Category{}
+	err := app.Database.NewSelect().
+		Model(category).
+		Where("id = ? AND account_id = ?", categoryID, accountID).
+		Scan(context.Background())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func CreateCategory(app *application.App, category *models.Category) error {
+	_, err := app.Database.NewInsert().
+		Model(category).
+		Returning("*").
+		Exec(context.Background())
+	return mapUniqueViolation(err)
+}
+
+func UpdateCategory(app *application.App, category *models.Category) error {
+	category.UpdatedAt = time.Now()
+	_, err := app.Database.NewUpdate().
+		Model(category).
+		Column("name", "color", "updated_at").
+		Where("id = ? AND account_id = ?", category.ID, category.AccountID).
+		Exec(context.Background())
+	return mapUniqueViolation(err)
+}
+
+// DeleteCategory removes a category. Subscriptions in it become uncategorized through ON DELETE SET NULL,
+// which is recorded as a new version of each of them.
+func DeleteCategory(ctx context.Context, app *application.App, accountID, categoryID int) error {
+	return app.Database.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		categorized := []models.Subscription_Details{}
+		err := tx.NewSelect().
+			Model(&categorized).
+			Where("category_id = ? AND account_id = ?", categoryID, accountID).
+			For("UPDATE").
+			Scan(ctx)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.NewDelete().
+			Model((*models.Category)(nil)).
+			Where("id = ? AND account_id = ?", categoryID, accountID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrCategoryNotFound
+		}
+
+		for _, before := range categorized {
+			after := before
+			after.CategoryID = nil
+			if err := subscriptionversions.Record(ctx, tx, &before, after); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SetSubscriptionCategory assigns a category to a subscription, or clears it when categoryID is nil, and
+// publishes the update to the account's stream in the same transaction
+func SetSubscriptionCategory(ctx context.Context, app *application.App, subscription models.Subscription_Details, categoryID *int) error {
+	after := subscription
+	after.CategoryID = categoryID
+	after.UpdatedAt = time.Now()
+
+	return app.Database.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().
+			Model((*models.Subscription_Details)(nil)).
+			Set("category_id = ?", after.CategoryID).
+			Set("updated_at = ?", after.UpdatedAt).
+			Where("id = ?", subscription.ID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if err := subscriptionversions.Record(ctx, tx, &subscription, after); err != nil {
+			return err
+		}
+
+		return stream.Publish(ctx, tx, subscription.AccountID, stream.EventSubscriptionUpdated, map[string]interface{}{
+			"subscription_details_id": subscription.ID,
+			"fields":                  []string{"category_id"},
+		})
+	})
+}
+
+func mapUniqueViolation(err error) error {
+	if err != nil && strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
+		return ErrCategoryNameTaken
+	}
+	return err
+}
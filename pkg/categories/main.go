@@ -0,0 +1,14 @@
+package categories
+
+import (
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/utils"
+)
+
+func RegisterRoutes(app *application.App) {
+	app.Echo.GET("/v1/categories", GetCategoriesHandler, utils.AuthMiddleware)
+	app.Echo.POST("/v1/categories", CreateCategoryHandler, utils.AuthMiddleware)
+	app.Echo.PUT("/v1/categories/:id", UpdateCategoryHandler, utils.AuthMiddleware)
+	app.Echo.DELETE("/v1/categories/:id", DeleteCategoryHandler, utils.AuthMiddleware)
+	app.Echo.PUT("/v1/subscription-details/:id/category", SetSubscriptionCategoryHandler, utils.AuthMiddleware)
+}
@@ -0,0 +1,18 @@
+package categories
+
+import "errors"
+
+// MaxCategoryNameLength matches the categories.name column
+const MaxCategoryNameLength = 100
+
+var (
+	ErrCategoryNotFound  = errors.New("category not found")
+	ErrCategoryNameTaken = errors.New("a category with this name already exists")
+)
+
+type CategoryWithCount struct {
+	ID                int    `json:"id"`
+	Name              string `json:"name"`
+	Color             string `json:"color,omitempty"`
+	SubscriptionCount int    `json:"subscription_count"`
+}
@@ -0,0 +1,203 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"subscritracker/pkg/models"
+	"subscritracker/pkg/money"
+	"subscritracker/pkg/utils"
+
+	"github.com/uptrace/bun"
+)
+
+// ECB publishes EUR based rates, so conversions without a direct pair go through EUR
+const pivotCurrency = "EUR"
+
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// AppliedRate records which rate was used for a conversion so responses can show it. Estimated is set
+// when no rate was known on or before the date converted for, and a later rate was used instead.
+type AppliedRate struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Rate      float64   `json:"rate"`
+	RateDate  time.Time `json:"rate_date"`
+	Estimated bool      `json:"estimated,omitempty"`
+}
+
+type datedRate struct {
+	date time.Time
+	rate float64
+}
+
+// Converter converts amounts between currencies using the exchange_rates table.
+// Rates are loaded lazily per currency pair and cached for the life of the converter,
+// so create one per request.
+type Converter struct {
+	db    bun.IDB
+	pairs map[string][]datedRate
+}
+
+func NewConverter(db bun.IDB) *Converter {
+	return &Converter{
+		db:    db,
+		pairs: map[string][]datedRate{},
+	}
+}
+
+// Convert converts amount from one currency to another using the latest rate on or before date.
+// The result is rounded half away from zero to the cent.
+func (c *Converter) Convert(ctx context.Context, amount money.Amount, from, to string, date time.Time) (money.Amount, AppliedRate, error) {
+	rate, err := c.Rate(ctx, from, to, date)
+	if err != nil {
+		return 0, AppliedRate{}, err
+	}
+	if rate.Rate == 1 {
+		return amount, rate, nil
+	}
+
+	return amount.MulFloat(rate.Rate), rate, nil
+}
+
+// ConvertMoney converts an amount with its currency to the to currency, like Convert
+func (c *Converter) ConvertMoney(ctx context.Context, amount money.Money, to string, date time.Time) (money.Money, AppliedRate, error) {
+	converted, rate, err := c.Convert(ctx, amount.Amount, amount.Currency, to, date)
+	if err != nil {
+		return money.Money{}, AppliedRate{}, err
+	}
+	return money.New(converted, rate.To), rate, nil
+}
+
+// Rate returns the rate to convert from -> to on date. Same currency conversions use a rate of 1.
+func (c *Converter) Rate(ctx context.Context, from, to string, date time.Time) (AppliedRate, error) {
+	from = strings.ToUpper(strings.TrimSpace(from))
+	to = strings.ToUpper(strings.TrimSpace(to))
+	if from == to {
+		return AppliedRate{From: from, To: to, Rate: 1, RateDate: utils.TruncateToDay(date)}, nil
+	}
+
+	day := utils.TruncateToDay(date)
+	rate, rateDate, err := c.pairRate(ctx, from, to, date)
+	if err == nil {
+		return AppliedRate{From: from, To: to, Rate: rate, RateDate: rateDate, Estimated: rateDate.After(day)}, nil
+	}
+	if !errors.Is(err, ErrRateNotFound) {
+		return AppliedRate{}, err
+	}
+
+	// Cross rate through the pivot currency
+	if from != pivotCurrency && to != pivotCurrency {
+		fromPivot, fromDate, fromErr := c.pairRate(ctx, from, pivotCurrency, date)
+		pivotTo, toDate, toErr := c.pairRate(ctx, pivotCurrency, to, date)
+		if fromErr == nil && toErr == nil {
+			rateDate := fromDate
+			if toDate.Before(rateDate) {
+				rateDate = toDate
+			}
+			estimated := fromDate.After(day) || toDate.After(day)
+			return AppliedRate{From: from, To: to, Rate: fromPivot * pivotTo, RateDate: rateDate, Estimated: estimated}, nil
+		}
+		for _, pairErr := range []error{fromErr, toErr} {
+			if pairErr != nil && !errors.Is(pairErr, ErrRateNotFound) {
+				return AppliedRate{}, pairErr
+			}
+		}
+	}
+
+	return AppliedRate{}, fmt.Errorf("%w: %s to %s on %s", ErrRateNotFound, from, to, date.Format("2006-01-02"))
+}
+
+// pairRate finds a direct or inverse rate between two currencies, preferring one known on or before date
+func (c *Converter) pairRate(ctx context.Context, from, to string, date time.Time) (float64, time.Time, error) {
+	day := utils.TruncateToDay(date)
+	direct, err := c.loadPair(ctx, from, to)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	directRate, directOK := rateOnOrBefore(direct, date)
+	if directOK && !directRate.date.After(day) {
+		return directRate.rate, directRate.date, nil
+	}
+
+	inverse, err := c.loadPair(ctx, to, from)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if rate, ok := rateOnOrBefore(inverse, date); ok && (!directOK || !rate.date.After(day)) {
+		return 1 / rate.rate, rate.date, nil
+	}
+	if directOK {
+		return directRate.rate, directRate.date, nil
+	}
+
+	return 0, time.Time{}, ErrRateNotFound
+}
+
+func (c *Converter) loadPair(ctx context.Context, base, quote string) ([]datedRate, error) {
+	key := base + "/" + quote
+	if rates, ok := c.pairs[key]; ok {
+		return rates, nil
+	}
+
+	rows := []models.Exchange_Rate{}
+	err := c.db.NewSelect().
+		Model(&rows).
+		Where("base_currency = ? AND quote_currency = ?", base, quote).
+		Order("rate_date ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make([]datedRate, 0, len(rows))
+	for _, row := range rows {
+		rates = append(rates, datedRate{date: utils.TruncateToDay(row.RateDate), rate: row.Rate})
+	}
+	c.pairs[key] = rates
+
+	return rates, nil
+}
+
+// rateOnOrBefore returns the latest rate on or before date. If every known rate is later
+// than date, the earliest rate is used so old charges can still be converted; its date is
+// then after date, which Rate reports as an estimated rate.
+func rateOnOrBefore(rates []datedRate, date time.Time) (datedRate, bool) {
+	if len(rates) == 0 {
+		return datedRate{}, false
+	}
+
+	day := utils.TruncateToDay(date)
+	index := sort.Search(len(rates), func(i int) bool { return rates[i].date.After(day) })
+	if index == 0 {
+		return rates[0], true
+	}
+	return rates[index-1], true
+}
+
+// RatesUsed collects the distinct rates applied while building a response
+type RatesUsed struct {
+	seen  map[string]bool
+	Rates []AppliedRate
+}
+
+func NewRatesUsed() *RatesUsed {
+	return &RatesUsed{seen: map[string]bool{}, Rates: []AppliedRate{}}
+}
+
+// Add records a rate unless it is an identity conversion or was already recorded
+func (r *RatesUsed) Add(rate AppliedRate) {
+	if rate.From == rate.To {
+		return
+	}
+	key := fmt.Sprintf("%s/%s/%s/%v/%t", rate.From, rate.To, rate.RateDate.Format("2006-01-02"), rate.Rate, rate.Estimated)
+	if r.seen[key] {
+		return
+	}
+	r.seen[key] = true
+	r.Rates = append(r.Rates, rate)
+}
@@ -0,0 +1,110 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func day(value string) time.Time {
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+func TestRateOnOrBefore(t *testing.T) {
+	rates := []datedRate{
+		{date: day("2026-01-05"), rate: 1.1},
+		{date: day("2026-01-10"), rate: 1.2},
+		{date: day("2026-01-15"), rate: 1.3},
+	}
+
+	tests := []struct {
+		name     string
+		rates    []datedRate
+		date     time.Time
+		wantOK   bool
+		wantDate string
+	}{
+		{"no rates", nil, day("2026-01-10"), false, ""},
+		{"on a rate date", rates, day("2026-01-10"), true, "2026-01-10"},
+		{"between rate dates", rates, day("2026-01-12"), true, "2026-01-10"},
+		{"time of day is ignored", rates, day("2026-01-10").Add(23 * time.Hour), true, "2026-01-10"},
+		{"after the last rate", rates, day("2026-03-01"), true, "2026-01-15"},
+		{"before the first rate falls back to it", rates, day("2025-12-01"), true, "2026-01-05"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rate, ok := rateOnOrBefore(test.rates, test.date)
+			if ok != test.wantOK {
+				t.Fatalf("rateOnOrBefore() ok = %v, want %v", ok, test.wantOK)
+			}
+			if ok && !rate.date.Equal(day(test.wantDate)) {
+				t.Errorf("rateOnOrBefore() date = %s, want %s", rate.date.Format("2006-01-02"), test.wantDate)
+			}
+		})
+	}
+}
+
+// cachedConverter is a converter whose pairs are all loaded, so it never queries the database
+func cachedConverter(pairs map[string][]datedRate) *Converter {
+	converter := NewConverter(nil)
+	for _, key := range []string{"USD/EUR", "EUR/USD", "GBP/EUR", "EUR/GBP", "USD/GBP", "GBP/USD"} {
+		converter.pairs[key] = pairs[key]
+	}
+	return converter
+}
+
+func TestRate(t *testing.T) {
+	converter := cachedConverter(map[string][]datedRate{
+		"USD/EUR": {{date: day("2026-01-10"), rate: 0.9}},
+		"EUR/USD": {{date: day("2025-06-01"), rate: 1.2}},
+		"EUR/GBP": {{date: day("2026-01-10"), rate: 0.8}},
+	})
+
+	tests := []struct {
+		name          string
+		from, to      string
+		date          time.Time
+		wantRate      float64
+		wantDate      string
+		wantEstimated bool
+		wantErr       error
+	}{
+		{"same currency", "usd", "USD", day("2026-01-12"), 1, "2026-01-12", false, nil},
+		{"direct rate", "USD", "EUR", day("2026-01-12"), 0.9, "2026-01-10", false, nil},
+		{"inverse rate known before a later direct one", "USD", "EUR", day("2025-07-01"), 1 / 1.2, "2025-06-01", false, nil},
+		{"only later rates are estimated", "EUR", "GBP", day("2025-07-01"), 0.8, "2026-01-10", true, nil},
+		{"cross rate through the pivot", "USD", "GBP", day("2026-01-12"), 0.9 * 0.8, "2026-01-10", false, nil},
+		{"cross rate with a later leg is estimated", "USD", "GBP", day("2025-07-01"), 1 / 1.2 * 0.8, "2025-06-01", true, nil},
+		{"unknown pair", "USD", "JPY", day("2026-01-12"), 0, "", false, ErrRateNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			converter.pairs["USD/JPY"], converter.pairs["JPY/USD"] = nil, nil
+			converter.pairs["EUR/JPY"], converter.pairs["JPY/EUR"] = nil, nil
+
+			rate, err := converter.Rate(context.Background(), test.from, test.to, test.date)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("Rate() error = %v, want %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if diff := rate.Rate - test.wantRate; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("Rate() rate = %v, want %v", rate.Rate, test.wantRate)
+			}
+			if !rate.RateDate.Equal(day(test.wantDate)) {
+				t.Errorf("Rate() rate date = %s, want %s", rate.RateDate.Format("2006-01-02"), test.wantDate)
+			}
+			if rate.Estimated != test.wantEstimated {
+				t.Errorf("Rate() estimated = %v, want %v", rate.Estimated, test.wantEstimated)
+			}
+		})
+	}
+}
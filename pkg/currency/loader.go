@@ -0,0 +1,269 @@
+package currency
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"subscritracker/pkg/models"
+
+	"github.com/uptrace/bun"
+)
+
+const (
+	SourceCSV = "csv"
+	SourceECB = "ecb"
+)
+
+// LoadRatesFromFile parses an exchange rate file, picking the format from the extension:
+// .xml is read as an ECB eurofxref feed, anything else as CSV
+func LoadRatesFromFile(path string) ([]models.Exchange_Rate, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".xml") {
+		return ParseECBXML(file)
+	}
+	return ParseRatesCSV(file)
+}
+
+/*
+**
+ParseRatesCSV parses exchange rates from CSV. Two layouts are supported:
+
+Long format, one rate per row:
+
+	date,base,quote,rate
+	2025-08-01,EUR,USD,1.1412
+
+ECB wide format (eurofxref.csv / eurofxref-hist.csv), EUR based:
+
+	Date,USD,JPY,INR,
+	2025-08-01,1.1412,170.31,99.62,
+
+**
+*/
+func ParseRatesCSV(r io.Reader) ([]models.Exchange_Rate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv: %w", err)
+	}
+	if len(records) < 2 {
+		return nil, errors.New("csv must have a header and at least one row")
+	}
+
+	header := make([]string, len(records[0]))
+	for i, column := range records[0] {
+		header[i] = strings.ToLower(strings.TrimSpace(column))
+	}
+
+	if columnIndex(header, "base") >= 0 && columnIndex(header, "quote") >= 0 {
+		return parseLongCSV(header, records[1:])
+	}
+	if len(header) > 0 && header[0] == "date" {
+		return parseECBWideCSV(records[0], records[1:])
+	}
+
+	return nil, errors.New("unrecognized csv layout. Expected columns date,base,quote,rate or the ECB Date,USD,JPY,... layout")
+}
+
+func parseLongCSV(header []string, rows [][]string) ([]models.Exchange_Rate, error) {
+	dateIndex := columnIndex(header, "date")
+	baseIndex := columnIndex(header, "base")
+	quoteIndex := columnIndex(header, "quote")
+	rateIndex := columnIndex(header, "rate")
+	if dateIndex < 0 || rateIndex < 0 {
+		return nil, errors.New("csv must have date, base, quote and rate columns")
+	}
+
+	rates := []models.Exchange_Rate{}
+	for i, row := range rows {
+		if len(row) <= maxInt(dateIndex, baseIndex, quoteIndex, rateIndex) {
+			return nil, fmt.Errorf("row %d: not enough columns", i+2)
+		}
+
+		rateDate, err := time.Parse("2006-01-02", strings.TrimSpace(row[dateIndex]))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid date %q", i+2, row[dateIndex])
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(row[rateIndex]), 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("row %d: invalid rate %q", i+2, row[rateIndex])
+		}
+
+		rates = append(rates, models.Exchange_Rate{
+			BaseCurrency:  strings.ToUpper(strings.TrimSpace(row[baseIndex])),
+			QuoteCurrency: strings.ToUpper(strings.TrimSpace(row[quoteIndex])),
+			Rate:          rate,
+			RateDate:      rateDate,
+			Source:        SourceCSV,
+		})
+	}
+
+	return rates, nil
+}
+
+func parseECBWideCSV(header []string, rows [][]string) ([]models.Exchange_Rate, error) {
+	rates := []models.Exchange_Rate{}
+	for i, row := range rows {
+		if len(row) == 0 || strings.TrimSpace(row[0]) == "" {
+			continue
+		}
+
+		rateDate, err := parseECBDate(strings.TrimSpace(row[0]))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid date %q", i+2, row[0])
+		}
+
+		for column := 1; column < len(row) && column < len(header); column++ {
+			quote := strings.ToUpper(strings.TrimSpace(header[column]))
+			value := strings.TrimSpace(row[column])
+			// ECB files end every line with a comma and use N/A for missing rates
+			if quote == "" || value == "" || value == "N/A" {
+				continue
+			}
+
+			rate, err := strconv.ParseFloat(value, 64)
+			if err != nil || rate <= 0 {
+				return nil, fmt.Errorf("row %d: invalid %s rate %q", i+2, quote, value)
+			}
+
+			rates = append(rates, models.Exchange_Rate{
+				BaseCurrency:  "EUR",
+				QuoteCurrency: quote,
+				Rate:          rate,
+				RateDate:      rateDate,
+				Source:        SourceECB,
+			})
+		}
+	}
+
+	return rates, nil
+}
+
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// ParseECBXML parses the ECB eurofxref daily / 90 day / historical XML feeds (EUR based)
+func ParseECBXML(r io.Reader) ([]models.Exchange_Rate, error) {
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("failed to read ecb xml: %w", err)
+	}
+
+	rates := []models.Exchange_Rate{}
+	for _, day := range envelope.Days {
+		rateDate, err := time.Parse("2006-01-02", day.Time)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ecb date %q", day.Time)
+		}
+
+		for _, dayRate := range day.Rates {
+			rate, err := strconv.ParseFloat(dayRate.Rate, 64)
+			if err != nil || rate <= 0 {
+				return nil, fmt.Errorf("invalid ecb rate %q for %s on %s", dayRate.Rate, dayRate.Currency, day.Time)
+			}
+
+			rates = append(rates, models.Exchange_Rate{
+				BaseCurrency:  "EUR",
+				QuoteCurrency: strings.ToUpper(dayRate.Currency),
+				Rate:          rate,
+				RateDate:      rateDate,
+				Source:        SourceECB,
+			})
+		}
+	}
+
+	if len(rates) == 0 {
+		return nil, errors.New("no rates found in ecb xml")
+	}
+
+	return rates, nil
+}
+
+// ImportRates upserts exchange rates, replacing the rate of an existing pair and date
+func ImportRates(ctx context.Context, db bun.IDB, rates []models.Exchange_Rate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	for i := range rates {
+		rates[i].CreatedAt = now
+		rates[i].UpdatedAt = now
+	}
+
+	// Insert in batches to keep the statement size reasonable for historical files
+	const batchSize = 1000
+	for start := 0; start < len(rates); start += batchSize {
+		end := start + batchSize
+		if end > len(rates) {
+			end = len(rates)
+		}
+
+		batch := rates[start:end]
+		_, err := db.NewInsert().
+			Model(&batch).
+			On("CONFLICT (base_currency, quote_currency, rate_date) DO UPDATE").
+			Set("rate = EXCLUDED.rate").
+			Set("source = EXCLUDED.source").
+			Set("updated_at = EXCLUDED.updated_at").
+			Exec(ctx)
+		if err != nil {
+			log.Println("Error importing exchange rates:", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseECBDate(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	// eurofxref.csv uses "01 August 2025"
+	return time.Parse("02 January 2006", value)
+}
+
+func columnIndex(header []string, name string) int {
+	for i, column := range header {
+		if column == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func maxInt(values ...int) int {
+	max := values[0]
+	for _, value := range values[1:] {
+		if value > max {
+			max = value
+		}
+	}
+	return max
+}
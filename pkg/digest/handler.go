@@ -0,0 +1,99 @@
+package digest
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	accountpkg "subscritracker/pkg/account"
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/validator"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetSettingsHandler returns the user's digest settings and when the next one goes out
+func GetSettingsHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	settings, err := GetSettings(app, accountID)
+	if err != nil {
+		log.Println("Error getting digest settings:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get digest settings"})
+	}
+
+	return c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettingsHandler opts in or out of the digest and sets its frequency and local send time
+func UpdateSettingsHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	request, err := validator.ValidateDigestSettingsRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	settings, err := SaveSettings(app, accountID, request)
+	if err != nil {
+		log.Println("Error saving digest settings:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save digest settings"})
+	}
+
+	return c.JSON(http.StatusOK, settings)
+}
+
+// PreviewHandler renders the digest the user would get for the current period, as JSON, HTML or text
+func PreviewHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	request, err := validator.ValidateDigestPreviewRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	account, err := accountpkg.GetAccountById(app, accountID)
+	if err != nil {
+		log.Printf("Error getting account: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get account"})
+	}
+
+	frequency := request.Frequency
+	if frequency == "" {
+		setting, err := GetSetting(c.Request().Context(), app.Database, accountID)
+		if err != nil {
+			log.Println("Error getting digest settings:", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get digest settings"})
+		}
+		frequency = setting.Frequency
+	}
+
+	today := time.Now().In(accountLocation(account.Timezone))
+	digest, err := BuildDigest(app, accountID, frequency, today, account.DefaultCurrency)
+	if err != nil {
+		log.Println("Error building digest:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to build digest"})
+	}
+
+	switch request.Format {
+	case "html":
+		html, err := RenderHTML(digest)
+		if err != nil {
+			log.Println("Error rendering digest:", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to render digest"})
+		}
+		return c.HTML(http.StatusOK, html)
+	case "text":
+		text, err := RenderText(digest)
+		if err != nil {
+			log.Println("Error rendering digest:", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to render digest"})
+		}
+		return c.String(http.StatusOK, text)
+	}
+
+	return c.JSON(http.StatusOK, digest)
+}
@@ -0,0 +1,430 @@
+package digest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	accountpkg "subscritracker/pkg/account"
+	"subscritracker/pkg/analysis/monthly_report"
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/currency"
+	"subscritracker/pkg/models"
+	"subscritracker/pkg/money"
+	"subscritracker/pkg/notifications"
+	pricehistory "subscritracker/pkg/price-history"
+	"subscritracker/pkg/recurrence"
+	subscriptionchannels "subscritracker/pkg/subscription-channels"
+	"subscritracker/pkg/utils"
+	"subscritracker/pkg/validator"
+
+	"github.com/uptrace/bun"
+)
+
+// Period returns the first and last day of the digest for date, and of the period before it.
+// A weekly digest covers the 7 days from date, a monthly one the calendar month date is in.
+func Period(frequency string, date time.Time) (start, end, previousStart, previousEnd time.Time) {
+	if frequency == FrequencyMonthly {
+		start = time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, -1), start.AddDate(0, -1, 0), start.AddDate(0, 0, -1)
+	}
+	start = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 0, 6), start.AddDate(0, 0, -7), start.AddDate(0, 0, -1)
+}
+
+// BuildDigest computes the digest of the account for the period of date, in targetCurrency. Charges
+// come from the same expansion as the analysis reports, so the digest and the reports agree.
+func BuildDigest(app *application.App, accountID int, frequency string, date time.Time, targetCurrency string) (*Digest, error) {
+	ctx := context.Background()
+	start, end, previousStart, previousEnd := Period(frequency, date)
+
+	sources, err := monthly_report.LoadChargeSources(app, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	channels, err := subscriptionchannels.GetChannelsBySubscription(ctx, app.Database, sources.Subscriptions)
+	if err != nil {
+		return nil, err
+	}
+
+	converter := currency.NewConverter(app.Database)
+	ratesUsed := currency.NewRatesUsed()
+	digest := &Digest{
+		Frequency:              frequency,
+		PeriodStart:            start,
+		PeriodEnd:              end,
+		Currency:               targetCurrency,
+		UpcomingCharges:        []DigestCharge{},
+		NewSubscriptions:       []DigestSubscription{},
+		CancelledSubscriptions: []DigestSubscription{},
+		TrialsEnding:           []DigestTrial{},
+	}
+
+	// Coming period: what will be charged
+	upcoming, err := monthly_report.ExtractCharges(sources, converter, targetCurrency, start, end)
+	if err != nil {
+		return nil, err
+	}
+	total := money.Zero(targetCurrency)
+	for _, charge := range upcoming {
+		ratesUsed.Add(charge.Rate)
+		digest.UpcomingCharges = append(digest.UpcomingCharges, DigestCharge{
+			SubscriptionDetailsID: charge.SubscriptionDetailsID,
+			ChannelName:           channels[charge.SubscriptionChannelID].ChannelName,
+			Date:                  charge.Date,
+			Amount:                charge.Cost.Amount,
+			OriginalAmount:        charge.Original.Amount,
+			OriginalCurrency:      charge.Original.Currency,
+		})
+		if total, err = total.Add(charge.Cost); err != nil {
+			return nil, err
+		}
+	}
+	digest.TotalSpend = total.Amount
+
+	// Previous period: what was charged
+	previous, err := monthly_report.ExtractCharges(sources, converter, targetCurrency, previousStart, previousEnd)
+	if err != nil {
+		return nil, err
+	}
+	previousTotal := money.Zero(targetCurrency)
+	for _, charge := range previous {
+		ratesUsed.Add(charge.Rate)
+		if previousTotal, err = previousTotal.Add(charge.Cost); err != nil {
+			return nil, err
+		}
+	}
+	digest.PreviousTotalSpend = previousTotal.Amount
+
+	for _, subscription := range sources.Subscriptions {
+		channelName := channels[subscription.SubscriptionChannelID].ChannelName
+
+		if subscription.Status == "active" && subscription.TrialEndDate != nil && inPeriod(*subscription.TrialEndDate, start, end) {
+			digest.TrialsEnding = append(digest.TrialsEnding, DigestTrial{
+				SubscriptionDetailsID: subscription.ID,
+				ChannelName:           channelName,
+				TrialEndDate:          *subscription.TrialEndDate,
+				FirstCharge:           pricehistory.ResolvePrice(subscription, sources.PriceHistory[subscription.ID], *subscription.TrialEndDate),
+				Currency:              subscription.Currency,
+			})
+		}
+
+		summary := DigestSubscription{
+			SubscriptionDetailsID: subscription.ID,
+			ChannelName:           channelName,
+			MonthlyBill:           subscription.MonthlyBill,
+			Currency:              subscription.Currency,
+		}
+		if inPeriod(subscription.CreatedAt, previousStart, previousEnd) {
+			summary.Date = subscription.CreatedAt
+			digest.NewSubscriptions = append(digest.NewSubscriptions, summary)
+		}
+		cancelled, ok := recurrence.StoppedOn(subscription, sources.StopEvents[subscription.ID])
+		if ok && subscription.Status == "cancelled" && inPeriod(cancelled, previousStart, previousEnd) {
+			summary.Date = cancelled
+			digest.CancelledSubscriptions = append(digest.CancelledSubscriptions, summary)
+		}
+	}
+
+	sort.SliceStable(digest.UpcomingCharges, func(i, j int) bool {
+		return digest.UpcomingCharges[i].Date.Before(digest.UpcomingCharges[j].Date)
+	})
+	sort.SliceStable(digest.TrialsEnding, func(i, j int) bool {
+		return digest.TrialsEnding[i].TrialEndDate.Before(digest.TrialsEnding[j].TrialEndDate)
+	})
+
+	digest.Change = digest.TotalSpend - digest.PreviousTotalSpend
+	if digest.PreviousTotalSpend != 0 {
+		digest.ChangePercent = math.Round(float64(digest.Change)/float64(digest.PreviousTotalSpend)*10000) / 100
+	}
+	digest.RatesUsed = ratesUsed.Rates
+
+	return digest, nil
+}
+
+// inPeriod reports whether t falls on a day between start and end, inclusive
+func inPeriod(t, start, end time.Time) bool {
+	day := utils.TruncateToDay(t)
+	return !day.Before(start) && !day.After(end)
+}
+
+// NextSendAt returns the first send time strictly after the given time: send_time local time on the
+// configured weekday for weekly digests, or on the first of the month for monthly ones
+func NextSendAt(setting models.Digest_Setting, location *time.Location, after time.Time) time.Time {
+	local := after.In(location)
+	hour, minute := setting.SendTime.Hour(), setting.SendTime.Minute()
+
+	if setting.Frequency == FrequencyMonthly {
+		next := time.Date(local.Year(), local.Month(), 1, hour, minute, 0, 0, location)
+		if !next.After(after) {
+			next = time.Date(local.Year(), local.Month()+1, 1, hour, minute, 0, 0, location)
+		}
+		return next
+	}
+
+	daysAhead := (setting.SendWeekday - int(local.Weekday()) + 7) % 7
+	next := time.Date(local.Year(), local.Month(), local.Day()+daysAhead, hour, minute, 0, 0, location)
+	if !next.After(after) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+daysAhead+7, hour, minute, 0, 0, location)
+	}
+	return next
+}
+
+func defaultSetting(accountID int) models.Digest_Setting {
+	return models.Digest_Setting{
+		AccountID:   accountID,
+		Frequency:   FrequencyWeekly,
+		SendTime:    time.Date(0, 1, 1, defaultSendHour, 0, 0, 0, time.UTC),
+		SendWeekday: int(defaultSendWeekday),
+	}
+}
+
+// GetSetting returns the digest settings of the account, or the defaults when it never set them
+func GetSetting(ctx context.Context, db bun.IDB, accountID int) (models.Digest_Setting, error) {
+	setting := models.Digest_Setting{}
+	err := db.NewSelect().
+		Model(&setting).
+		Where("account_id = ?", accountID).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return defaultSetting(accountID), nil
+	}
+	if err != nil {
+		return models.Digest_Setting{}, err
+	}
+
+	return setting, nil
+}
+
+// GetSettings returns the digest settings of the account as shown to the user
+func GetSettings(app *application.App, accountID int) (*Settings, error) {
+	setting, err := GetSetting(context.Background(), app.Database, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := accountpkg.GetAccountById(app, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Settings{
+		Enabled:     setting.Enabled,
+		Frequency:   setting.Frequency,
+		SendTime:    setting.SendTime.Format("15:04"),
+		SendWeekday: setting.SendWeekday,
+		Timezone:    account.Timezone,
+		NextSendAt:  setting.NextSendAt,
+		LastSentAt:  setting.LastSentAt,
+	}, nil
+}
+
+// SaveSettings applies a settings update and schedules the next digest. Enabling the digest also adds
+// an email notification channel for the account's address when the account has none, so it gets emailed.
+func SaveSettings(app *application.App, accountID int, request *validator.DigestSettingsRequest) (*Settings, error) {
+	ctx := context.Background()
+	account, err := accountpkg.GetAccountById(app, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	setting, err := GetSetting(ctx, app.Database, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if request.Enabled != nil {
+		setting.Enabled = *request.Enabled
+	}
+	if request.Frequency != "" {
+		setting.Frequency = request.Frequency
+	}
+	if request.ParsedTime != nil {
+		setting.SendTime = *request.ParsedTime
+	}
+	if request.SendWeekday != nil {
+		setting.SendWeekday = *request.SendWeekday
+	}
+
+	now := time.Now()
+	setting.NextSendAt = nil
+	if setting.Enabled {
+		next := NextSendAt(setting, accountLocation(account.Timezone), now)
+		setting.NextSendAt = &next
+	}
+	if setting.CreatedAt.IsZero() {
+		setting.CreatedAt = now
+	}
+	setting.UpdatedAt = now
+
+	err = app.Database.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewInsert().
+			Model(&setting).
+			On("CONFLICT (account_id) DO UPDATE").
+			Set("enabled = EXCLUDED.enabled").
+			Set("frequency = EXCLUDED.frequency").
+			Set("send_time = EXCLUDED.send_time").
+			Set("send_weekday = EXCLUDED.send_weekday").
+			Set("next_send_at = EXCLUDED.next_send_at").
+			Set("updated_at = EXCLUDED.updated_at").
+			Exec(ctx)
+		if err != nil || !setting.Enabled {
+			return err
+		}
+
+		hasEmail, err := tx.NewSelect().
+			Model((*models.Notification_Channel)(nil)).
+			Where("account_id = ? AND type = ?", accountID, notifications.ChannelEmail).
+			Exists(ctx)
+		if err != nil || hasEmail {
+			return err
+		}
+		_, err = tx.NewInsert().
+			Model(&models.Notification_Channel{
+				AccountID: accountID,
+				Type:      notifications.ChannelEmail,
+				Name:      "Email",
+				Config:    models.Notification_Channel_Config{Email: account.Email},
+				Enabled:   true,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}).
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return GetSettings(app, accountID)
+}
+
+// SendDueDigests sends every digest whose send time has come and schedules the next one. Each digest is
+// claimed and queued in its own transaction with SKIP LOCKED, so replicas never send the same one, and the
+// dedupe key makes a retried digest a no-op. It returns how many digests were queued.
+func SendDueDigests(ctx context.Context, app *application.App, now time.Time) (int, error) {
+	sent := 0
+	for i := 0; i < sendBatchSize; i++ {
+		claimed := false
+		err := app.Database.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			due := struct {
+				models.Digest_Setting `bun:",extend"`
+				Timezone              string `bun:"timezone"`
+				DefaultCurrency       string `bun:"default_currency"`
+			}{}
+			err := tx.NewRaw(`
+				SELECT ds.*, a.timezone, a.default_currency
+				FROM digest_settings ds
+				JOIN account a ON ds.account_id = a.id
+				WHERE ds.enabled AND ds.next_send_at <= ?
+				ORDER BY ds.next_send_at ASC
+				LIMIT 1
+				FOR UPDATE OF ds SKIP LOCKED
+			`, now).Scan(ctx, &due)
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			claimed = true
+
+			location := accountLocation(due.Timezone)
+			scheduled := *due.NextSendAt
+			query := tx.NewUpdate().
+				Model((*models.Digest_Setting)(nil)).
+				Set("next_send_at = ?", NextSendAt(due.Digest_Setting, location, now)).
+				Set("updated_at = ?", now).
+				Where("account_id = ?", due.AccountID)
+
+			if now.Sub(scheduled) > staleAfter {
+				log.Printf("Skipping digest of account %d scheduled for %s", due.AccountID, scheduled.Format(time.RFC3339))
+			} else if err := queueDigest(ctx, tx, app, due.Digest_Setting, scheduled.In(location), due.DefaultCurrency, now); err != nil {
+				log.Printf("Error sending digest of account %d: %v", due.AccountID, err)
+			} else {
+				query = query.Set("last_sent_at = ?", now)
+				sent++
+			}
+
+			_, err = query.Exec(ctx)
+			return err
+		})
+		if err != nil {
+			return sent, err
+		}
+		if !claimed {
+			break
+		}
+	}
+
+	return sent, nil
+}
+
+// queueDigest builds and renders the digest of a period and hands it to the notification service
+func queueDigest(ctx context.Context, tx bun.Tx, app *application.App, setting models.Digest_Setting, date time.Time, targetCurrency string, now time.Time) error {
+	digest, err := BuildDigest(app, setting.AccountID, setting.Frequency, date, targetCurrency)
+	if err != nil {
+		return err
+	}
+	text, err := RenderText(digest)
+	if err != nil {
+		return err
+	}
+	html, err := RenderHTML(digest)
+	if err != nil {
+		return err
+	}
+
+	_, err = notifications.Notify(ctx, tx, setting.AccountID, notifications.Event{
+		Type:      notifications.EventDigest,
+		DedupeKey: fmt.Sprintf("digest:%s:%s", setting.Frequency, digest.PeriodStart.Format("2006-01-02")),
+		Title:     Title(digest),
+		Body:      text,
+		HTML:      html,
+		URL:       app.Config.Frontend.URL,
+		Data: map[string]interface{}{
+			"frequency":            digest.Frequency,
+			"period_start":         digest.PeriodStart.Format("2006-01-02"),
+			"period_end":           digest.PeriodEnd.Format("2006-01-02"),
+			"total_spend":          digest.TotalSpend,
+			"previous_total_spend": digest.PreviousTotalSpend,
+			"currency":             digest.Currency,
+		},
+	}, now)
+	return err
+}
+
+// StartDigestWorker sends due digests once at startup and then every interval
+func StartDigestWorker(ctx context.Context, app *application.App, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if sent, err := SendDueDigests(ctx, app, time.Now()); err != nil {
+				log.Printf("Error sending digests: %v", err)
+			} else if sent > 0 {
+				log.Printf("Sent %d digests", sent)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func accountLocation(timezone string) *time.Location {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
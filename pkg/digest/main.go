@@ -0,0 +1,12 @@
+package digest
+
+import (
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/utils"
+)
+
+func RegisterRoutes(app *application.App) {
+	app.Echo.GET("/v1/digest/settings", GetSettingsHandler, utils.AuthMiddleware)
+	app.Echo.PUT("/v1/digest/settings", UpdateSettingsHandler, utils.AuthMiddleware)
+	app.Echo.GET("/v1/digest/preview", PreviewHandler, utils.AuthMiddleware)
+}
@@ -0,0 +1,67 @@
+package digest
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+	"time"
+
+	"subscritracker/pkg/money"
+)
+
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+var templateFuncs = map[string]interface{}{
+	"date": func(t time.Time) string { return t.Format("Mon, Jan 2") },
+	"period": func(frequency string) string {
+		if frequency == FrequencyMonthly {
+			return "month"
+		}
+		return "week"
+	},
+	"signed": func(amount money.Amount) string {
+		if amount > 0 {
+			return "+" + amount.String()
+		}
+		return amount.String()
+	},
+	"percent": func(value float64) string {
+		return fmt.Sprintf("%+.1f%%", value)
+	},
+	"increased": func(amount money.Amount) bool { return amount > 0 },
+}
+
+var (
+	htmlTemplate = htmltemplate.Must(htmltemplate.New("digest.html.tmpl").Funcs(templateFuncs).ParseFS(templateFiles, "templates/digest.html.tmpl"))
+	textTemplate = texttemplate.Must(texttemplate.New("digest.txt.tmpl").Funcs(templateFuncs).ParseFS(templateFiles, "templates/digest.txt.tmpl"))
+)
+
+// RenderHTML renders the digest email body
+func RenderHTML(digest *Digest) (string, error) {
+	var out bytes.Buffer
+	if err := htmlTemplate.Execute(&out, digest); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// RenderText renders the plain text version of the digest, used by email clients without HTML and chat channels
+func RenderText(digest *Digest) (string, error) {
+	var out bytes.Buffer
+	if err := textTemplate.Execute(&out, digest); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// Title is the subject line of the digest
+func Title(digest *Digest) string {
+	period := "week"
+	if digest.Frequency == FrequencyMonthly {
+		period = "month"
+	}
+	return fmt.Sprintf("Your %s ahead: %s %s in subscriptions", period, digest.TotalSpend.String(), digest.Currency)
+}
@@ -0,0 +1,79 @@
+package digest
+
+import (
+	"time"
+
+	"subscritracker/pkg/currency"
+	"subscritracker/pkg/money"
+)
+
+const (
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+)
+
+const (
+	// defaultSendHour is the local time digests go out at when none is set
+	defaultSendHour = 8
+	// defaultSendWeekday is the day weekly digests go out on when none is set
+	defaultSendWeekday = time.Monday
+	// staleAfter skips a digest that could not be sent for this long, e.g. while the server was down
+	staleAfter = 24 * time.Hour
+	// sendBatchSize is how many digests one worker tick sends
+	sendBatchSize = 50
+)
+
+// Digest summarizes the coming period of an account: the charges due, how that compares to the
+// previous period of the same length, what was added or cancelled during it and trials about to end
+type Digest struct {
+	Frequency              string                 `json:"frequency"`
+	PeriodStart            time.Time              `json:"period_start"`
+	PeriodEnd              time.Time              `json:"period_end"`
+	Currency               string                 `json:"currency"`
+	UpcomingCharges        []DigestCharge         `json:"upcoming_charges"`
+	TotalSpend             money.Amount           `json:"total_spend"`
+	PreviousTotalSpend     money.Amount           `json:"previous_total_spend"`
+	Change                 money.Amount           `json:"change"`
+	ChangePercent          float64                `json:"change_percent"`
+	NewSubscriptions       []DigestSubscription   `json:"new_subscriptions"`
+	CancelledSubscriptions []DigestSubscription   `json:"cancelled_subscriptions"`
+	TrialsEnding           []DigestTrial          `json:"trials_ending"`
+	RatesUsed              []currency.AppliedRate `json:"rates_used"`
+}
+
+// DigestCharge is one expected charge. Amount is in the digest currency, OriginalAmount in the subscription's.
+type DigestCharge struct {
+	SubscriptionDetailsID int          `json:"subscription_details_id"`
+	ChannelName           string       `json:"channel_name"`
+	Date                  time.Time    `json:"date"`
+	Amount                money.Amount `json:"amount"`
+	OriginalAmount        money.Amount `json:"original_amount"`
+	OriginalCurrency      string       `json:"original_currency"`
+}
+
+type DigestSubscription struct {
+	SubscriptionDetailsID int          `json:"subscription_details_id"`
+	ChannelName           string       `json:"channel_name"`
+	Date                  time.Time    `json:"date"`
+	MonthlyBill           money.Amount `json:"monthly_bill"`
+	Currency              string       `json:"currency"`
+}
+
+type DigestTrial struct {
+	SubscriptionDetailsID int          `json:"subscription_details_id"`
+	ChannelName           string       `json:"channel_name"`
+	TrialEndDate          time.Time    `json:"trial_end_date"`
+	FirstCharge           money.Amount `json:"first_charge"`
+	Currency              string       `json:"currency"`
+}
+
+// Settings is the digest configuration of an account as shown to the user
+type Settings struct {
+	Enabled     bool       `json:"enabled"`
+	Frequency   string     `json:"frequency"`
+	SendTime    string     `json:"send_time"`
+	SendWeekday int        `json:"send_weekday"`
+	Timezone    string     `json:"timezone"`
+	NextSendAt  *time.Time `json:"next_send_at"`
+	LastSentAt  *time.Time `json:"last_sent_at"`
+}
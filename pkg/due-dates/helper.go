@@ -0,0 +1,135 @@
+package duedates
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/models"
+	pricehistory "subscritracker/pkg/price-history"
+	"subscritracker/pkg/recurrence"
+	"subscritracker/pkg/stream"
+	subscriptionevents "subscritracker/pkg/subscription-events"
+	subscriptionversions "subscritracker/pkg/subscription-versions"
+
+	"github.com/uptrace/bun"
+)
+
+// AdvanceDueDates moves the next_due_date of active subscriptions that have passed to their next
+// billing date on or after today and returns how many moved. Only next_due_date changes; the day a
+// monthly subscription bills on is derived by recurrence, so a clamped date such as Feb 28 does not
+// become the billing day for the months after it.
+func AdvanceDueDates(ctx context.Context, db *bun.DB, now time.Time) (int, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	overdue := []models.Subscription_Details{}
+	err := db.NewSelect().
+		Model(&overdue).
+		Where("status = 'active'").
+		Where("next_due_date < ?", today).
+		Scan(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	advanced := 0
+	for _, subscription := range overdue {
+		nextDueDate, ok := recurrence.NextOccurrence(subscription, today)
+		if !ok {
+			continue
+		}
+
+		moved := false
+		err := db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			// Only the replica that still sees the old date moves it
+			result, err := tx.NewUpdate().
+				Model((*models.Subscription_Details)(nil)).
+				Set("next_due_date = ?", nextDueDate).
+				Set("updated_at = ?", now).
+				Where("id = ? AND next_due_date = ?", subscription.ID, subscription.NextDueDate).
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+			if updated, err := result.RowsAffected(); err != nil || updated == 0 {
+				return err
+			}
+			moved = true
+
+			before := subscription
+			after := subscription
+			after.NextDueDate = nextDueDate
+			after.UpdatedAt = now
+			if err := subscriptionversions.Record(ctx, tx, &before, after); err != nil {
+				return err
+			}
+
+			history := []models.Subscription_Price_History{}
+			err = tx.NewSelect().
+				Model(&history).
+				Where("subscription_details_id = ?", subscription.ID).
+				Order("effective_date ASC").
+				Scan(ctx)
+			if err != nil {
+				return err
+			}
+
+			// Every billing date the subscription moved past was a charge, at the price of that date
+			for _, chargedOn := range recurrence.Occurrences(subscription, subscription.NextDueDate, today.AddDate(0, 0, -1)) {
+				err := subscriptionevents.Record(ctx, tx, &models.Subscription_Event{
+					SubscriptionDetailsID: subscription.ID,
+					AccountID:             subscription.AccountID,
+					Type:                  subscriptionevents.EventCharged,
+					Payload: map[string]interface{}{
+						"due_date": chargedOn.Format("2006-01-02"),
+						"amount":   pricehistory.ResolvePrice(subscription, history, chargedOn),
+						"currency": subscription.Currency,
+					},
+					Actor: subscriptionevents.ActorSystem,
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			return stream.Publish(ctx, tx, subscription.AccountID, stream.EventSubscriptionDueDateAdvanced, map[string]interface{}{
+				"subscription_details_id": subscription.ID,
+				"previous_due_date":       subscription.NextDueDate.Format("2006-01-02"),
+				"next_due_date":           nextDueDate.Format("2006-01-02"),
+			})
+		})
+		if err != nil {
+			log.Printf("Error advancing due date of subscription %d: %v", subscription.ID, err)
+			continue
+		}
+		if moved {
+			advanced++
+		}
+	}
+
+	return advanced, nil
+}
+
+// StartDueDateWorker advances passed due dates once at startup and then every interval
+func StartDueDateWorker(ctx context.Context, app *application.App, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			advanced, err := AdvanceDueDates(ctx, app.Database, time.Now())
+			if err != nil {
+				log.Printf("Error advancing due dates: %v", err)
+			} else if advanced > 0 {
+				log.Printf("Advanced the due date of %d subscriptions", advanced)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
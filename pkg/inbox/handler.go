@@ -0,0 +1,82 @@
+package inbox
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/validator"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetNotificationsHandler lists the user's inbox, optionally only unread notifications
+func GetNotificationsHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	filters, err := validator.ValidateInboxFilters(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	page, err := GetNotifications(app, accountID, filters)
+	if err != nil {
+		log.Println("Error getting notifications:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get notifications"})
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+// GetUnreadCountHandler returns the number of unread notifications, for the inbox badge
+func GetUnreadCountHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	count, err := CountUnread(app, accountID)
+	if err != nil {
+		log.Println("Error counting unread notifications:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to count unread notifications"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]int{"unread_count": count})
+}
+
+// MarkReadHandler marks one notification as read
+func MarkReadHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	notificationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid notification ID"})
+	}
+
+	notification, err := MarkRead(app, accountID, notificationID)
+	if err != nil {
+		if errors.Is(err, ErrNotificationNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error marking notification as read:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to mark notification as read"})
+	}
+
+	return c.JSON(http.StatusOK, notification)
+}
+
+// MarkAllReadHandler marks every notification of the user as read
+func MarkAllReadHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	updated, err := MarkAllRead(app, accountID)
+	if err != nil {
+		log.Println("Error marking notifications as read:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to mark notifications as read"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]int64{"updated": updated})
+}
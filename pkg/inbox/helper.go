@@ -0,0 +1,141 @@
+package inbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/models"
+	"subscritracker/pkg/stream"
+	"subscritracker/pkg/validator"
+
+	"github.com/uptrace/bun"
+)
+
+// Add puts a notification in the account's inbox. A notification with the same dedupe key is only
+// added once, so it returns false when the inbox already had it.
+func Add(ctx context.Context, db bun.IDB, notification *models.Notification) (bool, error) {
+	if notification.Data == nil {
+		notification.Data = map[string]interface{}{}
+	}
+
+	result, err := db.NewInsert().
+		Model(notification).
+		On("CONFLICT (account_id, dedupe_key) DO NOTHING").
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	added, err := result.RowsAffected()
+	if err != nil || added == 0 {
+		return false, err
+	}
+
+	err = stream.Publish(ctx, db, notification.AccountID, stream.EventNotificationCreated, map[string]interface{}{
+		"notification_id": notification.ID,
+		"type":            notification.Type,
+	})
+	return err == nil, err
+}
+
+// GetNotifications lists the account's inbox, newest first
+func GetNotifications(app *application.App, accountID int, filters *validator.InboxFilters) (*NotificationPage, error) {
+	notifications := []models.Notification{}
+	query := app.Database.NewSelect().
+		Model(&notifications).
+		Where("account_id = ?", accountID)
+
+	if filters.Unread {
+		query = query.Where("read_at IS NULL")
+	}
+	if filters.Type != "" {
+		query = query.Where("type = ?", filters.Type)
+	}
+	if filters.ParsedCursor != nil {
+		query = query.Where("id < ?", filters.ParsedCursor.ID)
+	}
+
+	err := query.
+		Order("id DESC").
+		Limit(filters.Limit + 1).
+		Scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	page := &NotificationPage{Data: notifications}
+	if len(notifications) > filters.Limit {
+		page.Data = notifications[:filters.Limit]
+		nextCursor := validator.EncodeCursor(validator.Cursor{ID: page.Data[len(page.Data)-1].ID})
+		page.NextCursor = &nextCursor
+	}
+
+	return page, nil
+}
+
+// CountUnread returns how many notifications in the account's inbox are unread
+func CountUnread(app *application.App, accountID int) (int, error) {
+	return app.Database.NewSelect().
+		Model((*models.Notification)(nil)).
+		Where("account_id = ? AND read_at IS NULL", accountID).
+		Count(context.Background())
+}
+
+// MarkRead marks a notification of the account as read. Marking it again keeps the first read time.
+func MarkRead(app *application.App, accountID, notificationID int) (*models.Notification, error) {
+	notification := &models.Notification{}
+	err := app.Database.NewUpdate().
+		Model(notification).
+		Set("read_at = COALESCE(read_at, ?)", time.Now()).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ? AND account_id = ?", notificationID, accountID).
+		Returning("*").
+		Scan(context.Background())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotificationNotFound
+		}
+		return nil, err
+	}
+
+	err = stream.Publish(context.Background(), app.Database, accountID, stream.EventNotificationRead, map[string]interface{}{
+		"notification_ids": []int{notification.ID},
+	})
+	if err != nil {
+		log.Println("Error publishing stream event:", err)
+	}
+
+	return notification, nil
+}
+
+// MarkAllRead marks every unread notification of the account as read and returns how many there were
+func MarkAllRead(app *application.App, accountID int) (int64, error) {
+	result, err := app.Database.NewUpdate().
+		Model((*models.Notification)(nil)).
+		Set("read_at = ?", time.Now()).
+		Set("updated_at = ?", time.Now()).
+		Where("account_id = ? AND read_at IS NULL", accountID).
+		Exec(context.Background())
+	if err != nil {
+		return 0, err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil || updated == 0 {
+		return updated, err
+	}
+
+	err = stream.Publish(context.Background(), app.Database, accountID, stream.EventNotificationRead, map[string]interface{}{
+		"all": true,
+	})
+	if err != nil {
+		log.Println("Error publishing stream event:", err)
+	}
+
+	return updated, nil
+}
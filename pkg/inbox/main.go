@@ -0,0 +1,13 @@
+package inbox
+
+import (
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/utils"
+)
+
+func RegisterRoutes(app *application.App) {
+	app.Echo.GET("/v1/notifications", GetNotificationsHandler, utils.AuthMiddleware)
+	app.Echo.GET("/v1/notifications/unread-count", GetUnreadCountHandler, utils.AuthMiddleware)
+	app.Echo.POST("/v1/notifications/read-all", MarkAllReadHandler, utils.AuthMiddleware)
+	app.Echo.POST("/v1/notifications/:id/read", MarkReadHandler, utils.AuthMiddleware)
+}
@@ -0,0 +1,14 @@
+package inbox
+
+import (
+	"errors"
+
+	"subscritracker/pkg/models"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+type NotificationPage struct {
+	Data       []models.Notification `json:"data"`
+	NextCursor *string               `json:"next_cursor"`
+}
@@ -23,6 +23,8 @@ type Account struct {
 	Tier              string                 `bun:"tier" json:"tier"`
 	Status            string                 `bun:"status" json:"status"`
 	Features          map[string]interface{} `bun:"features" json:"features"`
+	DefaultCurrency   string                 `bun:"default_currency,nullzero" json:"default_currency"`
+	Timezone          string                 `bun:"timezone,nullzero" json:"timezone"`
 	SubscriptionCount int                    `bun:"subscription_count" json:"subscription_count"`
 	LastLoginAt       time.Time              `bun:"last_login_at" json:"last_login_at"`
 	CreatedAt         time.Time              `bun:"created_at" json:"created_at"`
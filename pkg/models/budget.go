@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"subscritracker/pkg/money"
+
+	"github.com/uptrace/bun"
+)
+
+// Budget is a monthly spending limit for all of an account's subscriptions, or those of a category or tag
+type Budget struct {
+	bun.BaseModel        `bun:"budgets"`
+	ID                   int          `bun:"id,pk,autoincrement" json:"id"`
+	AccountID            int          `bun:"account_id" json:"account_id"`
+	Scope                string       `bun:"scope" json:"scope"`
+	CategoryID           *int         `bun:"category_id" json:"category_id,omitempty"`
+	TagID                *int         `bun:"tag_id" json:"tag_id,omitempty"`
+	Amount               money.Amount `bun:"amount" json:"amount"`
+	Currency             string       `bun:"currency" json:"currency"`
+	NearThresholdPercent int          `bun:"near_threshold_percent" json:"near_threshold_percent"`
+	CreatedAt            time.Time    `bun:"created_at" json:"created_at"`
+	UpdatedAt            time.Time    `bun:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type Calendar_Feed_Token struct {
+	bun.BaseModel `bun:"calendar_feed_tokens"`
+	ID            int        `bun:"id,pk,autoincrement" json:"id"`
+	AccountID     int        `bun:"account_id" json:"account_id"`
+	TokenHash     string     `bun:"token_hash" json:"-"`
+	LastUsedAt    *time.Time `bun:"last_used_at,nullzero" json:"last_used_at,omitempty"`
+	RevokedAt     *time.Time `bun:"revoked_at,nullzero" json:"revoked_at,omitempty"`
+	CreatedAt     time.Time  `bun:"created_at" json:"created_at"`
+	UpdatedAt     time.Time  `bun:"updated_at" json:"updated_at"`
+}
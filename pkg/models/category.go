@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type Category struct {
+	bun.BaseModel `bun:"categories"`
+	ID            int       `bun:"id,pk,autoincrement" json:"id"`
+	AccountID     int       `bun:"account_id" json:"account_id"`
+	Name          string    `bun:"name" json:"name"`
+	Color         string    `bun:"color,nullzero" json:"color,omitempty"`
+	CreatedAt     time.Time `bun:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `bun:"updated_at" json:"updated_at"`
+}
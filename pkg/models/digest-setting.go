@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type Digest_Setting struct {
+	bun.BaseModel `bun:"digest_settings"`
+	AccountID     int        `bun:"account_id,pk" json:"account_id"`
+	Enabled       bool       `bun:"enabled" json:"enabled"`
+	Frequency     string     `bun:"frequency" json:"frequency"`
+	SendTime      time.Time  `bun:"send_time" json:"-"`
+	SendWeekday   int        `bun:"send_weekday" json:"send_weekday"`
+	NextSendAt    *time.Time `bun:"next_send_at,nullzero" json:"next_send_at"`
+	LastSentAt    *time.Time `bun:"last_sent_at,nullzero" json:"last_sent_at"`
+	CreatedAt     time.Time  `bun:"created_at" json:"created_at"`
+	UpdatedAt     time.Time  `bun:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Exchange_Rate means 1 BaseCurrency = Rate QuoteCurrency on RateDate
+type Exchange_Rate struct {
+	bun.BaseModel `bun:"exchange_rates"`
+	ID            int       `bun:"id,pk,autoincrement" json:"id"`
+	BaseCurrency  string    `bun:"base_currency" json:"base_currency"`
+	QuoteCurrency string    `bun:"quote_currency" json:"quote_currency"`
+	Rate          float64   `bun:"rate" json:"rate"`
+	RateDate      time.Time `bun:"rate_date" json:"rate_date"`
+	Source        string    `bun:"source" json:"source"`
+	CreatedAt     time.Time `bun:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `bun:"updated_at" json:"updated_at"`
+}
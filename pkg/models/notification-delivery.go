@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Notification_Channel_Config holds the address of a notification channel; which fields are set depends on its type
+type Notification_Channel_Config struct {
+	Email    string `json:"email,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Endpoint string `json:"endpoint,omitempty"`
+	P256dh   string `json:"p256dh,omitempty"`
+	Auth     string `json:"auth,omitempty"`
+}
+
+type Notification_Channel struct {
+	bun.BaseModel `bun:"notification_channels"`
+	ID            int                         `bun:"id,pk,autoincrement" json:"id"`
+	AccountID     int                         `bun:"account_id" json:"account_id"`
+	Type          string                      `bun:"type" json:"type"`
+	Name          string                      `bun:"name" json:"name"`
+	Config        Notification_Channel_Config `bun:"config,type:jsonb" json:"config"`
+	Enabled       bool                        `bun:"enabled" json:"enabled"`
+	CreatedAt     time.Time                   `bun:"created_at" json:"created_at"`
+	UpdatedAt     time.Time                   `bun:"updated_at" json:"updated_at"`
+}
+
+type Notification_Preference struct {
+	bun.BaseModel `bun:"notification_preferences"`
+	AccountID     int       `bun:"account_id,pk" json:"account_id"`
+	EventType     string    `bun:"event_type,pk" json:"event_type"`
+	ChannelIDs    []int     `bun:"channel_ids,array" json:"channel_ids"`
+	UpdatedAt     time.Time `bun:"updated_at" json:"updated_at"`
+}
+
+type Notification_Setting struct {
+	bun.BaseModel   `bun:"notification_settings"`
+	AccountID       int        `bun:"account_id,pk" json:"account_id"`
+	QuietHoursStart *time.Time `bun:"quiet_hours_start,nullzero" json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd   *time.Time `bun:"quiet_hours_end,nullzero" json:"quiet_hours_end,omitempty"`
+	UpdatedAt       time.Time  `bun:"updated_at" json:"updated_at"`
+}
+
+// Notification_Payload is the rendered content of a notification, shared by every transport.
+// HTML is an optional rich version of Body used by email.
+type Notification_Payload struct {
+	Title string                 `json:"title"`
+	Body  string                 `json:"body"`
+	HTML  string                 `json:"html,omitempty"`
+	URL   string                 `json:"url,omitempty"`
+	Data  map[string]interface{} `json:"data,omitempty"`
+}
+
+type Notification_Dispatch struct {
+	bun.BaseModel         `bun:"notification_dispatches"`
+	ID                    int                  `bun:"id,pk,autoincrement" json:"id"`
+	AccountID             int                  `bun:"account_id" json:"account_id"`
+	NotificationChannelID int                  `bun:"notification_channel_id" json:"notification_channel_id"`
+	EventType             string               `bun:"event_type" json:"event_type"`
+	DedupeKey             string               `bun:"dedupe_key" json:"dedupe_key"`
+	Payload               Notification_Payload `bun:"payload,type:jsonb" json:"payload"`
+	Status                string               `bun:"status" json:"status"`
+	Attempts              int                  `bun:"attempts" json:"attempts"`
+	NextAttemptAt         time.Time            `bun:"next_attempt_at" json:"next_attempt_at"`
+	LockedAt              *time.Time           `bun:"locked_at,nullzero" json:"-"`
+	SentAt                *time.Time           `bun:"sent_at,nullzero" json:"sent_at,omitempty"`
+	LastError             string               `bun:"last_error,nullzero" json:"last_error,omitempty"`
+	CreatedAt             time.Time            `bun:"created_at" json:"created_at"`
+	UpdatedAt             time.Time            `bun:"updated_at" json:"updated_at"`
+}
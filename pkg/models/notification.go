@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Notification is an entry of the in-app inbox
+type Notification struct {
+	bun.BaseModel         `bun:"notifications"`
+	ID                    int                    `bun:"id,pk,autoincrement" json:"id"`
+	AccountID             int                    `bun:"account_id" json:"account_id"`
+	SubscriptionDetailsID *int                   `bun:"subscription_details_id" json:"subscription_details_id,omitempty"`
+	Type                  string                 `bun:"type" json:"type"`
+	DedupeKey             string                 `bun:"dedupe_key" json:"-"`
+	Title                 string                 `bun:"title" json:"title"`
+	Body                  string                 `bun:"body" json:"body"`
+	URL                   string                 `bun:"url,nullzero" json:"url,omitempty"`
+	Data                  map[string]interface{} `bun:"data,type:jsonb" json:"data"`
+	ReadAt                *time.Time             `bun:"read_at,nullzero" json:"read_at"`
+	CreatedAt             time.Time              `bun:"created_at" json:"created_at"`
+	UpdatedAt             time.Time              `bun:"updated_at" json:"updated_at"`
+}
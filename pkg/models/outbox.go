@@ -0,0 +1,25 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Outbox_Message is a side effect of a stored change, waiting to be relayed to its consumers
+type Outbox_Message struct {
+	bun.BaseModel `bun:"outbox_messages"`
+	ID            int64           `bun:"id,pk,autoincrement" json:"id"`
+	AccountID     *int            `bun:"account_id" json:"account_id,omitempty"`
+	Topic         string          `bun:"topic" json:"topic"`
+	Key           string          `bun:"key,nullzero" json:"key,omitempty"`
+	Payload       json.RawMessage `bun:"payload,type:jsonb" json:"payload"`
+	Status        string          `bun:"status" json:"-"`
+	Attempts      int             `bun:"attempts" json:"-"`
+	NextAttemptAt time.Time       `bun:"next_attempt_at" json:"-"`
+	LockedAt      *time.Time      `bun:"locked_at,nullzero" json:"-"`
+	PublishedAt   *time.Time      `bun:"published_at,nullzero" json:"-"`
+	LastError     string          `bun:"last_error,nullzero" json:"-"`
+	CreatedAt     time.Time       `bun:"created_at" json:"created_at"`
+}
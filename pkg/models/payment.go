@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"subscritracker/pkg/money"
+
+	"github.com/uptrace/bun"
+)
+
+// Expected_Charge is a billing date of a subscription that has come due, at the price in effect then
+type Expected_Charge struct {
+	bun.BaseModel         `bun:"expected_charges"`
+	ID                    int          `bun:"id,pk,autoincrement" json:"id"`
+	AccountID             int          `bun:"account_id" json:"account_id"`
+	SubscriptionDetailsID int          `bun:"subscription_details_id" json:"subscription_details_id"`
+	DueDate               time.Time    `bun:"due_date" json:"due_date"`
+	Amount                money.Amount `bun:"amount" json:"amount"`
+	Currency              string       `bun:"currency" json:"currency"`
+	CreatedAt             time.Time    `bun:"created_at" json:"created_at"`
+	UpdatedAt             time.Time    `bun:"updated_at" json:"updated_at"`
+}
+
+// Payment is a charge the user actually paid for a subscription
+type Payment struct {
+	bun.BaseModel         `bun:"payments"`
+	ID                    int          `bun:"id,pk,autoincrement" json:"id"`
+	AccountID             int          `bun:"account_id" json:"account_id"`
+	SubscriptionDetailsID int          `bun:"subscription_details_id" json:"subscription_details_id"`
+	ExpectedChargeID      *int         `bun:"expected_charge_id" json:"expected_charge_id"`
+	Amount                money.Amount `bun:"amount" json:"amount"`
+	Currency              string       `bun:"currency" json:"currency"`
+	PaidAt                time.Time    `bun:"paid_at" json:"paid_at"`
+	Method                string       `bun:"method" json:"method"`
+	ReceiptReference      string       `bun:"receipt_reference,nullzero" json:"receipt_reference,omitempty"`
+	Notes                 string       `bun:"notes,nullzero" json:"notes,omitempty"`
+	VoidedAt              *time.Time   `bun:"voided_at,nullzero" json:"voided_at,omitempty"`
+	VoidReason            string       `bun:"void_reason,nullzero" json:"void_reason,omitempty"`
+	CreatedAt             time.Time    `bun:"created_at" json:"created_at"`
+	UpdatedAt             time.Time    `bun:"updated_at" json:"updated_at"`
+}
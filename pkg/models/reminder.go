@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type Reminder struct {
+	bun.BaseModel         `bun:"reminders"`
+	ID                    int        `bun:"id,pk,autoincrement" json:"id"`
+	AccountID             int        `bun:"account_id" json:"account_id"`
+	SubscriptionDetailsID int        `bun:"subscription_details_id" json:"subscription_details_id"`
+	DueDate               time.Time  `bun:"due_date" json:"due_date"`
+	RemindAt              time.Time  `bun:"remind_at" json:"remind_at"`
+	Status                string     `bun:"status" json:"status"`
+	Attempts              int        `bun:"attempts" json:"attempts"`
+	NextAttemptAt         time.Time  `bun:"next_attempt_at" json:"next_attempt_at"`
+	LockedAt              *time.Time `bun:"locked_at,nullzero" json:"-"`
+	SentAt                *time.Time `bun:"sent_at,nullzero" json:"sent_at,omitempty"`
+	LastError             string     `bun:"last_error,nullzero" json:"last_error,omitempty"`
+	CreatedAt             time.Time  `bun:"created_at" json:"created_at"`
+	UpdatedAt             time.Time  `bun:"updated_at" json:"updated_at"`
+}
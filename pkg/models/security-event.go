@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Security_Event is an entry of the append-only security log
+type Security_Event struct {
+	bun.BaseModel `bun:"security_events"`
+	ID            int64                  `bun:"id,pk,autoincrement" json:"id"`
+	AccountID     *int                   `bun:"account_id" json:"account_id,omitempty"`
+	Email         string                 `bun:"email,nullzero" json:"email,omitempty"`
+	EventType     string                 `bun:"event_type" json:"event_type"`
+	Outcome       string                 `bun:"outcome" json:"outcome"`
+	Reason        string                 `bun:"reason,nullzero" json:"reason,omitempty"`
+	IPAddress     string                 `bun:"ip_address,nullzero" json:"ip_address,omitempty"`
+	UserAgent     string                 `bun:"user_agent,nullzero" json:"user_agent,omitempty"`
+	RequestID     string                 `bun:"request_id,nullzero" json:"request_id,omitempty"`
+	Metadata      map[string]interface{} `bun:"metadata,type:jsonb" json:"metadata"`
+	CreatedAt     time.Time              `bun:"created_at" json:"created_at"`
+}
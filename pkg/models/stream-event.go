@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Stream_Event is a change pushed to the account's /v1/stream connections
+type Stream_Event struct {
+	bun.BaseModel `bun:"stream_events"`
+	ID            int64                  `bun:"id,pk,autoincrement" json:"id"`
+	AccountID     int                    `bun:"account_id" json:"-"`
+	Type          string                 `bun:"type" json:"type"`
+	Payload       map[string]interface{} `bun:"payload,type:jsonb" json:"payload"`
+	CreatedAt     time.Time              `bun:"created_at" json:"created_at"`
+}
@@ -3,25 +3,64 @@ package models
 import (
 	"time"
 
+	"subscritracker/pkg/money"
+	"subscritracker/pkg/utils"
+
 	"github.com/uptrace/bun"
 )
 
 type Subscription_Details struct {
 	bun.BaseModel         `bun:"subscription_details"`
-	ID                    int        `bun:"id,pk,autoincrement" json:"id"`
-	AccountID             int        `bun:"account_id" json:"account_id"`
-	SubscriptionChannelID int        `bun:"subscription_channel_id" json:"subscription_channel_id"`
-	StartDate             time.Time  `bun:"start_date" json:"start_date"`
-	NextDueDate           time.Time  `bun:"next_due_date" json:"next_due_date"`
-	DueType               string     `bun:"due_type" json:"due_type"`
-	DueDayOfMonth         int        `bun:"due_day_of_month" json:"due_day_of_month"`
-	EndDate               *time.Time `bun:"end_date,nullzero" json:"end_date,omitempty"`
-	Status                string     `bun:"status" json:"status"`
-	StartTime             *time.Time `bun:"start_time,nullzero" json:"start_time,omitempty"`
-	DueTime               *time.Time `bun:"due_time,nullzero" json:"due_time,omitempty"`
-	MonthlyBill           float64    `bun:"monthly_bill" json:"monthly_bill"`
-	ReminderDate          *time.Time `bun:"reminder_date,nullzero" json:"reminder_date,omitempty"`
-	ReminderTime          *time.Time `bun:"reminder_time,nullzero" json:"reminder_time,omitempty"`
-	CreatedAt             time.Time  `bun:"created_at" json:"created_at"`
-	UpdatedAt             time.Time  `bun:"updated_at" json:"updated_at"`
+	ID                    int           `bun:"id,pk,autoincrement" json:"id"`
+	AccountID             int           `bun:"account_id" json:"account_id"`
+	SubscriptionChannelID int           `bun:"subscription_channel_id" json:"subscription_channel_id"`
+	CategoryID            *int          `bun:"category_id" json:"category_id,omitempty"`
+	StartDate             time.Time     `bun:"start_date" json:"start_date"`
+	NextDueDate           time.Time     `bun:"next_due_date" json:"next_due_date"`
+	DueType               string        `bun:"due_type" json:"due_type"`
+	DueDayOfMonth         int           `bun:"due_day_of_month" json:"due_day_of_month"`
+	EndDate               *time.Time    `bun:"end_date,nullzero" json:"end_date,omitempty"`
+	Status                string        `bun:"status" json:"status"`
+	StartTime             *time.Time    `bun:"start_time,nullzero" json:"start_time,omitempty"`
+	DueTime               *time.Time    `bun:"due_time,nullzero" json:"due_time,omitempty"`
+	MonthlyBill           money.Amount  `bun:"monthly_bill" json:"monthly_bill"`
+	Currency              string        `bun:"currency,nullzero" json:"currency"`
+	TrialEndDate          *time.Time    `bun:"trial_end_date,nullzero" json:"trial_end_date,omitempty"`
+	IntroPrice            *money.Amount `bun:"intro_price" json:"intro_price,omitempty"`
+	IntroPriceUntil       *time.Time    `bun:"intro_price_until,nullzero" json:"intro_price_until,omitempty"`
+	Notes                 string        `bun:"notes,nullzero" json:"notes,omitempty"`
+	ReminderDate          *time.Time    `bun:"reminder_date,nullzero" json:"reminder_date,omitempty"`
+	ReminderTime          *time.Time    `bun:"reminder_time,nullzero" json:"reminder_time,omitempty"`
+	ReminderDaysBefore    *int          `bun:"reminder_days_before" json:"reminder_days_before,omitempty"`
+	CreatedAt             time.Time     `bun:"created_at" json:"created_at"`
+	UpdatedAt             time.Time     `bun:"updated_at" json:"updated_at"`
+}
+
+// BillForDate returns the amount charged for a billing date, taking the free
+// trial and the introductory price into account
+func (s Subscription_Details) BillForDate(date time.Time) money.Amount {
+	return s.ApplyIntroductoryPricing(date, s.MonthlyBill)
+}
+
+// ApplyIntroductoryPricing returns what is charged on date when the regular price is regularBill.
+// Nothing is charged before the trial ends and the intro price applies strictly before its cutoff.
+func (s Subscription_Details) ApplyIntroductoryPricing(date time.Time, regularBill money.Amount) money.Amount {
+	day := utils.TruncateToDay(date)
+	if s.TrialEndDate != nil && day.Before(utils.TruncateToDay(*s.TrialEndDate)) {
+		return 0
+	}
+	if s.IntroPrice != nil && s.IntroPriceUntil != nil && day.Before(utils.TruncateToDay(*s.IntroPriceUntil)) {
+		return *s.IntroPrice
+	}
+	return regularBill
+}
+
+// IsTrialEndingWithin reports whether the trial ends between now and now + days
+func (s Subscription_Details) IsTrialEndingWithin(now time.Time, days int) bool {
+	if s.TrialEndDate == nil {
+		return false
+	}
+	today := utils.TruncateToDay(now)
+	trialEnd := utils.TruncateToDay(*s.TrialEndDate)
+	return !trialEnd.Before(today) && !trialEnd.After(today.AddDate(0, 0, days))
 }
@@ -6,11 +6,16 @@ import (
 	"github.com/uptrace/bun"
 )
 
+// Subscription_Event is an entry of a subscription's history. Payload holds the details of the
+// event type, e.g. the old and new price of price_changed.
 type Subscription_Event struct {
 	bun.BaseModel         `bun:"subscription_events"`
-	ID                    int       `json:"id" bun:",autoincrement"`
-	SubscriptionDetailsID int       `json:"subscription_details_id"`
-	AccountID             int       `json:"account_id"`
-	CreatedAt             time.Time `json:"created_at"`
-	UpdatedAt             time.Time `json:"updated_at"`
+	ID                    int                    `json:"id" bun:",autoincrement"`
+	SubscriptionDetailsID int                    `json:"subscription_details_id"`
+	AccountID             int                    `json:"account_id"`
+	Type                  string                 `json:"type"`
+	Payload               map[string]interface{} `json:"payload" bun:",type:jsonb"`
+	Actor                 string                 `json:"actor"`
+	CreatedAt             time.Time              `json:"created_at"`
+	UpdatedAt             time.Time              `json:"updated_at"`
 }
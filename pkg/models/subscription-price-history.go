@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"subscritracker/pkg/money"
+
+	"github.com/uptrace/bun"
+)
+
+type Subscription_Price_History struct {
+	bun.BaseModel         `bun:"subscription_price_history"`
+	ID                    int          `bun:"id,pk,autoincrement" json:"id"`
+	SubscriptionDetailsID int          `bun:"subscription_details_id" json:"subscription_details_id"`
+	AccountID             int          `bun:"account_id" json:"account_id"`
+	MonthlyBill           money.Amount `bun:"monthly_bill" json:"monthly_bill"`
+	EffectiveDate         time.Time    `bun:"effective_date" json:"effective_date"`
+	CreatedAt             time.Time    `bun:"created_at" json:"created_at"`
+	UpdatedAt             time.Time    `bun:"updated_at" json:"updated_at"`
+}
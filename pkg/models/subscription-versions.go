@@ -0,0 +1,25 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Subscription_Version is one stored state of a subscription. Before and After are the subscription
+// as the API returns it; Changes maps each changed field to {"from", "to"}.
+type Subscription_Version struct {
+	bun.BaseModel         `bun:"subscription_versions"`
+	ID                    int64           `bun:"id,pk,autoincrement" json:"id"`
+	SubscriptionDetailsID int             `bun:"subscription_details_id" json:"subscription_details_id"`
+	AccountID             int             `bun:"account_id" json:"-"`
+	Version               int             `bun:"version" json:"version"`
+	Operation             string          `bun:"operation" json:"operation"`
+	Before                json.RawMessage `bun:"before,type:jsonb,nullzero" json:"before"`
+	After                 json.RawMessage `bun:"after,type:jsonb" json:"after"`
+	Changes               json.RawMessage `bun:"changes,type:jsonb" json:"changes"`
+	Actor                 string          `bun:"actor" json:"actor"`
+	RequestID             string          `bun:"request_id,nullzero" json:"request_id,omitempty"`
+	RecordedAt            time.Time       `bun:"recorded_at" json:"recorded_at"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type Tag struct {
+	bun.BaseModel `bun:"tags"`
+	ID            int       `bun:"id,pk,autoincrement" json:"id"`
+	AccountID     int       `bun:"account_id" json:"account_id"`
+	Name          string    `bun:"name" json:"name"`
+	Color         string    `bun:"color,nullzero" json:"color,omitempty"`
+	CreatedAt     time.Time `bun:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `bun:"updated_at" json:"updated_at"`
+}
+
+type Subscription_Details_Tag struct {
+	bun.BaseModel         `bun:"subscription_details_tags"`
+	SubscriptionDetailsID int       `bun:"subscription_details_id,pk" json:"subscription_details_id"`
+	TagID                 int       `bun:"tag_id,pk" json:"tag_id"`
+	CreatedAt             time.Time `bun:"created_at" json:"created_at"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Webhook_Endpoint is a URL the account's events are posted to. The secret signs every delivery
+// and is only shown when the endpoint is created or its secret rotated.
+type Webhook_Endpoint struct {
+	bun.BaseModel `bun:"webhook_endpoints"`
+	ID            int       `bun:"id,pk,autoincrement" json:"id"`
+	AccountID     int       `bun:"account_id" json:"account_id"`
+	URL           string    `bun:"url" json:"url"`
+	Description   string    `bun:"description,nullzero" json:"description,omitempty"`
+	Secret        string    `bun:"secret" json:"-"`
+	EventTypes    []string  `bun:"event_types,array" json:"event_types"`
+	Enabled       bool      `bun:"enabled" json:"enabled"`
+	CreatedAt     time.Time `bun:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `bun:"updated_at" json:"updated_at"`
+}
+
+// Webhook_Delivery is one event queued for, or delivered to, a webhook endpoint
+type Webhook_Delivery struct {
+	bun.BaseModel     `bun:"webhook_deliveries"`
+	ID                int64           `bun:"id,pk,autoincrement" json:"id"`
+	WebhookEndpointID int             `bun:"webhook_endpoint_id" json:"webhook_endpoint_id"`
+	AccountID         int             `bun:"account_id" json:"account_id"`
+	EventID           string          `bun:"event_id" json:"event_id"`
+	EventType         string          `bun:"event_type" json:"event_type"`
+	Payload           json.RawMessage `bun:"payload,type:jsonb" json:"payload"`
+	Status            string          `bun:"status" json:"status"`
+	Attempts          int             `bun:"attempts" json:"attempts"`
+	NextAttemptAt     time.Time       `bun:"next_attempt_at" json:"next_attempt_at"`
+	LockedAt          *time.Time      `bun:"locked_at,nullzero" json:"-"`
+	ResponseStatus    *int            `bun:"response_status" json:"response_status,omitempty"`
+	ResponseBody      string          `bun:"response_body,nullzero" json:"response_body,omitempty"`
+	LastError         string          `bun:"last_error,nullzero" json:"last_error,omitempty"`
+	DeliveredAt       *time.Time      `bun:"delivered_at,nullzero" json:"delivered_at,omitempty"`
+	RedeliveryOf      *int64          `bun:"redelivery_of" json:"redelivery_of,omitempty"`
+	CreatedAt         time.Time       `bun:"created_at" json:"created_at"`
+	UpdatedAt         time.Time       `bun:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,249 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Scale is the number of decimal places stored for every amount, matching NUMERIC(10, 2)
+const Scale = 2
+
+const minorPerMajor = 100
+
+// MaxAmount is the largest value a NUMERIC(10, 2) column can hold
+const MaxAmount Amount = 99999999_99
+
+var (
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrTooManyDecimal   = errors.New("amount cannot have more than 2 decimal places")
+	ErrCurrencyMismatch = errors.New("amounts are in different currencies")
+)
+
+// Amount is an exact decimal amount stored in minor units (cents).
+// It scans from and writes to NUMERIC columns as text and marshals to JSON as a plain number,
+// so sums never drift the way float64 totals do.
+type Amount int64
+
+// Money is an amount with its ISO-4217 currency. Totals that may see more than one currency are
+// kept as Money, so adding amounts in different currencies is an error instead of a wrong sum.
+type Money struct {
+	Amount   Amount `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// New returns amount in currency, with the currency code upper cased
+func New(amount Amount, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(strings.TrimSpace(currency))}
+}
+
+// Zero returns nothing in currency, the start of a total in that currency
+func Zero(currency string) Money {
+	return New(0, currency)
+}
+
+// Add returns the sum of two amounts in the same currency
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) String() string {
+	return m.Amount.String() + " " + m.Currency
+}
+
+// FromMinor creates an amount from minor units (cents)
+func FromMinor(minor int64) Amount {
+	return Amount(minor)
+}
+
+// FromFloat converts a float to the nearest cent. Only use it at boundaries where the
+// value is already a float (e.g. exchange rates), never for stored amounts.
+func FromFloat(value float64) Amount {
+	return Amount(math.Round(value * minorPerMajor))
+}
+
+// ParseAmount parses a decimal string like "12", "12.3", "-0.99" or "1e2" exactly.
+// More than 2 significant decimal places is an error rather than being rounded.
+func ParseAmount(value string) (Amount, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, ErrInvalidAmount
+	}
+
+	rat, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return 0, ErrInvalidAmount
+	}
+
+	minor := new(big.Rat).Mul(rat, big.NewRat(minorPerMajor, 1))
+	if !minor.IsInt() {
+		return 0, ErrTooManyDecimal
+	}
+	if !minor.Num().IsInt64() {
+		return 0, ErrInvalidAmount
+	}
+
+	return Amount(minor.Num().Int64()), nil
+}
+
+// MustParse is ParseAmount for constants, panicking on invalid input
+func MustParse(value string) Amount {
+	amount, err := ParseAmount(value)
+	if err != nil {
+		panic(fmt.Sprintf("money: %q: %v", value, err))
+	}
+	return amount
+}
+
+// Minor returns the amount in minor units (cents)
+func (a Amount) Minor() int64 {
+	return int64(a)
+}
+
+// Float64 returns the amount as a float for display or ratio computations only
+func (a Amount) Float64() float64 {
+	return float64(a) / minorPerMajor
+}
+
+// String formats the amount with exactly 2 decimal places, e.g. "12.30"
+func (a Amount) String() string {
+	sign := ""
+	minor := int64(a)
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, minor/minorPerMajor, minor%minorPerMajor)
+}
+
+func (a Amount) IsNegative() bool {
+	return a < 0
+}
+
+func (a Amount) IsZero() bool {
+	return a == 0
+}
+
+// Rat returns the exact rational value of the amount
+func (a Amount) Rat() *big.Rat {
+	return big.NewRat(int64(a), minorPerMajor)
+}
+
+// MulRat multiplies by an exact ratio, rounding half away from zero to the cent
+func (a Amount) MulRat(ratio *big.Rat) Amount {
+	minor := new(big.Rat).Mul(big.NewRat(int64(a), 1), ratio)
+	return roundRat(minor)
+}
+
+// MulFloat multiplies by a float factor such as an exchange rate, rounding half away from zero to the cent.
+// The factor is taken at its exact binary value so the result does not depend on float formatting.
+func (a Amount) MulFloat(factor float64) Amount {
+	ratio := new(big.Rat)
+	if ratio.SetFloat64(factor) == nil {
+		return 0
+	}
+	return a.MulRat(ratio)
+}
+
+// Sum adds amounts exactly
+func Sum(amounts ...Amount) Amount {
+	var total Amount
+	for _, amount := range amounts {
+		total += amount
+	}
+	return total
+}
+
+func roundRat(value *big.Rat) Amount {
+	num := new(big.Int).Set(value.Num())
+	den := value.Denom()
+
+	negative := num.Sign() < 0
+	num.Abs(num)
+
+	quotient, remainder := new(big.Int).QuoRem(num, den, new(big.Int))
+	// Round half away from zero: compare 2 * remainder with the denominator
+	if remainder.Lsh(remainder, 1).Cmp(den) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	if negative {
+		quotient.Neg(quotient)
+	}
+
+	return Amount(quotient.Int64())
+}
+
+// MarshalJSON writes the amount as a JSON number with 2 decimals, e.g. 12.30
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or numeric string and rejects more than 2 decimals
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	text := strings.TrimSpace(string(data))
+	if text == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	}
+
+	amount, err := ParseAmount(text)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// UnmarshalParam lets echo bind amounts from query and form parameters
+func (a *Amount) UnmarshalParam(param string) error {
+	amount, err := ParseAmount(param)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// Scan reads NUMERIC values, which the driver returns as text
+func (a *Amount) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case nil:
+		*a = 0
+		return nil
+	case []byte:
+		return a.scanText(string(value))
+	case string:
+		return a.scanText(value)
+	case int64:
+		*a = Amount(value * minorPerMajor)
+		return nil
+	case float64:
+		*a = FromFloat(value)
+		return nil
+	default:
+		return fmt.Errorf("money: cannot scan %T into Amount", src)
+	}
+}
+
+func (a *Amount) scanText(text string) error {
+	amount, err := ParseAmount(text)
+	if err != nil {
+		return fmt.Errorf("money: cannot scan %q into Amount: %w", text, err)
+	}
+	*a = amount
+	return nil
+}
+
+// Value writes the amount as exact decimal text for NUMERIC columns
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
@@ -0,0 +1,176 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+const propertyRuns = 2000
+
+// randomNumeric returns a random value a NUMERIC(10, 2) column can hold, formatted the way Postgres returns it
+func randomNumeric(rng *rand.Rand) string {
+	minor := rng.Int63n(int64(MaxAmount) + 1)
+	if rng.Intn(4) == 0 {
+		minor = -minor
+	}
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, minor/100, minor%100)
+}
+
+// ratSum is the oracle for SQL SUM over NUMERIC: exact decimal addition
+func ratSum(values []string) *big.Rat {
+	total := new(big.Rat)
+	for _, value := range values {
+		rat, ok := new(big.Rat).SetString(value)
+		if !ok {
+			panic("invalid oracle value " + value)
+		}
+		total.Add(total, rat)
+	}
+	return total
+}
+
+func TestSumMatchesExactDecimalSum(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for run := 0; run < propertyRuns; run++ {
+		values := make([]string, rng.Intn(200))
+		amounts := make([]Amount, len(values))
+		for i := range values {
+			values[i] = randomNumeric(rng)
+
+			var err error
+			if amounts[i], err = ParseAmount(values[i]); err != nil {
+				t.Fatalf("ParseAmount(%q): %v", values[i], err)
+			}
+		}
+
+		want := ratSum(values)
+		got := Sum(amounts...)
+		if got.Rat().Cmp(want) != 0 {
+			t.Fatalf("Sum(%v) = %s, want %s", values, got, want.FloatString(2))
+		}
+		if got.String() != want.FloatString(2) {
+			t.Fatalf("Sum(%v) formats as %s, want %s", values, got, want.FloatString(2))
+		}
+	}
+}
+
+func TestScannedTotalsMatchExactDecimalSum(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for run := 0; run < propertyRuns; run++ {
+		values := make([]string, rng.Intn(50))
+		var total Amount
+		for i := range values {
+			values[i] = randomNumeric(rng)
+
+			// The driver hands NUMERIC columns over as text
+			var amount Amount
+			if err := amount.Scan([]byte(values[i])); err != nil {
+				t.Fatalf("Scan(%q): %v", values[i], err)
+			}
+			total += amount
+		}
+
+		// SUM(NUMERIC) comes back as text too
+		var summed Amount
+		if err := summed.Scan(ratSum(values).FloatString(2)); err != nil {
+			t.Fatalf("Scan(sum): %v", err)
+		}
+		if total != summed {
+			t.Fatalf("total of %v = %s, SQL SUM = %s", values, total, summed)
+		}
+	}
+}
+
+func TestRoundTrips(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for run := 0; run < propertyRuns; run++ {
+		value := randomNumeric(rng)
+		amount := MustParse(value)
+
+		if amount.String() != value {
+			t.Fatalf("String() = %s, want %s", amount, value)
+		}
+
+		stored, err := amount.Value()
+		if err != nil {
+			t.Fatalf("Value(): %v", err)
+		}
+		var scanned Amount
+		if err := scanned.Scan(stored); err != nil || scanned != amount {
+			t.Fatalf("Scan(Value(%s)) = %s, %v", value, scanned, err)
+		}
+
+		encoded, err := json.Marshal(amount)
+		if err != nil {
+			t.Fatalf("Marshal(%s): %v", value, err)
+		}
+		var decoded Amount
+		if err := json.Unmarshal(encoded, &decoded); err != nil || decoded != amount {
+			t.Fatalf("Unmarshal(%s) = %s, %v", encoded, decoded, err)
+		}
+	}
+}
+
+func TestParseAmountRejectsSubCentPrecision(t *testing.T) {
+	for _, value := range []string{"0.001", "1.999", "-12.345"} {
+		if _, err := ParseAmount(value); err != ErrTooManyDecimal {
+			t.Errorf("ParseAmount(%q) error = %v, want %v", value, err, ErrTooManyDecimal)
+		}
+	}
+	for _, value := range []string{"", "abc", "1e30"} {
+		if _, err := ParseAmount(value); err == nil {
+			t.Errorf("ParseAmount(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestMulRatRoundsHalfAwayFromZero(t *testing.T) {
+	cases := []struct {
+		amount Amount
+		ratio  *big.Rat
+		want   Amount
+	}{
+		{FromMinor(1), big.NewRat(1, 2), FromMinor(1)},
+		{FromMinor(-1), big.NewRat(1, 2), FromMinor(-1)},
+		{FromMinor(1), big.NewRat(1, 3), FromMinor(0)},
+		{MustParse("10.00"), big.NewRat(1, 3), MustParse("3.33")},
+		{MustParse("20.00"), big.NewRat(1, 3), MustParse("6.67")},
+	}
+	for _, tc := range cases {
+		if got := tc.amount.MulRat(tc.ratio); got != tc.want {
+			t.Errorf("%s * %s = %s, want %s", tc.amount, tc.ratio, got, tc.want)
+		}
+	}
+}
+
+func TestMoneyAddOnlyCombinesTheSameCurrency(t *testing.T) {
+	cases := []struct {
+		left, right Money
+		want        Money
+		wantErr     error
+	}{
+		{New(MustParse("1.10"), "eur"), New(MustParse("2.20"), "EUR"), New(MustParse("3.30"), "EUR"), nil},
+		{Zero("USD"), New(MustParse("-0.01"), " usd "), New(MustParse("-0.01"), "USD"), nil},
+		{New(MustParse("1.00"), "EUR"), New(MustParse("1.00"), "USD"), Money{}, ErrCurrencyMismatch},
+		{Zero(""), New(MustParse("1.00"), "USD"), Money{}, ErrCurrencyMismatch},
+	}
+	for _, tc := range cases {
+		got, err := tc.left.Add(tc.right)
+		if !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s + %s error = %v, want %v", tc.left, tc.right, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s + %s = %s, want %s", tc.left, tc.right, got, tc.want)
+		}
+	}
+}
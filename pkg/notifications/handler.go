@@ -0,0 +1,217 @@
+package notifications
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/models"
+	"subscritracker/pkg/validator"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetChannelsHandler lists the user's notification channels
+func GetChannelsHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	channels, err := GetChannels(app, accountID)
+	if err != nil {
+		log.Println("Error getting notification channels:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get notification channels"})
+	}
+
+	return c.JSON(http.StatusOK, channels)
+}
+
+// CreateChannelHandler adds an email address, webhook, browser push subscription or chat webhook
+func CreateChannelHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	request, config, err := validator.ValidateNotificationChannelRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	channel := &models.Notification_Channel{
+		AccountID: accountID,
+		Type:      request.Type,
+		Name:      request.Name,
+		Config:    *config,
+		Enabled:   request.Enabled == nil || *request.Enabled,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := CreateChannel(app, channel); err != nil {
+		log.Println("Error creating notification channel:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create notification channel"})
+	}
+
+	return c.JSON(http.StatusCreated, channel)
+}
+
+// UpdateChannelHandler renames, enables or disables a channel, or replaces its address
+func UpdateChannelHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	channelID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid notification channel ID"})
+	}
+
+	channel, err := GetChannel(app, accountID, channelID)
+	if err != nil {
+		if errors.Is(err, ErrChannelNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error getting notification channel:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get notification channel"})
+	}
+
+	request, config, err := validator.ValidateNotificationChannelUpdate(c, channel.Type)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	if request.Name != "" {
+		channel.Name = request.Name
+	}
+	if config != nil {
+		channel.Config = *config
+	}
+	if request.Enabled != nil {
+		channel.Enabled = *request.Enabled
+	}
+	if err := UpdateChannel(app, channel); err != nil {
+		log.Println("Error updating notification channel:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update notification channel"})
+	}
+
+	return c.JSON(http.StatusOK, channel)
+}
+
+// DeleteChannelHandler deletes a notification channel
+func DeleteChannelHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	channelID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid notification channel ID"})
+	}
+
+	if err := DeleteChannel(app, accountID, channelID); err != nil {
+		if errors.Is(err, ErrChannelNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error deleting notification channel:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete notification channel"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// TestChannelHandler sends a test notification to a channel and returns the logged dispatch
+func TestChannelHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	channelID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid notification channel ID"})
+	}
+
+	channel, err := GetChannel(app, accountID, channelID)
+	if err != nil {
+		if errors.Is(err, ErrChannelNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error getting notification channel:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get notification channel"})
+	}
+
+	dispatch, err := SendTestNotification(app, NewTransports(app.Config.Notifications), *channel)
+	if err != nil {
+		log.Println("Error sending test notification:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to send test notification"})
+	}
+
+	return c.JSON(http.StatusOK, dispatch)
+}
+
+// GetVAPIDPublicKeyHandler returns the key browsers need to create a push subscription for this server
+func GetVAPIDPublicKeyHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+
+	publicKey := app.Config.Notifications.VAPID.PublicKey
+	if publicKey == "" {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Web Push is not configured"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"public_key": publicKey})
+}
+
+// GetPreferencesHandler returns which channels each event type goes to and the quiet hours
+func GetPreferencesHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	preferences, err := GetPreferences(app, accountID)
+	if err != nil {
+		log.Println("Error getting notification preferences:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get notification preferences"})
+	}
+
+	return c.JSON(http.StatusOK, preferences)
+}
+
+// UpdatePreferencesHandler replaces the notification preferences and quiet hours of the user
+func UpdatePreferencesHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	request, err := validator.ValidateNotificationPreferencesRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	if err := SavePreferences(app, accountID, request); err != nil {
+		if errors.Is(err, ErrChannelNotFound) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "channel_ids contains a notification channel that cannot be found"})
+		}
+		log.Println("Error saving notification preferences:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save notification preferences"})
+	}
+
+	preferences, err := GetPreferences(app, accountID)
+	if err != nil {
+		log.Println("Error getting notification preferences:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get notification preferences"})
+	}
+
+	return c.JSON(http.StatusOK, preferences)
+}
+
+// GetDispatchesHandler lists every notification sent or queued for the user with its status and attempts
+func GetDispatchesHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	filters, err := validator.ValidateNotificationDispatchFilters(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	page, err := GetDispatches(app, accountID, filters)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get notification dispatches"})
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,750 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/inbox"
+	"subscritracker/pkg/models"
+	"subscritracker/pkg/money"
+	pricehistory "subscritracker/pkg/price-history"
+	"subscritracker/pkg/reminders"
+	subscriptionevents "subscritracker/pkg/subscription-events"
+	"subscritracker/pkg/validator"
+
+	"github.com/uptrace/bun"
+)
+
+// GetChannels returns the notification channels of the account
+func GetChannels(app *application.App, accountID int) ([]models.Notification_Channel, error) {
+	channels := []models.Notification_Channel{}
+	err := app.Database.NewSelect().
+		Model(&channels).
+		Where("account_id = ?", accountID).
+		Order("id ASC").
+		Scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return channels, nil
+}
+
+// GetChannel loads a notification channel only if it belongs to the account
+func GetChannel(app *application.App, accountID, channelID int) (*models.Notification_Channel, error) {
+	channel := &models.Notification_Channel{}
+	err := app.Database.NewSelect().
+		Model(channel).
+		Where("id = ? AND account_id = ?", channelID, accountID).
+		Scan(context.Background())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrChannelNotFound
+		}
+		return nil, err
+	}
+
+	return channel, nil
+}
+
+func CreateChannel(app *application.App, channel *models.Notification_Channel) error {
+	_, err := app.Database.NewInsert().
+		Model(channel).
+		Returning("*").
+		Exec(context.Background())
+	return err
+}
+
+func UpdateChannel(app *application.App, channel *models.Notification_Channel) error {
+	channel.UpdatedAt = time.Now()
+	_, err := app.Database.NewUpdate().
+		Model(channel).
+		Column("name", "config", "enabled", "updated_at").
+		WherePK().
+		Exec(context.Background())
+	return err
+}
+
+// DeleteChannel deletes a notification channel and removes it from the account's preferences.
+// Its dispatch log is deleted with it.
+func DeleteChannel(app *application.App, accountID, channelID int) error {
+	return app.Database.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		result, err := tx.NewDelete().
+			Model((*models.Notification_Channel)(nil)).
+			Where("id = ? AND account_id = ?", channelID, accountID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if deleted, _ := result.RowsAffected(); deleted == 0 {
+			return ErrChannelNotFound
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*models.Notification_Preference)(nil)).
+			Set("channel_ids = array_remove(channel_ids, ?)", channelID).
+			Set("updated_at = ?", time.Now()).
+			Where("account_id = ?", accountID).
+			Exec(ctx)
+		return err
+	})
+}
+
+// GetPreferences returns which channels each event type goes to, and the account's quiet hours
+func GetPreferences(app *application.App, accountID int) (*Preferences, error) {
+	ctx := context.Background()
+
+	stored := []models.Notification_Preference{}
+	err := app.Database.NewSelect().
+		Model(&stored).
+		Where("account_id = ?", accountID).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byEventType := map[string][]int{}
+	for _, preference := range stored {
+		byEventType[preference.EventType] = preference.ChannelIDs
+	}
+
+	preferences := &Preferences{Events: []EventPreference{}}
+	for _, eventType := range EventTypes {
+		channelIDs, chosen := byEventType[eventType]
+		if channelIDs == nil {
+			channelIDs = []int{}
+		}
+		preferences.Events = append(preferences.Events, EventPreference{
+			EventType:   eventType,
+			AllChannels: !chosen,
+			ChannelIDs:  channelIDs,
+		})
+	}
+
+	settings, err := loadDeliverySettings(ctx, app.Database, accountID)
+	if err != nil {
+		return nil, err
+	}
+	preferences.Timezone = settings.Timezone
+	if settings.QuietHoursStart != nil && settings.QuietHoursEnd != nil {
+		start, end := settings.QuietHoursStart.Format("15:04"), settings.QuietHoursEnd.Format("15:04")
+		preferences.QuietHoursStart, preferences.QuietHoursEnd = &start, &end
+	}
+
+	return preferences, nil
+}
+
+// SavePreferences replaces the account's notification routing and quiet hours.
+// Event types that are not given, or given without channel_ids, go to every enabled channel.
+func SavePreferences(app *application.App, accountID int, request *validator.NotificationPreferences) error {
+	return app.Database.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		channelIDs := []int{}
+		for _, preference := range request.Preferences {
+			if preference.ChannelIDs != nil {
+				channelIDs = append(channelIDs, *preference.ChannelIDs...)
+			}
+		}
+		if len(channelIDs) > 0 {
+			owned, err := tx.NewSelect().
+				Model((*models.Notification_Channel)(nil)).
+				Where("account_id = ? AND id IN (?)", accountID, bun.In(channelIDs)).
+				Count(ctx)
+			if err != nil {
+				return err
+			}
+			if owned != len(uniqueInts(channelIDs)) {
+				return ErrChannelNotFound
+			}
+		}
+
+		_, err := tx.NewDelete().
+			Model((*models.Notification_Preference)(nil)).
+			Where("account_id = ?", accountID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		preferences := []models.Notification_Preference{}
+		for _, preference := range request.Preferences {
+			if preference.ChannelIDs == nil {
+				continue
+			}
+			preferences = append(preferences, models.Notification_Preference{
+				AccountID:  accountID,
+				EventType:  preference.EventType,
+				ChannelIDs: *preference.ChannelIDs,
+				UpdatedAt:  time.Now(),
+			})
+		}
+		if len(preferences) > 0 {
+			if _, err := tx.NewInsert().Model(&preferences).Exec(ctx); err != nil {
+				return err
+			}
+		}
+
+		settings := &models.Notification_Setting{
+			AccountID:       accountID,
+			QuietHoursStart: request.QuietHoursStart,
+			QuietHoursEnd:   request.QuietHoursEnd,
+			UpdatedAt:       time.Now(),
+		}
+		_, err = tx.NewInsert().
+			Model(settings).
+			On("CONFLICT (account_id) DO UPDATE").
+			Set("quiet_hours_start = EXCLUDED.quiet_hours_start").
+			Set("quiet_hours_end = EXCLUDED.quiet_hours_end").
+			Set("updated_at = EXCLUDED.updated_at").
+			Exec(ctx)
+		return err
+	})
+}
+
+// Notify adds an event to the account's inbox and queues it for every channel the account routes it to.
+// Events inside the account's quiet hours are held until they end. The dedupe key makes notifying the
+// same event again a no-op, so callers that retry are safe. It returns how many dispatches were queued.
+func Notify(ctx context.Context, db bun.IDB, accountID int, event Event, now time.Time) (int64, error) {
+	entry := &models.Notification{
+		AccountID: accountID,
+		Type:      event.Type,
+		DedupeKey: event.DedupeKey,
+		Title:     event.Title,
+		Body:      event.Body,
+		URL:       event.URL,
+		Data:      event.Data,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if event.SubscriptionDetailsID > 0 {
+		entry.SubscriptionDetailsID = &event.SubscriptionDetailsID
+	}
+	if _, err := inbox.Add(ctx, db, entry); err != nil {
+		return 0, err
+	}
+
+	channels := []models.Notification_Channel{}
+	err := db.NewRaw(`
+		SELECT nc.*
+		FROM notification_channels nc
+		LEFT JOIN notification_preferences np ON np.account_id = nc.account_id AND np.event_type = ?
+		WHERE nc.account_id = ?
+			AND nc.enabled
+			AND (np.account_id IS NULL OR nc.id = ANY(np.channel_ids))
+	`, event.Type, accountID).Scan(ctx, &channels)
+	if err != nil {
+		return 0, err
+	}
+	if len(channels) == 0 {
+		return 0, nil
+	}
+
+	settings, err := loadDeliverySettings(ctx, db, accountID)
+	if err != nil {
+		return 0, err
+	}
+	sendAt := DeferForQuietHours(now, settings.location(), settings.QuietHoursStart, settings.QuietHoursEnd)
+
+	dispatches := []models.Notification_Dispatch{}
+	for _, channel := range channels {
+		dispatches = append(dispatches, models.Notification_Dispatch{
+			AccountID:             accountID,
+			NotificationChannelID: channel.ID,
+			EventType:             event.Type,
+			DedupeKey:             event.DedupeKey,
+			Payload:               event.payload(),
+			Status:                StatusPending,
+			NextAttemptAt:         sendAt,
+			CreatedAt:             now,
+			UpdatedAt:             now,
+		})
+	}
+
+	result, err := db.NewInsert().
+		Model(&dispatches).
+		On("CONFLICT (dedupe_key, notification_channel_id) DO NOTHING").
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (e Event) payload() models.Notification_Payload {
+	return models.Notification_Payload{Title: e.Title, Body: e.Body, HTML: e.HTML, URL: e.URL, Data: e.Data}
+}
+
+type deliverySettings struct {
+	Timezone        string     `bun:"timezone"`
+	QuietHoursStart *time.Time `bun:"quiet_hours_start"`
+	QuietHoursEnd   *time.Time `bun:"quiet_hours_end"`
+}
+
+func (s deliverySettings) location() *time.Location {
+	location, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+func loadDeliverySettings(ctx context.Context, db bun.IDB, accountID int) (*deliverySettings, error) {
+	settings := &deliverySettings{}
+	err := db.NewRaw(`
+		SELECT a.timezone, ns.quiet_hours_start, ns.quiet_hours_end
+		FROM account a
+		LEFT JOIN notification_settings ns ON ns.account_id = a.id
+		WHERE a.id = ?
+	`, accountID).Scan(ctx, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// DeferForQuietHours returns when a notification due at now may be sent: now, or the end of the
+// quiet hours now falls in. Quiet hours are local times of day and wrap around midnight when start is after end.
+func DeferForQuietHours(now time.Time, location *time.Location, start, end *time.Time) time.Time {
+	if start == nil || end == nil {
+		return now
+	}
+
+	local := now.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+
+	quiet := false
+	if startMinute < endMinute {
+		quiet = minute >= startMinute && minute < endMinute
+	} else {
+		quiet = minute >= startMinute || minute < endMinute
+	}
+	if !quiet {
+		return now
+	}
+
+	resume := time.Date(local.Year(), local.Month(), local.Day(), end.Hour(), end.Minute(), 0, 0, location)
+	if !resume.After(local) {
+		resume = time.Date(local.Year(), local.Month(), local.Day()+1, end.Hour(), end.Minute(), 0, 0, location)
+	}
+	return resume
+}
+
+// ClaimDueDispatches locks a batch of dispatches that are due for this worker. SKIP LOCKED lets replicas
+// claim different rows, and dispatches stuck in sending past lockTimeout are claimed again.
+func ClaimDueDispatches(ctx context.Context, db bun.IDB, now time.Time) ([]models.Notification_Dispatch, error) {
+	claimed := []models.Notification_Dispatch{}
+	err := db.NewRaw(`
+		UPDATE notification_dispatches
+		SET status = ?, locked_at = ?, attempts = attempts + 1, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM notification_dispatches
+			WHERE (status = ? AND next_attempt_at <= ?)
+				OR (status = ? AND locked_at < ?)
+			ORDER BY next_attempt_at ASC
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`, StatusSending, now, now, StatusPending, now, StatusSending, now.Add(-lockTimeout), claimBatchSize).Scan(ctx, &claimed)
+	if err != nil {
+		return nil, err
+	}
+
+	return claimed, nil
+}
+
+// ProcessDueDispatches claims due dispatches and sends them, recording the outcome of each
+func ProcessDueDispatches(ctx context.Context, db bun.IDB, transports map[string]Transport, now time.Time) (int, error) {
+	claimed, err := ClaimDueDispatches(ctx, db, now)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, dispatch := range claimed {
+		channel := &models.Notification_Channel{}
+		err := db.NewSelect().
+			Model(channel).
+			Where("id = ?", dispatch.NotificationChannelID).
+			Scan(ctx)
+		if err != nil {
+			retryDispatch(ctx, db, dispatch, err, now)
+			continue
+		}
+		if !channel.Enabled {
+			markDispatch(ctx, db, dispatch.ID, StatusFailed, "notification channel is disabled", now)
+			continue
+		}
+
+		if err := send(ctx, transports, *channel, dispatch); err != nil {
+			retryDispatch(ctx, db, dispatch, err, now)
+			continue
+		}
+		markDispatch(ctx, db, dispatch.ID, StatusSent, "", now)
+		sent++
+	}
+
+	return sent, nil
+}
+
+func send(ctx context.Context, transports map[string]Transport, channel models.Notification_Channel, dispatch models.Notification_Dispatch) error {
+	transport, ok := transports[channel.Type]
+	if !ok {
+		return &PermanentError{Err: ErrUnknownChannelType}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	return transport.Send(ctx, channel, dispatch)
+}
+
+func markDispatch(ctx context.Context, db bun.IDB, dispatchID int, status, reason string, now time.Time) {
+	query := db.NewUpdate().
+		Model((*models.Notification_Dispatch)(nil)).
+		Set("status = ?", status).
+		Set("locked_at = NULL").
+		Set("updated_at = ?", now).
+		Where("id = ?", dispatchID)
+	if status == StatusSent {
+		query = query.Set("sent_at = ?", now)
+	}
+	if reason != "" {
+		query = query.Set("last_error = ?", reason)
+	}
+	if _, err := query.Exec(ctx); err != nil {
+		log.Printf("Error marking notification dispatch %d as %s: %v", dispatchID, status, err)
+	}
+}
+
+// retryDispatch puts a failed dispatch back in the queue with exponential backoff, or marks it failed
+// when it is out of attempts or the failure is permanent
+func retryDispatch(ctx context.Context, db bun.IDB, dispatch models.Notification_Dispatch, cause error, now time.Time) {
+	log.Printf("Error sending notification dispatch %d (attempt %d): %v", dispatch.ID, dispatch.Attempts, cause)
+	var permanent *PermanentError
+	if dispatch.Attempts >= maxAttempts || errors.As(cause, &permanent) {
+		markDispatch(ctx, db, dispatch.ID, StatusFailed, cause.Error(), now)
+		return
+	}
+
+	_, err := db.NewUpdate().
+		Model((*models.Notification_Dispatch)(nil)).
+		Set("status = ?", StatusPending).
+		Set("locked_at = NULL").
+		Set("next_attempt_at = ?", now.Add(reminders.Backoff(dispatch.Attempts))).
+		Set("last_error = ?", cause.Error()).
+		Set("updated_at = ?", now).
+		Where("id = ?", dispatch.ID).
+		Exec(ctx)
+	if err != nil {
+		log.Printf("Error rescheduling notification dispatch %d: %v", dispatch.ID, err)
+	}
+}
+
+// SendTestNotification sends a test message to a channel right away, ignoring quiet hours and
+// preferences, and logs it as a dispatch like any other notification
+func SendTestNotification(app *application.App, transports map[string]Transport, channel models.Notification_Channel) (*models.Notification_Dispatch, error) {
+	ctx := context.Background()
+	now := time.Now()
+
+	dispatch := &models.Notification_Dispatch{
+		AccountID:             channel.AccountID,
+		NotificationChannelID: channel.ID,
+		EventType:             "test",
+		DedupeKey:             fmt.Sprintf("test:%d:%d", channel.ID, now.UnixNano()),
+		Payload: models.Notification_Payload{
+			Title: "Test notification",
+			Body:  fmt.Sprintf("Notifications to %s are working.", channel.Name),
+			URL:   app.Config.Frontend.URL,
+		},
+		Status:        StatusSending,
+		Attempts:      1,
+		NextAttemptAt: now,
+		LockedAt:      &now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	_, err := app.Database.NewInsert().
+		Model(dispatch).
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dispatch.LockedAt = nil
+	if err := send(ctx, transports, channel, *dispatch); err != nil {
+		dispatch.Status = StatusFailed
+		dispatch.LastError = err.Error()
+		markDispatch(ctx, app.Database, dispatch.ID, StatusFailed, err.Error(), time.Now())
+		return dispatch, nil
+	}
+
+	sentAt := time.Now()
+	dispatch.Status = StatusSent
+	dispatch.SentAt = &sentAt
+	markDispatch(ctx, app.Database, dispatch.ID, StatusSent, "", sentAt)
+	return dispatch, nil
+}
+
+// GetDispatches lists the account's notification dispatches, newest first
+func GetDispatches(app *application.App, accountID int, filters *validator.NotificationDispatchFilters) (*DispatchPage, error) {
+	query := `
+		SELECT nd.*, nc.name AS channel_name, nc.type AS channel_type
+		FROM notification_dispatches nd
+		JOIN notification_channels nc ON nd.notification_channel_id = nc.id
+		WHERE nd.account_id = ?
+	`
+	args := []interface{}{accountID}
+
+	if filters.Status != "" {
+		query += ` AND nd.status = ?`
+		args = append(args, filters.Status)
+	}
+	if filters.ChannelID > 0 {
+		query += ` AND nd.notification_channel_id = ?`
+		args = append(args, filters.ChannelID)
+	}
+	if filters.EventType != "" {
+		query += ` AND nd.event_type = ?`
+		args = append(args, filters.EventType)
+	}
+	if filters.ParsedCursor != nil {
+		query += ` AND nd.id < ?`
+		args = append(args, filters.ParsedCursor.ID)
+	}
+	query += ` ORDER BY nd.id DESC LIMIT ?`
+	args = append(args, filters.Limit+1)
+
+	items := []DispatchListItem{}
+	if err := app.Database.NewRaw(query, args...).Scan(context.Background(), &items); err != nil {
+		log.Println("Error getting notification dispatches: because of database error", err)
+		return nil, err
+	}
+
+	page := &DispatchPage{Data: items}
+	if len(items) > filters.Limit {
+		page.Data = items[:filters.Limit]
+		nextCursor := validator.EncodeCursor(validator.Cursor{ID: page.Data[len(page.Data)-1].ID})
+		page.NextCursor = &nextCursor
+	}
+
+	return page, nil
+}
+
+// StartDispatchWorker sends queued notifications once at startup and then every interval
+func StartDispatchWorker(ctx context.Context, app *application.App, interval time.Duration, transports map[string]Transport) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if sent, err := ProcessDueDispatches(ctx, app.Database, transports, time.Now()); err != nil {
+				log.Printf("Error processing notification dispatches: %v", err)
+			} else if sent > 0 {
+				log.Printf("Sent %d notifications", sent)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// NotifyTrialsEnding tells accounts about active subscriptions whose free trial ends within
+// trialEndingNoticeDays, once per trial. It returns how many trials were notified.
+func NotifyTrialsEnding(ctx context.Context, db bun.IDB, frontendURL string, now time.Time) (int, error) {
+	trials := []struct {
+		models.Subscription_Details `bun:",extend"`
+		ChannelName                 string `bun:"channel_name"`
+	}{}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	err := db.NewRaw(`
+		SELECT sd.*, sc.channel_name
+		FROM subscription_details sd
+		JOIN subscription_channels sc ON sd.subscription_channel_id = sc.id
+		WHERE sd.status = 'active'
+			AND sd.trial_end_date BETWEEN ? AND ?
+	`, today, today.AddDate(0, 0, trialEndingNoticeDays)).Scan(ctx, &trials)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]int, len(trials))
+	for i, trial := range trials {
+		ids[i] = trial.ID
+	}
+	history, err := pricehistory.GetPriceHistories(ctx, db, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	notified := 0
+	for _, trial := range trials {
+		trialEnd := trial.TrialEndDate.Format("Mon, Jan 2")
+		firstCharge := pricehistory.ResolvePrice(trial.Subscription_Details, history[trial.ID], *trial.TrialEndDate)
+		_, err := Notify(ctx, db, trial.AccountID, Event{
+			Type:                  EventTrialEnding,
+			DedupeKey:             fmt.Sprintf("trial_ending:%d:%s", trial.ID, trial.TrialEndDate.Format("2006-01-02")),
+			SubscriptionDetailsID: trial.ID,
+			Title:                 fmt.Sprintf("%s trial ends %s", trial.ChannelName, trialEnd),
+			Body: fmt.Sprintf("Your %s trial ends on %s. Cancel before then to avoid the first charge of %s %s.",
+				trial.ChannelName, trialEnd, firstCharge.String(), trial.Currency),
+			URL: frontendURL,
+			Data: map[string]interface{}{
+				"subscription_details_id": trial.ID,
+				"trial_end_date":          trial.TrialEndDate.Format("2006-01-02"),
+				"first_charge":            firstCharge,
+				"currency":                trial.Currency,
+			},
+		}, now)
+		if err != nil {
+			log.Printf("Error notifying trial end of subscription %d: %v", trial.ID, err)
+			continue
+		}
+		notified++
+	}
+
+	return notified, nil
+}
+
+// StartTrialEndingWorker checks for trials ending soon once at startup and then every interval
+func StartTrialEndingWorker(ctx context.Context, app *application.App, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := NotifyTrialsEnding(ctx, app.Database, app.Config.Frontend.URL, time.Now()); err != nil {
+				log.Printf("Error notifying trials ending: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// ReminderDeliverer delivers due reminders by queueing a reminder notification for the account.
+// The reminder id is the dedupe key, so a reminder retried after a crash is not sent twice.
+type ReminderDeliverer struct {
+	App *application.App
+}
+
+func (d ReminderDeliverer) Deliver(ctx context.Context, reminder reminders.DueReminder) error {
+	dueDate := reminder.Reminder.DueDate.Format("Mon, Jan 2")
+
+	// Quote the price of the charge date, which may be after a scheduled price change
+	history, err := pricehistory.GetPriceHistories(ctx, d.App.Database, []int{reminder.Subscription.ID})
+	if err != nil {
+		return err
+	}
+	amount := pricehistory.ResolvePrice(reminder.Subscription, history[reminder.Subscription.ID], reminder.Reminder.DueDate)
+
+	_, err = Notify(ctx, d.App.Database, reminder.Reminder.AccountID, Event{
+		Type:                  EventReminder,
+		DedupeKey:             fmt.Sprintf("reminder:%d", reminder.Reminder.ID),
+		SubscriptionDetailsID: reminder.Subscription.ID,
+		Title:                 fmt.Sprintf("%s is due %s", reminder.ChannelName, dueDate),
+		Body:                  fmt.Sprintf("%s will charge %s %s on %s.", reminder.ChannelName, amount.String(), reminder.Subscription.Currency, dueDate),
+		URL:                   d.App.Config.Frontend.URL,
+		Data: map[string]interface{}{
+			"subscription_details_id": reminder.Subscription.ID,
+			"due_date":                reminder.Reminder.DueDate.Format("2006-01-02"),
+			"amount":                  amount,
+			"currency":                reminder.Subscription.Currency,
+		},
+	}, time.Now())
+	return err
+}
+
+// PriceChangeNotifier is the outbox consumer that tells the account a scheduled price change took effect
+// with a price_change notification. Changes the user made themselves are not notified.
+type PriceChangeNotifier struct {
+	App *application.App
+}
+
+func (n PriceChangeNotifier) NotifyPriceChange(ctx context.Context, tx bun.Tx, message models.Outbox_Message) error {
+	event := struct {
+		SubscriptionDetailsID int    `json:"subscription_details_id"`
+		AccountID             int    `json:"account_id"`
+		Type                  string `json:"type"`
+		Actor                 string `json:"actor"`
+		Payload               struct {
+			OldBill       money.Amount `json:"old_bill"`
+			NewBill       money.Amount `json:"new_bill"`
+			EffectiveDate string       `json:"effective_date"`
+			Currency      string       `json:"currency"`
+		} `json:"payload"`
+	}{}
+	if err := json.Unmarshal(message.Payload, &event); err != nil {
+		return err
+	}
+	if event.Type != subscriptionevents.EventPriceChanged || event.Actor != subscriptionevents.ActorSystem {
+		return nil
+	}
+
+	var channelName string
+	err := tx.NewRaw(`
+		SELECT sc.channel_name
+		FROM subscription_details sd
+		JOIN subscription_channels sc ON sc.id = sd.subscription_channel_id
+		WHERE sd.id = ?
+	`, event.SubscriptionDetailsID).Scan(ctx, &channelName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	change := event.Payload
+	direction := "went up"
+	if change.NewBill < change.OldBill {
+		direction = "went down"
+	}
+
+	_, err = Notify(ctx, tx, event.AccountID, Event{
+		Type:                  EventPriceChange,
+		DedupeKey:             fmt.Sprintf("price_change:%d:%s", event.SubscriptionDetailsID, change.EffectiveDate),
+		SubscriptionDetailsID: event.SubscriptionDetailsID,
+		Title:                 fmt.Sprintf("%s price %s", channelName, direction),
+		Body: fmt.Sprintf("%s now costs %s %s, was %s %s.", channelName,
+			change.NewBill.String(), change.Currency, change.OldBill.String(), change.Currency),
+		URL: n.App.Config.Frontend.URL,
+		Data: map[string]interface{}{
+			"subscription_details_id": event.SubscriptionDetailsID,
+			"effective_date":          change.EffectiveDate,
+			"old_bill":                change.OldBill,
+			"new_bill":                change.NewBill,
+			"change_percent":          pricehistory.PercentChange(change.OldBill, change.NewBill),
+			"currency":                change.Currency,
+		},
+	}, time.Now())
+	return err
+}
+
+func uniqueInts(values []int) []int {
+	seen := map[int]bool{}
+	unique := []int{}
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,57 @@
+package notifications
+
+import (
+	"testing"
+	"time"
+)
+
+func clock(value string) *time.Time {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		panic(err)
+	}
+	return &parsed
+}
+
+func TestDeferForQuietHours(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no timezone database")
+	}
+
+	tests := []struct {
+		name       string
+		now        time.Time
+		location   *time.Location
+		start, end *time.Time
+		want       time.Time
+	}{
+		{"no quiet hours", time.Date(2026, 1, 10, 23, 0, 0, 0, time.UTC), time.UTC, nil, nil,
+			time.Date(2026, 1, 10, 23, 0, 0, 0, time.UTC)},
+		{"before quiet hours", time.Date(2026, 1, 10, 21, 59, 0, 0, time.UTC), time.UTC, clock("22:00"), clock("07:00"),
+			time.Date(2026, 1, 10, 21, 59, 0, 0, time.UTC)},
+		{"start of quiet hours", time.Date(2026, 1, 10, 22, 0, 0, 0, time.UTC), time.UTC, clock("22:00"), clock("07:00"),
+			time.Date(2026, 1, 11, 7, 0, 0, 0, time.UTC)},
+		{"after midnight in wrapping quiet hours", time.Date(2026, 1, 11, 3, 30, 0, 0, time.UTC), time.UTC, clock("22:00"), clock("07:00"),
+			time.Date(2026, 1, 11, 7, 0, 0, 0, time.UTC)},
+		{"end of quiet hours", time.Date(2026, 1, 11, 7, 0, 0, 0, time.UTC), time.UTC, clock("22:00"), clock("07:00"),
+			time.Date(2026, 1, 11, 7, 0, 0, 0, time.UTC)},
+		{"inside quiet hours within a day", time.Date(2026, 1, 10, 13, 15, 0, 0, time.UTC), time.UTC, clock("12:00"), clock("14:00"),
+			time.Date(2026, 1, 10, 14, 0, 0, 0, time.UTC)},
+		{"outside quiet hours within a day", time.Date(2026, 1, 10, 23, 0, 0, 0, time.UTC), time.UTC, clock("12:00"), clock("14:00"),
+			time.Date(2026, 1, 10, 23, 0, 0, 0, time.UTC)},
+		{"quiet hours are local times", time.Date(2026, 1, 10, 21, 30, 0, 0, time.UTC), berlin, clock("22:00"), clock("07:00"),
+			time.Date(2026, 1, 11, 7, 0, 0, 0, berlin)},
+		{"quiet hours over the start of daylight saving time", time.Date(2026, 3, 28, 22, 0, 0, 0, time.UTC), berlin, clock("22:00"), clock("07:00"),
+			time.Date(2026, 3, 29, 5, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := DeferForQuietHours(test.now, test.location, test.start, test.end)
+			if !got.Equal(test.want) {
+				t.Errorf("DeferForQuietHours() = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package notifications
+
+import (
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/utils"
+)
+
+func RegisterRoutes(app *application.App) {
+	app.Echo.GET("/v1/notification-channels", GetChannelsHandler, utils.AuthMiddleware)
+	app.Echo.POST("/v1/notification-channels", CreateChannelHandler, utils.AuthMiddleware)
+	app.Echo.GET("/v1/notification-channels/vapid-public-key", GetVAPIDPublicKeyHandler, utils.AuthMiddleware)
+	app.Echo.PUT("/v1/notification-channels/:id", UpdateChannelHandler, utils.AuthMiddleware)
+	app.Echo.DELETE("/v1/notification-channels/:id", DeleteChannelHandler, utils.AuthMiddleware)
+	app.Echo.POST("/v1/notification-channels/:id/test", TestChannelHandler, utils.AuthMiddleware)
+	app.Echo.GET("/v1/notification-preferences", GetPreferencesHandler, utils.AuthMiddleware)
+	app.Echo.PUT("/v1/notification-preferences", UpdatePreferencesHandler, utils.AuthMiddleware)
+	app.Echo.GET("/v1/notification-dispatches", GetDispatchesHandler, utils.AuthMiddleware)
+}
@@ -0,0 +1,247 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+
+	"subscritracker/config"
+	"subscritracker/pkg/models"
+	"subscritracker/pkg/utils"
+)
+
+// httpClient refuses to connect to private and local addresses, as the URLs it posts to are user-supplied
+var httpClient = utils.NewOutboundHTTPClient(sendTimeout)
+
+// NewTransports returns the transport of every channel type. With fake transports configured,
+// every type shares one FakeTransport that only records and logs what it would send.
+func NewTransports(cfg config.NotificationsConfig) map[string]Transport {
+	if cfg.UseFakeTransports {
+		fake := &FakeTransport{}
+		return map[string]Transport{
+			ChannelEmail:   fake,
+			ChannelWebhook: fake,
+			ChannelWebPush: fake,
+			ChannelSlack:   fake,
+			ChannelDiscord: fake,
+		}
+	}
+
+	return map[string]Transport{
+		ChannelEmail:   &EmailTransport{Config: cfg.SMTP},
+		ChannelWebhook: WebhookTransport{},
+		ChannelWebPush: &WebPushTransport{Config: cfg.VAPID},
+		ChannelSlack:   ChatTransport{Field: "text"},
+		ChannelDiscord: ChatTransport{Field: "content"},
+	}
+}
+
+// EmailTransport sends email through an SMTP server, with an HTML part when the payload has one
+type EmailTransport struct {
+	Config config.SMTPConfig
+}
+
+func (t *EmailTransport) Send(ctx context.Context, channel models.Notification_Channel, dispatch models.Notification_Dispatch) error {
+	if t.Config.Host == "" || t.Config.From == "" {
+		return errors.New("SMTP is not configured")
+	}
+
+	message, err := buildEmail(t.Config.From, channel.Config.Email, dispatch.Payload)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if t.Config.Username != "" {
+		auth = smtp.PlainAuth("", t.Config.Username, t.Config.Password, t.Config.Host)
+	}
+
+	address := net.JoinHostPort(t.Config.Host, t.Config.Port)
+	return smtp.SendMail(address, auth, t.Config.From, []string{channel.Config.Email}, message)
+}
+
+// buildEmail renders a payload as a plain text email, or as multipart/alternative when it has an HTML version
+func buildEmail(from, to string, payload models.Notification_Payload) ([]byte, error) {
+	text := payload.Body
+	if payload.URL != "" {
+		text += "\n\n" + payload.URL
+	}
+
+	var message bytes.Buffer
+	message.WriteString("From: " + from + "\r\n")
+	message.WriteString("To: " + to + "\r\n")
+	message.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", payload.Title) + "\r\n")
+	message.WriteString("MIME-Version: 1.0\r\n")
+
+	if payload.HTML == "" {
+		message.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+		message.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&message, text); err != nil {
+			return nil, err
+		}
+		return message.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&message)
+	message.WriteString("Content-Type: multipart/alternative; boundary=" + parts.Boundary() + "\r\n\r\n")
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", text},
+		{"text/html; charset=UTF-8", payload.HTML},
+	} {
+		writer, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(writer, part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	return message.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, content string) error {
+	encoder := quotedprintable.NewWriter(w)
+	if _, err := encoder.Write([]byte(strings.ReplaceAll(content, "\n", "\r\n"))); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// WebhookPayload is the JSON body posted to generic webhook channels
+type WebhookPayload struct {
+	ID        int                    `json:"id"`
+	EventType string                 `json:"event_type"`
+	Title     string                 `json:"title"`
+	Body      string                 `json:"body"`
+	URL       string                 `json:"url,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	SentAt    time.Time              `json:"sent_at"`
+}
+
+// WebhookTransport posts the notification as JSON to the channel's URL
+type WebhookTransport struct{}
+
+func (WebhookTransport) Send(ctx context.Context, channel models.Notification_Channel, dispatch models.Notification_Dispatch) error {
+	return postJSON(ctx, channel.Config.URL, WebhookPayload{
+		ID:        dispatch.ID,
+		EventType: dispatch.EventType,
+		Title:     dispatch.Payload.Title,
+		Body:      dispatch.Payload.Body,
+		URL:       dispatch.Payload.URL,
+		Data:      dispatch.Payload.Data,
+		SentAt:    time.Now().UTC(),
+	})
+}
+
+// ChatTransport posts a message to a Slack or Discord incoming webhook.
+// Slack reads the message from "text" and Discord from "content".
+type ChatTransport struct {
+	Field string
+}
+
+func (t ChatTransport) Send(ctx context.Context, channel models.Notification_Channel, dispatch models.Notification_Dispatch) error {
+	text := "*" + dispatch.Payload.Title + "*\n" + dispatch.Payload.Body
+	if t.Field == "content" {
+		text = "**" + dispatch.Payload.Title + "**\n" + dispatch.Payload.Body
+	}
+	if dispatch.Payload.URL != "" {
+		text += "\n" + dispatch.Payload.URL
+	}
+
+	return postJSON(ctx, channel.Config.URL, map[string]string{t.Field: text})
+}
+
+func postJSON(ctx context.Context, url string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return &PermanentError{Err: err}
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "subscritracker-notifications")
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return checkResponse(response)
+}
+
+// checkResponse turns a non 2xx response into an error. Client errors other than
+// timeouts and rate limits mean the destination rejects us and are permanent.
+func checkResponse(response *http.Response) error {
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return nil
+	}
+
+	detail, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+	err := fmt.Errorf("destination responded %d: %s", response.StatusCode, strings.TrimSpace(string(detail)))
+	if response.StatusCode >= 400 && response.StatusCode < 500 &&
+		response.StatusCode != http.StatusRequestTimeout && response.StatusCode != http.StatusTooManyRequests {
+		return &PermanentError{Err: err}
+	}
+	return err
+}
+
+// FakeSend is a notification recorded by FakeTransport
+type FakeSend struct {
+	Channel  models.Notification_Channel
+	Dispatch models.Notification_Dispatch
+}
+
+// FakeTransport records notifications instead of sending them, for development and tests.
+// Setting Err makes every send fail with it.
+type FakeTransport struct {
+	Err error
+
+	mu   sync.Mutex
+	sent []FakeSend
+}
+
+func (t *FakeTransport) Send(ctx context.Context, channel models.Notification_Channel, dispatch models.Notification_Dispatch) error {
+	if t.Err != nil {
+		return t.Err
+	}
+
+	t.mu.Lock()
+	t.sent = append(t.sent, FakeSend{Channel: channel, Dispatch: dispatch})
+	t.mu.Unlock()
+
+	log.Printf("Notification to %s channel %d (%s): %s - %s",
+		channel.Type, channel.ID, channel.Name, dispatch.Payload.Title, dispatch.Payload.Body)
+	return nil
+}
+
+// Sent returns the notifications recorded so far
+func (t *FakeTransport) Sent() []FakeSend {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]FakeSend(nil), t.sent...)
+}
@@ -0,0 +1,111 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"subscritracker/pkg/models"
+)
+
+// Event types a user can route to channels
+const (
+	EventReminder      = "reminder"
+	EventPriceChange   = "price_change"
+	EventTrialEnding   = "trial_ending"
+	EventRenewalFailed = "renewal_failed"
+	EventDigest        = "digest"
+	EventBudgetAlert   = "budget_alert"
+)
+
+// EventTypes lists every event type, in the order preferences are shown
+var EventTypes = []string{EventReminder, EventPriceChange, EventTrialEnding, EventRenewalFailed, EventDigest, EventBudgetAlert}
+
+// Channel types
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelWebPush = "web_push"
+	ChannelSlack   = "slack"
+	ChannelDiscord = "discord"
+)
+
+// Dispatch statuses
+const (
+	StatusPending = "pending"
+	StatusSending = "sending"
+	StatusSent    = "sent"
+	StatusFailed  = "failed"
+)
+
+const (
+	// lockTimeout releases dispatches claimed by a worker that died before finishing
+	lockTimeout = 10 * time.Minute
+	// claimBatchSize is how many dispatches one worker tick claims
+	claimBatchSize = 50
+	// maxAttempts before a dispatch is marked failed
+	maxAttempts = 5
+	// sendTimeout bounds a single transport call
+	sendTimeout = 15 * time.Second
+	// trialEndingNoticeDays is how long before a trial ends the user is told, matching the "ending soon" flag
+	trialEndingNoticeDays = 7
+)
+
+var (
+	ErrChannelNotFound    = errors.New("notification channel not found")
+	ErrUnknownChannelType = errors.New("no transport for this notification channel type")
+)
+
+// Event is something that happened to an account that it may want to be told about.
+// DedupeKey identifies the occurrence, so notifying twice with the same key sends it once per channel.
+type Event struct {
+	Type                  string
+	DedupeKey             string
+	SubscriptionDetailsID int
+	Title                 string
+	Body                  string
+	HTML                  string
+	URL                   string
+	Data                  map[string]interface{}
+}
+
+// Transport delivers a dispatch to one kind of channel. Returning a PermanentError stops retries.
+type Transport interface {
+	Send(ctx context.Context, channel models.Notification_Channel, dispatch models.Notification_Dispatch) error
+}
+
+// PermanentError is a delivery failure that retrying cannot fix, such as an expired push subscription
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// Preferences is the notification routing of an account, as shown to the user
+type Preferences struct {
+	Events          []EventPreference `json:"events"`
+	QuietHoursStart *string           `json:"quiet_hours_start"`
+	QuietHoursEnd   *string           `json:"quiet_hours_end"`
+	Timezone        string            `json:"timezone"`
+}
+
+// EventPreference lists the channels an event type goes to. AllChannels is set when the user
+// has not chosen, in which case every enabled channel receives it.
+type EventPreference struct {
+	EventType   string `json:"event_type"`
+	AllChannels bool   `json:"all_channels"`
+	ChannelIDs  []int  `json:"channel_ids"`
+}
+
+// DispatchListItem is a dispatch with the name and type of its channel, as listed to the user
+type DispatchListItem struct {
+	models.Notification_Dispatch `bun:",extend"`
+	ChannelName                  string `bun:"channel_name" json:"channel_name"`
+	ChannelType                  string `bun:"channel_type" json:"channel_type"`
+}
+
+type DispatchPage struct {
+	Data       []DispatchListItem `json:"data"`
+	NextCursor *string            `json:"next_cursor"`
+}
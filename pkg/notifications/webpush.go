@@ -0,0 +1,234 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"subscritracker/config"
+	"subscritracker/pkg/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// webPushTTL is how long the push service keeps a message for an offline browser
+	webPushTTL = 24 * time.Hour
+	// webPushRecordSize is the aes128gcm record size; notifications always fit in one record
+	webPushRecordSize = 4096
+)
+
+// WebPushTransport sends notifications to browser push subscriptions, encrypting the payload
+// with aes128gcm (RFC 8291) and identifying this server with a VAPID token (RFC 8292)
+type WebPushTransport struct {
+	Config config.VAPIDConfig
+}
+
+func (t *WebPushTransport) Send(ctx context.Context, channel models.Notification_Channel, dispatch models.Notification_Dispatch) error {
+	signingKey, err := ParseVAPIDPrivateKey(t.Config.PrivateKey)
+	if err != nil {
+		return err
+	}
+
+	message, err := json.Marshal(map[string]interface{}{
+		"id":         dispatch.ID,
+		"event_type": dispatch.EventType,
+		"title":      dispatch.Payload.Title,
+		"body":       dispatch.Payload.Body,
+		"url":        dispatch.Payload.URL,
+	})
+	if err != nil {
+		return err
+	}
+
+	body, err := EncryptWebPush(message, channel.Config.P256dh, channel.Config.Auth)
+	if err != nil {
+		return &PermanentError{Err: err}
+	}
+
+	authorization, err := vapidAuthorization(channel.Config.Endpoint, signingKey, t.Config)
+	if err != nil {
+		return &PermanentError{Err: err}
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, channel.Config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return &PermanentError{Err: err}
+	}
+	request.Header.Set("Content-Type", "application/octet-stream")
+	request.Header.Set("Content-Encoding", "aes128gcm")
+	request.Header.Set("TTL", strconv.Itoa(int(webPushTTL.Seconds())))
+	request.Header.Set("Urgency", "normal")
+	request.Header.Set("Authorization", authorization)
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	// 404 and 410 mean the browser unsubscribed; checkResponse treats them as permanent
+	return checkResponse(response)
+}
+
+// EncryptWebPush encrypts a push message for a subscription's p256dh key and auth secret
+// as a single aes128gcm record (RFC 8291 section 3.4, RFC 8188 section 2)
+func EncryptWebPush(message []byte, p256dh, authSecret string) ([]byte, error) {
+	userAgentKeyBytes, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(p256dh, "="))
+	if err != nil {
+		return nil, errors.New("invalid p256dh key")
+	}
+	userAgentKey, err := ecdh.P256().NewPublicKey(userAgentKeyBytes)
+	if err != nil {
+		return nil, errors.New("invalid p256dh key")
+	}
+	auth, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(authSecret, "="))
+	if err != nil || len(auth) != 16 {
+		return nil, errors.New("invalid auth secret")
+	}
+	if len(message)+17+16 > webPushRecordSize {
+		return nil, errors.New("push message is too large")
+	}
+
+	serverKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	sharedSecret, err := serverKey.ECDH(userAgentKey)
+	if err != nil {
+		return nil, err
+	}
+	serverPublicKey := serverKey.PublicKey().Bytes()
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	// Combine the ECDH secret with the auth secret, bound to both public keys
+	keyInfo := append([]byte("WebPush: info\x00"), userAgentKeyBytes...)
+	keyInfo = append(keyInfo, serverPublicKey...)
+	inputKey, err := expand(hkdf.Extract(sha256.New, sharedSecret, auth), keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	// Derive the content encryption key and nonce from the salted input key
+	pseudoRandomKey := hkdf.Extract(sha256.New, inputKey, salt)
+	contentKey, err := expand(pseudoRandomKey, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := expand(pseudoRandomKey, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// A single record: the message followed by the last record delimiter, without padding
+	plaintext := append(append([]byte{}, message...), 0x02)
+
+	header := make([]byte, 0, 16+4+1+len(serverPublicKey))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, webPushRecordSize)
+	header = append(header, byte(len(serverPublicKey)))
+	header = append(header, serverPublicKey...)
+
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+func expand(pseudoRandomKey, info []byte, length int) ([]byte, error) {
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, pseudoRandomKey, info), out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// vapidAuthorization signs a VAPID token for the push service that owns the endpoint
+func vapidAuthorization(endpoint string, signingKey *ecdsa.PrivateKey, cfg config.VAPIDConfig) (string, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": parsed.Scheme + "://" + parsed.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": cfg.Subject,
+	})
+	signed, err := token.SignedString(signingKey)
+	if err != nil {
+		return "", err
+	}
+
+	ecdhKey, err := signingKey.ECDH()
+	if err != nil {
+		return "", err
+	}
+	return "vapid t=" + signed + ", k=" + base64.RawURLEncoding.EncodeToString(ecdhKey.PublicKey().Bytes()), nil
+}
+
+// ParseVAPIDPrivateKey reads a base64url encoded raw P-256 private key
+func ParseVAPIDPrivateKey(value string) (*ecdsa.PrivateKey, error) {
+	if value == "" {
+		return nil, errors.New("VAPID keys are not configured")
+	}
+
+	scalar, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, errors.New("invalid VAPID private key")
+	}
+	key, err := ecdh.P256().NewPrivateKey(scalar)
+	if err != nil {
+		return nil, errors.New("invalid VAPID private key")
+	}
+
+	// Uncompressed point: 0x04 || X || Y
+	point := key.PublicKey().Bytes()
+	return &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(point[1:33]),
+			Y:     new(big.Int).SetBytes(point[33:]),
+		},
+		D: new(big.Int).SetBytes(scalar),
+	}, nil
+}
+
+// GenerateVAPIDKeys creates a new VAPID key pair, base64url encoded as browsers and VAPID_* settings expect
+func GenerateVAPIDKeys() (publicKey, privateKey string, err error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+		base64.RawURLEncoding.EncodeToString(key.Bytes()), nil
+}
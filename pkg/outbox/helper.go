@@ -0,0 +1,242 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/models"
+
+	"github.com/uptrace/bun"
+)
+
+// Write adds a message to the outbox. Call it with the transaction of the change the message describes:
+// the message is relayed only if that transaction commits, and is relayed even if the process stops
+// right after. accountID is 0 for messages that belong to no account.
+func Write(ctx context.Context, db bun.IDB, accountID int, topic, key string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	message := &models.Outbox_Message{
+		Topic:         topic,
+		Key:           key,
+		Payload:       data,
+		Status:        StatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+	if accountID != 0 {
+		message.AccountID = &accountID
+	}
+
+	_, err = db.NewInsert().Model(message).Exec(ctx)
+	return err
+}
+
+// NewRelay returns a relay without consumers. publisher may be nil to relay to in-process consumers only.
+func NewRelay(publisher Publisher) *Relay {
+	return &Relay{consumers: map[string][]consumer{}, publisher: publisher}
+}
+
+// Subscribe adds a consumer of a topic. name identifies the consumer in outbox_consumptions,
+// so it must stay the same across releases or the consumer will see old messages again.
+func (r *Relay) Subscribe(topic, name string, handler Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.consumers[topic] = append(r.consumers[topic], consumer{name: name, handler: handler})
+}
+
+// ClaimDueMessages locks a batch of messages that are due for this relay. SKIP LOCKED lets replicas
+// claim different rows, and messages stuck in relaying past lockTimeout are claimed again.
+func ClaimDueMessages(ctx context.Context, db bun.IDB, now time.Time) ([]models.Outbox_Message, error) {
+	claimed := []models.Outbox_Message{}
+	err := db.NewRaw(`
+		UPDATE outbox_messages
+		SET status = ?, locked_at = ?, attempts = attempts + 1
+		WHERE id IN (
+			SELECT id FROM outbox_messages
+			WHERE (status = ? AND next_attempt_at <= ?)
+				OR (status = ? AND locked_at < ?)
+			ORDER BY id ASC
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`, StatusRelaying, now, StatusPending, now, StatusRelaying, now.Add(-lockTimeout), claimBatchSize).Scan(ctx, &claimed)
+	if err != nil {
+		return nil, err
+	}
+
+	return claimed, nil
+}
+
+// ProcessDueMessages claims due messages and relays them, returning how many were published
+func (r *Relay) ProcessDueMessages(ctx context.Context, db bun.IDB, now time.Time) (int, error) {
+	claimed, err := ClaimDueMessages(ctx, db, now)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, message := range claimed {
+		if err := r.relay(ctx, db, message); err != nil {
+			retryMessage(ctx, db, message, err, now)
+			continue
+		}
+		markPublished(ctx, db, message.ID, now)
+		published++
+	}
+
+	return published, nil
+}
+
+// relay hands a message to every consumer of its topic, then to the publisher. A consumer that
+// fails does not stop the others; the message is retried and only the failed ones run again.
+func (r *Relay) relay(ctx context.Context, db bun.IDB, message models.Outbox_Message) error {
+	r.mu.RLock()
+	consumers := r.consumers[message.Topic]
+	r.mu.RUnlock()
+
+	var errs []error
+	for _, consumer := range consumers {
+		if err := consume(ctx, db, consumer, message); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", consumer.name, err))
+		}
+	}
+
+	if r.publisher != nil {
+		publishCtx, cancel := context.WithTimeout(ctx, publishTimeout)
+		err := r.publisher.Publish(publishCtx, message)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("publisher: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// consume runs a consumer on a message unless it already handled it, recording that it did
+// in the same transaction as the consumer's own changes
+func consume(ctx context.Context, db bun.IDB, consumer consumer, message models.Outbox_Message) error {
+	return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		result, err := tx.NewRaw(`
+			INSERT INTO outbox_consumptions (consumer, outbox_message_id, consumed_at)
+			VALUES (?, ?, ?)
+			ON CONFLICT DO NOTHING
+		`, consumer.name, message.ID, time.Now()).Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		recorded, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if recorded == 0 {
+			return nil
+		}
+
+		return consumer.handler(ctx, tx, message)
+	})
+}
+
+func markPublished(ctx context.Context, db bun.IDB, messageID int64, now time.Time) {
+	_, err := db.NewUpdate().
+		Model((*models.Outbox_Message)(nil)).
+		Set("status = ?", StatusPublished).
+		Set("locked_at = NULL").
+		Set("published_at = ?", now).
+		Set("last_error = NULL").
+		Where("id = ?", messageID).
+		Exec(ctx)
+	if err != nil {
+		log.Printf("Error marking outbox message %d as published: %v", messageID, err)
+	}
+}
+
+// retryMessage puts a message back in the outbox with exponential backoff, or dead-letters it
+// once it is out of attempts
+func retryMessage(ctx context.Context, db bun.IDB, message models.Outbox_Message, cause error, now time.Time) {
+	log.Printf("Error relaying outbox message %d (attempt %d): %v", message.ID, message.Attempts, cause)
+
+	status := StatusPending
+	if message.Attempts >= maxAttempts {
+		status = StatusDead
+	}
+
+	_, err := db.NewUpdate().
+		Model((*models.Outbox_Message)(nil)).
+		Set("status = ?", status).
+		Set("locked_at = NULL").
+		Set("next_attempt_at = ?", now.Add(backoff(message.Attempts))).
+		Set("last_error = ?", cause.Error()).
+		Where("id = ?", message.ID).
+		Exec(ctx)
+	if err != nil {
+		log.Printf("Error rescheduling outbox message %d: %v", message.ID, err)
+	}
+}
+
+// backoff returns the delay before the next attempt after the given number of attempts
+func backoff(attempts int) time.Duration {
+	delay := firstRetryDelay << max(attempts-1, 0)
+	if delay <= 0 || delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
+
+// PruneMessages deletes messages published before the cutoff, along with their consumptions
+func PruneMessages(ctx context.Context, db bun.IDB, before time.Time) (int64, error) {
+	result, err := db.NewDelete().
+		Model((*models.Outbox_Message)(nil)).
+		Where("status = ? AND published_at < ?", StatusPublished, before).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// StartRelayWorker relays due outbox messages once at startup and then every interval,
+// and prunes published messages past the retention period every hour
+func StartRelayWorker(ctx context.Context, app *application.App, interval time.Duration, relay *Relay) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var lastPruned time.Time
+		for {
+			if published, err := relay.ProcessDueMessages(ctx, app.Database, time.Now()); err != nil {
+				log.Printf("Error relaying outbox messages: %v", err)
+			} else if published > 0 {
+				log.Printf("Relayed %d outbox messages", published)
+			}
+
+			if time.Since(lastPruned) >= time.Hour {
+				lastPruned = time.Now()
+				if pruned, err := PruneMessages(ctx, app.Database, time.Now().Add(-retention)); err != nil {
+					log.Printf("Error pruning outbox messages: %v", err)
+				} else if pruned > 0 {
+					log.Printf("Pruned %d outbox messages", pruned)
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
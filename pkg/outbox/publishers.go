@@ -0,0 +1,236 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"subscritracker/config"
+	"subscritracker/pkg/models"
+)
+
+// NewPublisher returns the publisher of the configured broker, or nil when none is configured
+func NewPublisher(cfg config.OutboxConfig) (Publisher, error) {
+	if cfg.PublisherURL == "" {
+		return nil, nil
+	}
+
+	parsed, err := url.Parse(cfg.PublisherURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid outbox publisher URL: %w", err)
+	}
+
+	switch parsed.Scheme {
+	case "redis":
+		publisher := &RedisPublisher{Address: hostWithPort(parsed, "6379"), Prefix: cfg.TopicPrefix}
+		if parsed.User != nil {
+			publisher.Password, _ = parsed.User.Password()
+		}
+		return publisher, nil
+	case "nats":
+		publisher := &NATSPublisher{Address: hostWithPort(parsed, "4222"), Prefix: cfg.TopicPrefix}
+		if parsed.User != nil {
+			publisher.User = parsed.User.Username()
+			publisher.Password, _ = parsed.User.Password()
+		}
+		return publisher, nil
+	default:
+		return nil, fmt.Errorf("unsupported outbox publisher %q. Must be redis or nats", parsed.Scheme)
+	}
+}
+
+func hostWithPort(parsed *url.URL, defaultPort string) string {
+	if parsed.Port() == "" {
+		return net.JoinHostPort(parsed.Hostname(), defaultPort)
+	}
+	return parsed.Host
+}
+
+// brokerConn is a connection to a broker that is opened on first use and dropped after any error,
+// to be opened again by the next publish
+type brokerConn struct {
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func (b *brokerConn) open(ctx context.Context, address string, handshake func() error) error {
+	if b.conn != nil {
+		return nil
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	b.conn = conn
+	b.reader = bufio.NewReader(conn)
+
+	if err := b.setDeadline(ctx); err != nil {
+		b.close()
+		return err
+	}
+	if err := handshake(); err != nil {
+		b.close()
+		return err
+	}
+	return nil
+}
+
+func (b *brokerConn) setDeadline(ctx context.Context) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(publishTimeout)
+	}
+	return b.conn.SetDeadline(deadline)
+}
+
+func (b *brokerConn) readLine() (string, error) {
+	line, err := b.reader.ReadString('\n')
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+func (b *brokerConn) close() {
+	if b.conn != nil {
+		b.conn.Close()
+	}
+	b.conn = nil
+	b.reader = nil
+}
+
+// RedisPublisher publishes every message to the Redis channel named after its topic
+type RedisPublisher struct {
+	Address  string
+	Password string
+	Prefix   string
+	brokerConn
+}
+
+func (p *RedisPublisher) Publish(ctx context.Context, message models.Outbox_Message) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	err = p.open(ctx, p.Address, func() error {
+		if p.Password == "" {
+			return nil
+		}
+		return p.command("AUTH", p.Password)
+	})
+	if err != nil {
+		return err
+	}
+	if err := p.setDeadline(ctx); err != nil {
+		p.close()
+		return err
+	}
+
+	if err := p.command("PUBLISH", p.Prefix+message.Topic, string(body)); err != nil {
+		p.close()
+		return err
+	}
+	return nil
+}
+
+// command sends a command in the RESP protocol and reads its reply, returning Redis errors
+func (p *RedisPublisher) command(args ...string) error {
+	var request strings.Builder
+	fmt.Fprintf(&request, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&request, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := p.conn.Write([]byte(request.String())); err != nil {
+		return err
+	}
+
+	reply, err := p.readLine()
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(reply, "-") {
+		return errors.New("redis: " + strings.TrimPrefix(reply, "-"))
+	}
+	return nil
+}
+
+// NATSPublisher publishes every message to the NATS subject named after its topic
+type NATSPublisher struct {
+	Address  string
+	User     string
+	Password string
+	Prefix   string
+	brokerConn
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, message models.Outbox_Message) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	err = p.open(ctx, p.Address, func() error {
+		// The server greets with INFO before it accepts CONNECT
+		if _, err := p.readLine(); err != nil {
+			return err
+		}
+		options, err := json.Marshal(map[string]interface{}{
+			"verbose":  false,
+			"pedantic": false,
+			"name":     "subscritracker-outbox",
+			"user":     p.User,
+			"pass":     p.Password,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(p.conn, "CONNECT %s\r\n", options)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := p.setDeadline(ctx); err != nil {
+		p.close()
+		return err
+	}
+
+	// PING after PUB so the publish is only reported done once the server has processed it
+	if _, err := fmt.Fprintf(p.conn, "PUB %s %d\r\n%s\r\nPING\r\n", p.Prefix+message.Topic, len(body), body); err != nil {
+		p.close()
+		return err
+	}
+	for {
+		reply, err := p.readLine()
+		if err != nil {
+			p.close()
+			return err
+		}
+		switch {
+		case reply == "PONG":
+			return nil
+		case reply == "PING":
+			if _, err := p.conn.Write([]byte("PONG\r\n")); err != nil {
+				p.close()
+				return err
+			}
+		case strings.HasPrefix(reply, "-ERR"):
+			p.close()
+			return errors.New("nats: " + strings.TrimSpace(strings.TrimPrefix(reply, "-ERR")))
+		}
+	}
+}
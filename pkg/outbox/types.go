@@ -0,0 +1,64 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"subscritracker/pkg/models"
+
+	"github.com/uptrace/bun"
+)
+
+// Topics. The payload of each is documented where it is written.
+const (
+	// TopicSubscriptionEventRecorded carries a models.Subscription_Event that was recorded
+	TopicSubscriptionEventRecorded = "subscription_event.recorded"
+)
+
+// Message statuses
+const (
+	StatusPending   = "pending"
+	StatusRelaying  = "relaying"
+	StatusPublished = "published"
+	StatusDead      = "dead"
+)
+
+const (
+	// maxAttempts before a message is dead-lettered; it stays in the table for inspection
+	maxAttempts = 10
+	// firstRetryDelay doubles after every failed attempt, up to maxRetryDelay
+	firstRetryDelay = 10 * time.Second
+	maxRetryDelay   = time.Hour
+	// lockTimeout releases messages claimed by a relay that died before finishing
+	lockTimeout = 5 * time.Minute
+	// claimBatchSize is how many messages one relay tick claims
+	claimBatchSize = 100
+	// publishTimeout bounds a single call to the external publisher
+	publishTimeout = 10 * time.Second
+	// retention is how long published messages are kept before they are pruned
+	retention = 7 * 24 * time.Hour
+)
+
+// Handler consumes a message. It runs in the transaction that records the message as consumed by it,
+// so changes it makes through tx take effect exactly once. Side effects outside the database may be
+// repeated if the transaction fails to commit and must be idempotent, keyed on the message id.
+type Handler func(ctx context.Context, tx bun.Tx, message models.Outbox_Message) error
+
+// Publisher sends messages to a broker outside this process. Delivery is at least once: a message
+// is published again when the relay fails before recording it, so subscribers dedupe on its id.
+type Publisher interface {
+	Publish(ctx context.Context, message models.Outbox_Message) error
+}
+
+type consumer struct {
+	name    string
+	handler Handler
+}
+
+// Relay hands outbox messages to the consumers subscribed to their topic and to the publisher
+type Relay struct {
+	mu        sync.RWMutex
+	consumers map[string][]consumer
+	publisher Publisher
+}
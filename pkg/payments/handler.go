@@ -0,0 +1,114 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/validator"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CreatePaymentHandler records a payment the user made for one of their subscriptions
+func CreatePaymentHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	request, err := validator.ValidatePaymentRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	subscriptions, err := LoadSubscriptions(context.Background(), app.Database, accountID, request.SubscriptionDetailsID, false)
+	if err != nil {
+		log.Println("Error getting subscription details:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get subscription details"})
+	}
+	if len(subscriptions) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "subscription_details_id cannot be found"})
+	}
+
+	payment, err := RecordPayment(app, subscriptions[0], request)
+	if err != nil {
+		if errors.Is(err, ErrExpectedChargeNotFound) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "expected_charge_id cannot be found for this subscription"})
+		}
+		log.Println("Error recording payment:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to record payment"})
+	}
+
+	return c.JSON(http.StatusCreated, payment)
+}
+
+// GetPaymentsHandler lists the user's payments, newest first
+func GetPaymentsHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	filters, err := validator.ValidatePaymentFilters(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	page, err := GetPayments(app, accountID, filters)
+	if err != nil {
+		log.Println("Error getting payments:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get payments"})
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+// VoidPaymentHandler voids a payment recorded by mistake
+func VoidPaymentHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	paymentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid payment ID"})
+	}
+
+	reason, err := validator.ValidateVoidPaymentRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	payment, err := VoidPayment(app, accountID, paymentID, reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrPaymentNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		case errors.Is(err, ErrPaymentAlreadyVoided):
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error voiding payment:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to void payment"})
+	}
+
+	return c.JSON(http.StatusOK, payment)
+}
+
+// GetReconciliationHandler compares the charges due in a period with the payments recorded for them
+func GetReconciliationHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	request, err := validator.ValidateReconciliationRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	reconciliation, err := Reconcile(app, accountID, request, time.Now())
+	if err != nil {
+		log.Println("Error reconciling payments:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to reconcile payments"})
+	}
+
+	return c.JSON(http.StatusOK, reconciliation)
+}
@@ -0,0 +1,572 @@
+package payments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/models"
+	"subscritracker/pkg/notifications"
+	pricehistory "subscritracker/pkg/price-history"
+	"subscritracker/pkg/recurrence"
+	"subscritracker/pkg/stream"
+	"subscritracker/pkg/utils"
+	"subscritracker/pkg/validator"
+
+	"github.com/uptrace/bun"
+)
+
+// LoadSubscriptions returns subscriptions with their currency filled in from the account default when unset.
+// accountID and subscriptionDetailsID narrow the list when not 0.
+func LoadSubscriptions(ctx context.Context, db bun.IDB, accountID, subscriptionDetailsID int, activeOnly bool) ([]models.Subscription_Details, error) {
+	rows := []struct {
+		models.Subscription_Details `bun:",extend"`
+		DefaultCurrency             string `bun:"default_currency"`
+	}{}
+	query := db.NewSelect().
+		TableExpr("subscription_details AS sd").
+		ColumnExpr("sd.*").
+		ColumnExpr("a.default_currency").
+		Join("JOIN account AS a ON a.id = sd.account_id")
+	if accountID != 0 {
+		query = query.Where("sd.account_id = ?", accountID)
+	}
+	if subscriptionDetailsID != 0 {
+		query = query.Where("sd.id = ?", subscriptionDetailsID)
+	}
+	if activeOnly {
+		query = query.Where("sd.status = 'active'")
+	}
+	if err := query.Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	subscriptions := make([]models.Subscription_Details, 0, len(rows))
+	for _, row := range rows {
+		if row.Currency == "" {
+			row.Currency = row.DefaultCurrency
+		}
+		subscriptions = append(subscriptions, row.Subscription_Details)
+	}
+	return subscriptions, nil
+}
+
+// GenerateExpectedCharges creates an expected charge for every billing date of the subscriptions
+// between from and to, at the price in effect on that date. Charges that already exist are left
+// as they are, so a later price change does not rewrite what was expected. Free dates are skipped.
+func GenerateExpectedCharges(ctx context.Context, db bun.IDB, subscriptions []models.Subscription_Details, from, to time.Time) (int64, error) {
+	if len(subscriptions) == 0 {
+		return 0, nil
+	}
+
+	subscriptionIDs := make([]int, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		subscriptionIDs = append(subscriptionIDs, subscription.ID)
+	}
+	history := []models.Subscription_Price_History{}
+	err := db.NewSelect().
+		Model(&history).
+		Where("subscription_details_id IN (?)", bun.In(subscriptionIDs)).
+		Order("subscription_details_id ASC", "effective_date ASC").
+		Scan(ctx)
+	if err != nil {
+		return 0, err
+	}
+	historyBySubscription := map[int][]models.Subscription_Price_History{}
+	for _, entry := range history {
+		historyBySubscription[entry.SubscriptionDetailsID] = append(historyBySubscription[entry.SubscriptionDetailsID], entry)
+	}
+
+	now := time.Now()
+	charges := []models.Expected_Charge{}
+	for _, subscription := range subscriptions {
+		for _, dueDate := range recurrence.Occurrences(subscription, from, to) {
+			amount := pricehistory.ResolvePrice(subscription, historyBySubscription[subscription.ID], dueDate)
+			if amount.IsZero() {
+				continue
+			}
+			charges = append(charges, models.Expected_Charge{
+				AccountID:             subscription.AccountID,
+				SubscriptionDetailsID: subscription.ID,
+				DueDate:               dueDate,
+				Amount:                amount,
+				Currency:              subscription.Currency,
+				CreatedAt:             now,
+				UpdatedAt:             now,
+			})
+		}
+	}
+	if len(charges) == 0 {
+		return 0, nil
+	}
+
+	result, err := db.NewInsert().
+		Model(&charges).
+		On("CONFLICT (subscription_details_id, due_date) DO NOTHING").
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// matchExpectedCharge links the payment to the expected charge of its subscription it most likely
+// settles: one without a payment yet if possible, then the closest due date within matchWindowDays.
+// The payment stays unlinked when no charge is close enough.
+func matchExpectedCharge(ctx context.Context, db bun.IDB, payment *models.Payment) error {
+	paidOn := payment.PaidAt.UTC().Format("2006-01-02")
+
+	var chargeID int
+	err := db.NewRaw(`
+		SELECT ec.id
+		FROM expected_charges ec
+		WHERE ec.subscription_details_id = ?
+			AND ec.due_date BETWEEN ?::date - ?::int AND ?::date + ?::int
+		ORDER BY
+			EXISTS (
+				SELECT 1 FROM payments p
+				WHERE p.expected_charge_id = ec.id AND p.voided_at IS NULL AND p.id <> ?
+			),
+			ABS(ec.due_date - ?::date),
+			ec.due_date
+		LIMIT 1
+	`, payment.SubscriptionDetailsID, paidOn, matchWindowDays, paidOn, matchWindowDays, payment.ID, paidOn).Scan(ctx, &chargeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	payment.ExpectedChargeID = &chargeID
+	return nil
+}
+
+// LinkUnmatchedPayments links payments recorded before their expected charge existed.
+// An accountID of 0 covers every account. It returns how many payments were linked.
+func LinkUnmatchedPayments(ctx context.Context, db bun.IDB, accountID int) (int, error) {
+	unmatched := []models.Payment{}
+	query := db.NewSelect().
+		Model(&unmatched).
+		Where("expected_charge_id IS NULL AND voided_at IS NULL")
+	if accountID != 0 {
+		query = query.Where("account_id = ?", accountID)
+	}
+	if err := query.Order("paid_at ASC").Scan(ctx); err != nil {
+		return 0, err
+	}
+
+	linked := 0
+	for i := range unmatched {
+		payment := &unmatched[i]
+		if err := matchExpectedCharge(ctx, db, payment); err != nil {
+			return linked, err
+		}
+		if payment.ExpectedChargeID == nil {
+			continue
+		}
+
+		_, err := db.NewUpdate().
+			Model(payment).
+			Set("expected_charge_id = ?", *payment.ExpectedChargeID).
+			Set("updated_at = ?", time.Now()).
+			WherePK().
+			Where("expected_charge_id IS NULL").
+			Exec(ctx)
+		if err != nil {
+			return linked, err
+		}
+		linked++
+	}
+
+	return linked, nil
+}
+
+// RecordPayment stores a payment of a subscription. Without an expected_charge_id it is linked to
+// the matching expected charge, which is created first if the ledger worker has not yet.
+func RecordPayment(app *application.App, subscription models.Subscription_Details, request *validator.ParsedPayment) (*models.Payment, error) {
+	payment := &models.Payment{
+		AccountID:             subscription.AccountID,
+		SubscriptionDetailsID: subscription.ID,
+		ExpectedChargeID:      request.ExpectedChargeID,
+		Amount:                request.Amount,
+		Currency:              request.Currency,
+		PaidAt:                request.PaidAt,
+		Method:                request.Method,
+		ReceiptReference:      request.ReceiptReference,
+		Notes:                 request.Notes,
+		CreatedAt:             time.Now(),
+		UpdatedAt:             time.Now(),
+	}
+	if payment.Currency == "" {
+		payment.Currency = subscription.Currency
+	}
+
+	err := app.Database.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		if payment.ExpectedChargeID != nil {
+			exists, err := tx.NewSelect().
+				Model((*models.Expected_Charge)(nil)).
+				Where("id = ? AND subscription_details_id = ?", *payment.ExpectedChargeID, subscription.ID).
+				Exists(ctx)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return ErrExpectedChargeNotFound
+			}
+		} else {
+			paidOn := utils.TruncateToDay(payment.PaidAt)
+			upTo := paidOn.AddDate(0, 0, matchWindowDays)
+			if today := utils.TruncateToDay(time.Now()); upTo.After(today) {
+				upTo = today
+			}
+			if _, err := GenerateExpectedCharges(ctx, tx, []models.Subscription_Details{subscription}, paidOn.AddDate(0, 0, -matchWindowDays), upTo); err != nil {
+				return err
+			}
+			if err := matchExpectedCharge(ctx, tx, payment); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.NewInsert().Model(payment).Exec(ctx); err != nil {
+			return err
+		}
+
+		return stream.Publish(ctx, tx, payment.AccountID, stream.EventPaymentRecorded, map[string]interface{}{
+			"payment_id":              payment.ID,
+			"subscription_details_id": payment.SubscriptionDetailsID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// GetPayments lists the account's payments, newest first. Voided payments are left out unless asked for.
+func GetPayments(app *application.App, accountID int, filters *validator.PaymentFilters) (*PaymentPage, error) {
+	payments := []models.Payment{}
+	query := app.Database.NewSelect().
+		Model(&payments).
+		Where("account_id = ?", accountID)
+
+	if filters.SubscriptionDetailsID != 0 {
+		query = query.Where("subscription_details_id = ?", filters.SubscriptionDetailsID)
+	}
+	if !filters.IncludeVoided {
+		query = query.Where("voided_at IS NULL")
+	}
+	if filters.ParsedFrom != nil {
+		query = query.Where("paid_at >= ?", *filters.ParsedFrom)
+	}
+	if filters.ParsedTo != nil {
+		query = query.Where("paid_at < ?", filters.ParsedTo.AddDate(0, 0, 1))
+	}
+	if filters.ParsedCursor != nil {
+		query = query.Where("id < ?", filters.ParsedCursor.ID)
+	}
+
+	err := query.
+		Order("id DESC").
+		Limit(filters.Limit + 1).
+		Scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	page := &PaymentPage{Data: payments}
+	if len(payments) > filters.Limit {
+		page.Data = payments[:filters.Limit]
+		nextCursor := validator.EncodeCursor(validator.Cursor{ID: page.Data[len(page.Data)-1].ID})
+		page.NextCursor = &nextCursor
+	}
+
+	return page, nil
+}
+
+// VoidPayment marks a payment of the account as void. It stays in the ledger but no longer settles its charge.
+func VoidPayment(app *application.App, accountID, paymentID int, reason string) (*models.Payment, error) {
+	payment := &models.Payment{}
+	err := app.Database.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		err := tx.NewSelect().
+			Model(payment).
+			Where("id = ? AND account_id = ?", paymentID, accountID).
+			For("UPDATE").
+			Scan(ctx)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPaymentNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if payment.VoidedAt != nil {
+			return ErrPaymentAlreadyVoided
+		}
+
+		now := time.Now()
+		payment.VoidedAt = &now
+		payment.VoidReason = reason
+		payment.UpdatedAt = now
+		_, err = tx.NewUpdate().
+			Model(payment).
+			Column("voided_at", "void_reason", "updated_at").
+			WherePK().
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return stream.Publish(ctx, tx, accountID, stream.EventPaymentVoided, map[string]interface{}{
+			"payment_id":              payment.ID,
+			"subscription_details_id": payment.SubscriptionDetailsID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// Reconcile compares the account's expected charges due between from and to with the payments
+// linked to them. Expected charges of active subscriptions are created for the period first.
+func Reconcile(app *application.App, accountID int, request *validator.ReconciliationRequest, now time.Time) (*Reconciliation, error) {
+	ctx := context.Background()
+	today := utils.TruncateToDay(now)
+
+	subscriptions, err := LoadSubscriptions(ctx, app.Database, accountID, request.SubscriptionDetailsID, true)
+	if err != nil {
+		return nil, err
+	}
+	upTo := request.ParsedTo
+	if upTo.After(today) {
+		upTo = today
+	}
+	if !upTo.Before(request.ParsedFrom) {
+		if _, err := GenerateExpectedCharges(ctx, app.Database, subscriptions, request.ParsedFrom, upTo); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := LinkUnmatchedPayments(ctx, app.Database, accountID); err != nil {
+		return nil, err
+	}
+
+	charges := []models.Expected_Charge{}
+	chargeQuery := app.Database.NewSelect().
+		Model(&charges).
+		Where("account_id = ?", accountID).
+		Where("due_date BETWEEN ? AND ?", request.ParsedFrom, request.ParsedTo)
+	if request.SubscriptionDetailsID != 0 {
+		chargeQuery = chargeQuery.Where("subscription_details_id = ?", request.SubscriptionDetailsID)
+	}
+	if err := chargeQuery.Order("due_date ASC", "id ASC").Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	chargeIDs := []int{0}
+	for _, charge := range charges {
+		chargeIDs = append(chargeIDs, charge.ID)
+	}
+	payments := []models.Payment{}
+	paymentQuery := app.Database.NewSelect().
+		Model(&payments).
+		Where("account_id = ? AND voided_at IS NULL", accountID).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("expected_charge_id IN (?)", bun.In(chargeIDs)).
+				WhereGroup(" OR ", func(q *bun.SelectQuery) *bun.SelectQuery {
+					return q.Where("expected_charge_id IS NULL").
+						Where("paid_at >= ? AND paid_at < ?", request.ParsedFrom, request.ParsedTo.AddDate(0, 0, 1))
+				})
+		})
+	if request.SubscriptionDetailsID != 0 {
+		paymentQuery = paymentQuery.Where("subscription_details_id = ?", request.SubscriptionDetailsID)
+	}
+	if err := paymentQuery.Order("paid_at ASC", "id ASC").Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	paymentsByCharge := map[int][]models.Payment{}
+	reconciliation := &Reconciliation{
+		From:               request.ParsedFrom.Format("2006-01-02"),
+		To:                 request.ParsedTo.Format("2006-01-02"),
+		Charges:            []ReconciledCharge{},
+		UnexpectedPayments: []models.Payment{},
+	}
+	for _, payment := range payments {
+		if payment.ExpectedChargeID == nil {
+			reconciliation.UnexpectedPayments = append(reconciliation.UnexpectedPayments, payment)
+			continue
+		}
+		paymentsByCharge[*payment.ExpectedChargeID] = append(paymentsByCharge[*payment.ExpectedChargeID], payment)
+	}
+
+	for _, charge := range charges {
+		reconciled := reconcileCharge(charge, paymentsByCharge[charge.ID], today)
+		reconciliation.Charges = append(reconciliation.Charges, reconciled)
+
+		summary := &reconciliation.Summary
+		summary.Expected++
+		switch reconciled.Status {
+		case StatusPaid:
+			summary.Paid++
+		case StatusPending:
+			summary.Pending++
+		case StatusMissed:
+			summary.Missed++
+		case StatusDuplicate:
+			summary.Duplicate++
+		case StatusAmountMismatch:
+			summary.AmountMismatch++
+		}
+	}
+	reconciliation.Summary.Unexpected = len(reconciliation.UnexpectedPayments)
+
+	return reconciliation, nil
+}
+
+// reconcileCharge works out the status of an expected charge from the payments linked to it:
+// more than one is a duplicate, one with another amount or currency a mismatch, and none is
+// missed once missedGraceDays have passed since the due date.
+func reconcileCharge(charge models.Expected_Charge, payments []models.Payment, today time.Time) ReconciledCharge {
+	reconciled := ReconciledCharge{Expected_Charge: charge, Payments: payments}
+	if reconciled.Payments == nil {
+		reconciled.Payments = []models.Payment{}
+	}
+
+	sameCurrency := true
+	for _, payment := range payments {
+		reconciled.PaidAmount += payment.Amount
+		sameCurrency = sameCurrency && payment.Currency == charge.Currency
+	}
+	if sameCurrency {
+		reconciled.Difference = reconciled.PaidAmount - charge.Amount
+	}
+
+	switch {
+	case len(payments) > 1:
+		reconciled.Status = StatusDuplicate
+	case len(payments) == 1 && (!sameCurrency || reconciled.Difference != 0):
+		reconciled.Status = StatusAmountMismatch
+	case len(payments) == 1:
+		reconciled.Status = StatusPaid
+	default:
+		reconciled.Status = UnpaidStatus(charge.DueDate, today)
+	}
+
+	return reconciled
+}
+
+// UnpaidStatus is the status of a charge due on dueDate that no payment is linked to: missed once
+// missedGraceDays have passed since the due date, pending until then
+func UnpaidStatus(dueDate, today time.Time) string {
+	if utils.TruncateToDay(dueDate).AddDate(0, 0, missedGraceDays).Before(today) {
+		return StatusMissed
+	}
+	return StatusPending
+}
+
+// NotifyMissedCharges sends a renewal_failed notification for recent expected charges that have no
+// payment past the grace period. Only subscriptions the user records payments for are checked, so
+// accounts that do not use the ledger are not told every charge is missing.
+func NotifyMissedCharges(ctx context.Context, db bun.IDB, frontendURL string, now time.Time) (int, error) {
+	today := utils.TruncateToDay(now)
+	missed := []struct {
+		models.Expected_Charge `bun:",extend"`
+		ChannelName            string `bun:"channel_name"`
+	}{}
+	err := db.NewRaw(`
+		SELECT ec.*, sc.channel_name
+		FROM expected_charges ec
+		JOIN subscription_details sd ON sd.id = ec.subscription_details_id
+		JOIN subscription_channels sc ON sc.id = sd.subscription_channel_id
+		WHERE sd.status = 'active'
+			AND ec.due_date BETWEEN ? AND ?
+			AND NOT EXISTS (
+				SELECT 1 FROM payments p WHERE p.expected_charge_id = ec.id AND p.voided_at IS NULL
+			)
+			AND EXISTS (
+				SELECT 1 FROM payments p WHERE p.subscription_details_id = ec.subscription_details_id AND p.voided_at IS NULL
+			)
+	`, today.AddDate(0, 0, -missedGraceDays-missedNoticeDays), today.AddDate(0, 0, -missedGraceDays-1)).Scan(ctx, &missed)
+	if err != nil {
+		return 0, err
+	}
+
+	notified := 0
+	for _, charge := range missed {
+		dueDate := charge.DueDate.Format("Mon, Jan 2")
+		_, err := notifications.Notify(ctx, db, charge.AccountID, notifications.Event{
+			Type:                  notifications.EventRenewalFailed,
+			DedupeKey:             fmt.Sprintf("renewal_failed:%d", charge.ID),
+			SubscriptionDetailsID: charge.SubscriptionDetailsID,
+			Title:                 fmt.Sprintf("No payment recorded for %s", charge.ChannelName),
+			Body: fmt.Sprintf("The %s charge of %s %s due on %s has no payment. Check whether the renewal went through.",
+				charge.ChannelName, charge.Amount.String(), charge.Currency, dueDate),
+			URL: frontendURL,
+			Data: map[string]interface{}{
+				"subscription_details_id": charge.SubscriptionDetailsID,
+				"expected_charge_id":      charge.ID,
+				"due_date":                charge.DueDate.Format("2006-01-02"),
+				"amount":                  charge.Amount,
+				"currency":                charge.Currency,
+			},
+		}, now)
+		if err != nil {
+			log.Printf("Error notifying missed charge %d: %v", charge.ID, err)
+			continue
+		}
+		notified++
+	}
+
+	return notified, nil
+}
+
+// UpdateLedger creates the expected charges that came due recently, links waiting payments to them
+// and notifies missed charges
+func UpdateLedger(ctx context.Context, db bun.IDB, frontendURL string, now time.Time) error {
+	today := utils.TruncateToDay(now)
+	subscriptions, err := LoadSubscriptions(ctx, db, 0, 0, true)
+	if err != nil {
+		return err
+	}
+	created, err := GenerateExpectedCharges(ctx, db, subscriptions, today.AddDate(0, 0, -generateLookbackDays), today)
+	if err != nil {
+		return err
+	}
+	if created > 0 {
+		log.Printf("Created %d expected charges", created)
+	}
+
+	if _, err := LinkUnmatchedPayments(ctx, db, 0); err != nil {
+		return err
+	}
+
+	_, err = NotifyMissedCharges(ctx, db, frontendURL, now)
+	return err
+}
+
+// StartLedgerWorker updates the payment ledger once at startup and then every interval
+func StartLedgerWorker(ctx context.Context, app *application.App, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := UpdateLedger(ctx, app.Database, app.Config.Frontend.URL, time.Now()); err != nil {
+				log.Printf("Error updating payment ledger: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,61 @@
+package payments
+
+import (
+	"testing"
+	"time"
+
+	"subscritracker/pkg/models"
+	"subscritracker/pkg/money"
+)
+
+func date(value string) time.Time {
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+func payment(amount, currency string) models.Payment {
+	return models.Payment{Amount: money.MustParse(amount), Currency: currency}
+}
+
+func TestReconcileCharge(t *testing.T) {
+	charge := models.Expected_Charge{ID: 1, DueDate: date("2026-01-10"), Amount: money.MustParse("9.99"), Currency: "EUR"}
+
+	tests := []struct {
+		name           string
+		payments       []models.Payment
+		today          string
+		wantStatus     string
+		wantPaid       string
+		wantDifference string
+	}{
+		{"paid in full", []models.Payment{payment("9.99", "EUR")}, "2026-01-11", StatusPaid, "9.99", "0"},
+		{"paid too little", []models.Payment{payment("8.99", "EUR")}, "2026-01-11", StatusAmountMismatch, "8.99", "-1.00"},
+		{"paid too much", []models.Payment{payment("10.99", "EUR")}, "2026-01-11", StatusAmountMismatch, "10.99", "1.00"},
+		{"paid in another currency", []models.Payment{payment("9.99", "USD")}, "2026-01-11", StatusAmountMismatch, "9.99", "0"},
+		{"paid twice", []models.Payment{payment("9.99", "EUR"), payment("9.99", "EUR")}, "2026-01-11", StatusDuplicate, "19.98", "9.99"},
+		{"not paid yet", nil, "2026-01-09", StatusPending, "0", "-9.99"},
+		{"not paid on the last day of grace", nil, "2026-01-13", StatusPending, "0", "-9.99"},
+		{"not paid after the grace days", nil, "2026-01-14", StatusMissed, "0", "-9.99"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reconciled := reconcileCharge(charge, test.payments, date(test.today))
+			if reconciled.Status != test.wantStatus {
+				t.Errorf("reconcileCharge() status = %s, want %s", reconciled.Status, test.wantStatus)
+			}
+			if reconciled.PaidAmount != money.MustParse(test.wantPaid) {
+				t.Errorf("reconcileCharge() paid = %s, want %s", reconciled.PaidAmount, test.wantPaid)
+			}
+			if reconciled.Difference != money.MustParse(test.wantDifference) {
+				t.Errorf("reconcileCharge() difference = %s, want %s", reconciled.Difference, test.wantDifference)
+			}
+			if reconciled.Payments == nil {
+				t.Errorf("reconcileCharge() payments = nil, want an empty list")
+			}
+		})
+	}
+}
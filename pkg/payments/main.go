@@ -0,0 +1,13 @@
+package payments
+
+import (
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/utils"
+)
+
+func RegisterRoutes(app *application.App) {
+	app.Echo.GET("/v1/payments", GetPaymentsHandler, utils.AuthMiddleware)
+	app.Echo.POST("/v1/payments", CreatePaymentHandler, utils.AuthMiddleware)
+	app.Echo.GET("/v1/payments/reconciliation", GetReconciliationHandler, utils.AuthMiddleware)
+	app.Echo.POST("/v1/payments/:id/void", VoidPaymentHandler, utils.AuthMiddleware)
+}
@@ -0,0 +1,69 @@
+package payments
+
+import (
+	"errors"
+
+	"subscritracker/pkg/models"
+	"subscritracker/pkg/money"
+)
+
+// Reconciliation status of an expected charge
+const (
+	StatusPaid           = "paid"
+	StatusPending        = "pending"
+	StatusMissed         = "missed"
+	StatusDuplicate      = "duplicate"
+	StatusAmountMismatch = "amount_mismatch"
+)
+
+const (
+	// matchWindowDays is how far a payment date may be from a due date to settle that charge
+	matchWindowDays = 10
+	// missedGraceDays is how long after the due date a charge without a payment counts as missed
+	missedGraceDays = 3
+	// generateLookbackDays is how far back the ledger worker creates expected charges
+	generateLookbackDays = 45
+	// missedNoticeDays limits missed charge notifications to recent charges
+	missedNoticeDays = 14
+)
+
+var (
+	ErrPaymentNotFound        = errors.New("payment not found")
+	ErrPaymentAlreadyVoided   = errors.New("payment is already voided")
+	ErrExpectedChargeNotFound = errors.New("expected charge not found")
+)
+
+type PaymentPage struct {
+	Data       []models.Payment `json:"data"`
+	NextCursor *string          `json:"next_cursor"`
+}
+
+// ReconciledCharge is an expected charge with the payments linked to it.
+// Difference is what was paid minus what was expected, when the currencies match.
+type ReconciledCharge struct {
+	models.Expected_Charge
+	Status     string           `json:"status"`
+	PaidAmount money.Amount     `json:"paid_amount"`
+	Difference money.Amount     `json:"difference"`
+	Payments   []models.Payment `json:"payments"`
+}
+
+type ReconciliationSummary struct {
+	Expected       int `json:"expected"`
+	Paid           int `json:"paid"`
+	Pending        int `json:"pending"`
+	Missed         int `json:"missed"`
+	Duplicate      int `json:"duplicate"`
+	AmountMismatch int `json:"amount_mismatch"`
+	Unexpected     int `json:"unexpected"`
+}
+
+// Reconciliation compares the charges due in a period with what was paid.
+// UnexpectedPayments were paid in the period but match no expected charge.
+type Reconciliation struct {
+	From               string                `json:"from"`
+	To                 string                `json:"to"`
+	Summary            ReconciliationSummary `json:"summary"`
+	Charges            []ReconciledCharge    `json:"charges"`
+	UnexpectedPayments []models.Payment      `json:"unexpected_payments"`
+}
@@ -0,0 +1,93 @@
+package pricehistory
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/utils"
+	"subscritracker/pkg/validator"
+
+	"github.com/labstack/echo/v4"
+)
+
+// PostPriceChangeHandler records a price change for a subscription, effective now or scheduled for a later date
+func PostPriceChangeHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	subscriptionDetailsID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid subscription details ID"})
+	}
+
+	request, err := validator.ValidatePriceChangeRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	subscription, err := GetOwnedSubscription(app, accountID, subscriptionDetailsID)
+	if err != nil {
+		if errors.Is(err, ErrSubscriptionNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error getting subscription details:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get subscription details"})
+	}
+
+	entry, err := RecordPriceChange(utils.RequestContext(c), app.Database, subscription, request.MonthlyBill, request.EffectiveDate)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to record price change"})
+	}
+
+	return c.JSON(http.StatusCreated, entry)
+}
+
+// GetPriceTimelineHandler returns the price timeline of a subscription, including scheduled changes
+func GetPriceTimelineHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	subscriptionDetailsID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid subscription details ID"})
+	}
+
+	subscription, err := GetOwnedSubscription(app, accountID, subscriptionDetailsID)
+	if err != nil {
+		if errors.Is(err, ErrSubscriptionNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error getting subscription details:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get subscription details"})
+	}
+
+	history, err := GetPriceHistory(app, subscription.ID)
+	if err != nil {
+		log.Println("Error getting price history:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get price history"})
+	}
+
+	return c.JSON(http.StatusOK, BuildPriceTimeline(subscription, history))
+}
+
+// GetPriceIncreasesHandler returns the prices that went up in the last `months` months
+func GetPriceIncreasesHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	months, err := validator.ValidatePriceIncreaseMonths(c, DefaultIncreaseLookbackMonths)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	increases, err := GetPriceIncreases(app, accountID, time.Now().AddDate(0, -months, 0))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get price increases"})
+	}
+
+	return c.JSON(http.StatusOK, increases)
+}
@@ -0,0 +1,438 @@
+package pricehistory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/models"
+	"subscritracker/pkg/money"
+	"subscritracker/pkg/stream"
+	subscriptionevents "subscritracker/pkg/subscription-events"
+	subscriptionversions "subscritracker/pkg/subscription-versions"
+	"subscritracker/pkg/utils"
+
+	"github.com/uptrace/bun"
+)
+
+var ErrSubscriptionNotFound = errors.New("subscription details not found")
+
+// GetOwnedSubscription loads a subscription and makes sure it belongs to the account
+func GetOwnedSubscription(app *application.App, accountID, subscriptionDetailsID int) (models.Subscription_Details, error) {
+	subscription := models.Subscription_Details{}
+	err := app.Database.NewSelect().
+		Model(&subscription).
+		Where("id = ? AND account_id = ?", subscriptionDetailsID, accountID).
+		Scan(context.Background())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Subscription_Details{}, ErrSubscriptionNotFound
+		}
+		return models.Subscription_Details{}, err
+	}
+
+	return subscription, nil
+}
+
+// RecordInitialPrice stores the price a subscription was created with, effective from its start date
+func RecordInitialPrice(ctx context.Context, db bun.IDB, subscription models.Subscription_Details) error {
+	effectiveDate := subscription.StartDate
+	if effectiveDate.IsZero() {
+		effectiveDate = subscription.CreatedAt
+	}
+
+	entry := models.Subscription_Price_History{
+		SubscriptionDetailsID: subscription.ID,
+		AccountID:             subscription.AccountID,
+		MonthlyBill:           subscription.MonthlyBill,
+		EffectiveDate:         utils.TruncateToDay(effectiveDate),
+		CreatedAt:             time.Now(),
+		UpdatedAt:             time.Now(),
+	}
+
+	_, err := db.NewInsert().
+		Model(&entry).
+		On("CONFLICT (subscription_details_id, effective_date) DO NOTHING").
+		Exec(ctx)
+	if err != nil {
+		log.Println("Error recording initial price:", err)
+	}
+	return err
+}
+
+// RecordPriceChange stores a new price for a subscription. monthly_bill is then set in the same
+// transaction to the latest price already in effect, so a backdated entry older than the current price
+// leaves it alone. Future prices are scheduled and applied later by ApplyDuePriceChanges.
+func RecordPriceChange(ctx context.Context, db *bun.DB, subscription models.Subscription_Details, monthlyBill money.Amount, effectiveDate time.Time) (models.Subscription_Price_History, error) {
+	entry := models.Subscription_Price_History{
+		SubscriptionDetailsID: subscription.ID,
+		AccountID:             subscription.AccountID,
+		MonthlyBill:           monthlyBill,
+		EffectiveDate:         utils.TruncateToDay(effectiveDate),
+		CreatedAt:             time.Now(),
+		UpdatedAt:             time.Now(),
+	}
+
+	err := db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewInsert().
+			Model(&entry).
+			On("CONFLICT (subscription_details_id, effective_date) DO UPDATE").
+			Set("monthly_bill = EXCLUDED.monthly_bill").
+			Set("updated_at = EXCLUDED.updated_at").
+			Returning("*").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		scheduled := entry.EffectiveDate.After(utils.TruncateToDay(time.Now()))
+		err = stream.Publish(ctx, tx, subscription.AccountID, stream.EventSubscriptionPriceChanged, map[string]interface{}{
+			"subscription_details_id": subscription.ID,
+			"monthly_bill":            monthlyBill,
+			"effective_date":          entry.EffectiveDate.Format("2006-01-02"),
+			"scheduled":               scheduled,
+		})
+		if err != nil || scheduled {
+			return err
+		}
+
+		// Compare against the stored row, not the caller's copy, so concurrent changes are not undone
+		before := models.Subscription_Details{}
+		err = tx.NewSelect().
+			Model(&before).
+			Where("id = ?", subscription.ID).
+			For("UPDATE").
+			Scan(ctx)
+		if err != nil {
+			return err
+		}
+
+		// The same rule as ApplyDuePriceChanges: the latest entry that is in effect today
+		current := models.Subscription_Price_History{}
+		err = tx.NewSelect().
+			Model(&current).
+			Where("subscription_details_id = ? AND effective_date <= CURRENT_DATE", subscription.ID).
+			Order("effective_date DESC").
+			Limit(1).
+			Scan(ctx)
+		if err != nil || before.MonthlyBill == current.MonthlyBill {
+			return err
+		}
+
+		after := before
+		after.MonthlyBill = current.MonthlyBill
+		after.UpdatedAt = time.Now()
+		_, err = tx.NewUpdate().
+			Model((*models.Subscription_Details)(nil)).
+			Set("monthly_bill = ?", after.MonthlyBill).
+			Set("updated_at = ?", after.UpdatedAt).
+			Where("id = ?", subscription.ID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if err := subscriptionversions.Record(ctx, tx, &before, after); err != nil {
+			return err
+		}
+
+		return recordPriceChangedEvent(ctx, tx, subscription.ID, subscription.AccountID, before.MonthlyBill, after.MonthlyBill,
+			current.EffectiveDate, before.Currency, subscriptionevents.ActorUser)
+	})
+	if err != nil {
+		log.Println("Error recording price change:", err)
+		return models.Subscription_Price_History{}, err
+	}
+
+	return entry, nil
+}
+
+// GetPriceHistory returns the price history of a subscription, oldest first
+func GetPriceHistory(app *application.App, subscriptionDetailsID int) ([]models.Subscription_Price_History, error) {
+	history := []models.Subscription_Price_History{}
+	err := app.Database.NewSelect().
+		Model(&history).
+		Where("subscription_details_id = ?", subscriptionDetailsID).
+		Order("effective_date ASC").
+		Scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// GetPriceHistoryForAccount returns every price history entry of the account grouped by subscription, oldest first
+func GetPriceHistoryForAccount(app *application.App, accountID int) (map[int][]models.Subscription_Price_History, error) {
+	history := []models.Subscription_Price_History{}
+	err := app.Database.NewSelect().
+		Model(&history).
+		Where("account_id = ?", accountID).
+		Order("subscription_details_id ASC", "effective_date ASC").
+		Scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	historyBySubscription := map[int][]models.Subscription_Price_History{}
+	for _, entry := range history {
+		historyBySubscription[entry.SubscriptionDetailsID] = append(historyBySubscription[entry.SubscriptionDetailsID], entry)
+	}
+
+	return historyBySubscription, nil
+}
+
+// GetPriceHistories returns the price history of the given subscriptions grouped by subscription, oldest first
+func GetPriceHistories(ctx context.Context, db bun.IDB, subscriptionDetailsIDs []int) (map[int][]models.Subscription_Price_History, error) {
+	historyBySubscription := map[int][]models.Subscription_Price_History{}
+	if len(subscriptionDetailsIDs) == 0 {
+		return historyBySubscription, nil
+	}
+
+	history := []models.Subscription_Price_History{}
+	err := db.NewSelect().
+		Model(&history).
+		Where("subscription_details_id IN (?)", bun.In(subscriptionDetailsIDs)).
+		Order("subscription_details_id ASC", "effective_date ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range history {
+		historyBySubscription[entry.SubscriptionDetailsID] = append(historyBySubscription[entry.SubscriptionDetailsID], entry)
+	}
+
+	return historyBySubscription, nil
+}
+
+// ResolvePrice returns what the subscription charges on date: the regular price effective on that
+// date according to the history (falling back to monthly_bill), then trial and intro pricing on top
+func ResolvePrice(subscription models.Subscription_Details, history []models.Subscription_Price_History, date time.Time) money.Amount {
+	return subscription.ApplyIntroductoryPricing(date, RegularPriceOn(subscription, history, date))
+}
+
+// RegularPriceOn returns the regular price effective on date, ignoring trial and intro pricing.
+// Dates before the first recorded price use the earliest known price.
+func RegularPriceOn(subscription models.Subscription_Details, history []models.Subscription_Price_History, date time.Time) money.Amount {
+	if len(history) == 0 {
+		return subscription.MonthlyBill
+	}
+
+	sorted := history
+	if !sort.SliceIsSorted(sorted, func(i, j int) bool { return sorted[i].EffectiveDate.Before(sorted[j].EffectiveDate) }) {
+		sorted = append([]models.Subscription_Price_History(nil), history...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].EffectiveDate.Before(sorted[j].EffectiveDate) })
+	}
+
+	day := utils.TruncateToDay(date)
+	price := sorted[0].MonthlyBill
+	for _, entry := range sorted {
+		if utils.TruncateToDay(entry.EffectiveDate).After(day) {
+			break
+		}
+		price = entry.MonthlyBill
+	}
+
+	return price
+}
+
+// BuildPriceTimeline turns a price history into a timeline with the change against the previous price
+func BuildPriceTimeline(subscription models.Subscription_Details, history []models.Subscription_Price_History) PriceTimeline {
+	today := utils.TruncateToDay(time.Now())
+	entries := []PriceTimelineEntry{}
+
+	for i, entry := range history {
+		timelineEntry := PriceTimelineEntry{
+			EffectiveDate: entry.EffectiveDate,
+			MonthlyBill:   entry.MonthlyBill,
+			Scheduled:     utils.TruncateToDay(entry.EffectiveDate).After(today),
+		}
+		if i > 0 {
+			previous := history[i-1].MonthlyBill
+			timelineEntry.PreviousBill = &previous
+			timelineEntry.Change = entry.MonthlyBill - previous
+			timelineEntry.ChangePercent = PercentChange(previous, entry.MonthlyBill)
+		}
+		entries = append(entries, timelineEntry)
+	}
+
+	return PriceTimeline{
+		SubscriptionDetailsID: subscription.ID,
+		CurrentBill:           subscription.MonthlyBill,
+		Entries:               entries,
+	}
+}
+
+// GetPriceIncreases returns the price increases of the account that took effect since the given date
+func GetPriceIncreases(app *application.App, accountID int, since time.Time) ([]PriceIncrease, error) {
+	increases := []PriceIncrease{}
+
+	query := `
+		SELECT
+			t.subscription_details_id,
+			t.subscription_channel_id,
+			t.subscription_channel_name,
+			t.effective_date,
+			t.old_bill,
+			t.new_bill
+		FROM (
+			SELECT
+				ph.subscription_details_id,
+				sd.subscription_channel_id,
+				sc.channel_name AS subscription_channel_name,
+				ph.effective_date,
+				ph.monthly_bill AS new_bill,
+				LAG(ph.monthly_bill) OVER (PARTITION BY ph.subscription_details_id ORDER BY ph.effective_date) AS old_bill
+			FROM subscription_price_history ph
+			JOIN subscription_details sd ON ph.subscription_details_id = sd.id
+			JOIN subscription_channels sc ON sd.subscription_channel_id = sc.id
+			WHERE ph.account_id = ?
+		) t
+		WHERE t.old_bill IS NOT NULL
+			AND t.new_bill > t.old_bill
+			AND t.effective_date >= ?
+			AND t.effective_date <= CURRENT_DATE
+		ORDER BY t.effective_date DESC
+	`
+
+	err := app.Database.NewRaw(query, accountID, utils.TruncateToDay(since)).Scan(context.Background(), &increases)
+	if err != nil {
+		log.Println("Error getting price increases: because of database error", err)
+		return nil, err
+	}
+
+	for i := range increases {
+		increases[i].Change = increases[i].NewBill - increases[i].OldBill
+		increases[i].ChangePercent = PercentChange(increases[i].OldBill, increases[i].NewBill)
+	}
+
+	return increases, nil
+}
+
+// ApplyDuePriceChanges copies scheduled prices that became effective onto subscription_details.monthly_bill
+// and returns the changes it applied. The version, price_changed event and stream update of each change are
+// stored in the same transaction, so a change is never applied without them.
+func ApplyDuePriceChanges(ctx context.Context, db bun.IDB) ([]AppliedPriceChange, error) {
+	applied := []AppliedPriceChange{}
+	err := db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		err := tx.NewRaw(`
+			UPDATE subscription_details sd
+			SET monthly_bill = ph.monthly_bill, updated_at = CURRENT_TIMESTAMP
+			FROM (
+				SELECT DISTINCT ON (h.subscription_details_id)
+					h.subscription_details_id, h.monthly_bill, h.effective_date,
+					current.monthly_bill AS old_bill, current.updated_at AS old_updated_at
+				FROM subscription_price_history h
+				JOIN subscription_details current ON current.id = h.subscription_details_id
+				WHERE h.effective_date <= CURRENT_DATE
+				ORDER BY h.subscription_details_id, h.effective_date DESC
+			) ph
+			WHERE sd.id = ph.subscription_details_id
+				AND sd.monthly_bill IS DISTINCT FROM ph.monthly_bill
+			RETURNING sd.id AS subscription_details_id, sd.account_id, sd.currency,
+				ph.effective_date, ph.old_bill, ph.old_updated_at, ph.monthly_bill AS new_bill
+		`).Scan(ctx, &applied)
+		if err != nil || len(applied) == 0 {
+			return err
+		}
+
+		ids := make([]int, len(applied))
+		for i, change := range applied {
+			ids[i] = change.SubscriptionDetailsID
+		}
+		changed := []models.Subscription_Details{}
+		if err := tx.NewSelect().Model(&changed).Where("id IN (?)", bun.In(ids)).Scan(ctx); err != nil {
+			return err
+		}
+
+		// Only monthly_bill and updated_at were changed, so the state before is the one after with those put back
+		changesByID := map[int]AppliedPriceChange{}
+		for _, change := range applied {
+			changesByID[change.SubscriptionDetailsID] = change
+		}
+		for _, after := range changed {
+			change := changesByID[after.ID]
+			before := after
+			before.MonthlyBill = change.OldBill
+			before.UpdatedAt = change.OldUpdatedAt
+			if err := subscriptionversions.Record(ctx, tx, &before, after); err != nil {
+				return err
+			}
+
+			err := recordPriceChangedEvent(ctx, tx, change.SubscriptionDetailsID, change.AccountID, change.OldBill, change.NewBill,
+				change.EffectiveDate, change.Currency, subscriptionevents.ActorSystem)
+			if err != nil {
+				return err
+			}
+			err = stream.Publish(ctx, tx, change.AccountID, stream.EventSubscriptionPriceChanged, map[string]interface{}{
+				"subscription_details_id": change.SubscriptionDetailsID,
+				"monthly_bill":            change.NewBill,
+				"effective_date":          change.EffectiveDate.Format("2006-01-02"),
+				"scheduled":               false,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+// StartScheduledPriceChangeWorker applies scheduled price changes once at startup and then every interval.
+// The account hears about them from the price_changed events, through the outbox.
+func StartScheduledPriceChangeWorker(ctx context.Context, app *application.App, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			applied, err := ApplyDuePriceChanges(ctx, app.Database)
+			if err != nil {
+				log.Printf("Error applying scheduled price changes: %v", err)
+			} else if len(applied) > 0 {
+				log.Printf("Applied %d scheduled price changes", len(applied))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// recordPriceChangedEvent adds a price change that took effect to the subscription's history
+func recordPriceChangedEvent(ctx context.Context, db bun.IDB, subscriptionDetailsID, accountID int, oldBill, newBill money.Amount, effectiveDate time.Time, currency, actor string) error {
+	return subscriptionevents.Record(ctx, db, &models.Subscription_Event{
+		SubscriptionDetailsID: subscriptionDetailsID,
+		AccountID:             accountID,
+		Type:                  subscriptionevents.EventPriceChanged,
+		Payload: map[string]interface{}{
+			"old_bill":       oldBill,
+			"new_bill":       newBill,
+			"effective_date": effectiveDate.Format("2006-01-02"),
+			"currency":       currency,
+		},
+		Actor: actor,
+	})
+}
+
+// PercentChange returns the change in percent rounded to 2 decimals
+func PercentChange(oldValue, newValue money.Amount) float64 {
+	if oldValue == 0 {
+		return 0
+	}
+	return math.Round(float64(newValue-oldValue)/float64(oldValue)*10000) / 100
+}
@@ -0,0 +1,12 @@
+package pricehistory
+
+import (
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/utils"
+)
+
+func RegisterRoutes(app *application.App) {
+	app.Echo.POST("/v1/subscription-details/:id/price-changes", PostPriceChangeHandler, utils.AuthMiddleware)
+	app.Echo.GET("/v1/subscription-details/:id/price-history", GetPriceTimelineHandler, utils.AuthMiddleware)
+	app.Echo.GET("/v1/price-history/increases", GetPriceIncreasesHandler, utils.AuthMiddleware)
+}
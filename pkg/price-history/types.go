@@ -0,0 +1,47 @@
+package pricehistory
+
+import (
+	"time"
+
+	"subscritracker/pkg/money"
+)
+
+// DefaultIncreaseLookbackMonths is used when the increases query has no `months` parameter
+const DefaultIncreaseLookbackMonths = 6
+
+type PriceTimelineEntry struct {
+	EffectiveDate time.Time     `json:"effective_date"`
+	MonthlyBill   money.Amount  `json:"monthly_bill"`
+	PreviousBill  *money.Amount `json:"previous_bill,omitempty"`
+	Change        money.Amount  `json:"change"`
+	ChangePercent float64       `json:"change_percent"`
+	Scheduled     bool          `json:"scheduled"`
+}
+
+type PriceTimeline struct {
+	SubscriptionDetailsID int                  `json:"subscription_details_id"`
+	CurrentBill           money.Amount         `json:"current_bill"`
+	Entries               []PriceTimelineEntry `json:"entries"`
+}
+
+type PriceIncrease struct {
+	SubscriptionDetailsID   int          `json:"subscription_details_id"`
+	SubscriptionChannelID   int          `json:"subscription_channel_id"`
+	SubscriptionChannelName string       `json:"subscription_channel_name"`
+	EffectiveDate           time.Time    `json:"effective_date"`
+	OldBill                 money.Amount `json:"old_bill"`
+	NewBill                 money.Amount `json:"new_bill"`
+	Change                  money.Amount `json:"change"`
+	ChangePercent           float64      `json:"change_percent"`
+}
+
+// AppliedPriceChange is a scheduled price that became effective and was copied onto the subscription
+type AppliedPriceChange struct {
+	SubscriptionDetailsID int          `bun:"subscription_details_id"`
+	AccountID             int          `bun:"account_id"`
+	Currency              string       `bun:"currency"`
+	EffectiveDate         time.Time    `bun:"effective_date"`
+	OldBill               money.Amount `bun:"old_bill"`
+	OldUpdatedAt          time.Time    `bun:"old_updated_at"`
+	NewBill               money.Amount `bun:"new_bill"`
+}
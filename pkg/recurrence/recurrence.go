@@ -0,0 +1,171 @@
+package recurrence
+
+import (
+	"time"
+
+	"subscritracker/pkg/models"
+	"subscritracker/pkg/utils"
+)
+
+// maxOccurrences guards against runaway loops, e.g. a daily subscription over a very long range
+const maxOccurrences = 5000
+
+// Occurrences returns every billing date of the subscription between from and to, inclusive.
+// The series is anchored on NextDueDate (or the first chargeable date when it is unset) and
+// repeats by DueType. Nothing is billed before StartDate, during the free trial or after EndDate.
+// Status is not considered; callers decide which subscriptions are still billing.
+func Occurrences(subscription models.Subscription_Details, from, to time.Time) []time.Time {
+	from, to = utils.TruncateToDay(from), utils.TruncateToDay(to)
+	occurrences := []time.Time{}
+	if to.Before(from) {
+		return occurrences
+	}
+
+	first := FirstChargeDate(subscription)
+	anchor := first
+	if !subscription.NextDueDate.IsZero() {
+		anchor = utils.TruncateToDay(subscription.NextDueDate)
+	}
+
+	last := to
+	if subscription.EndDate != nil && utils.TruncateToDay(*subscription.EndDate).Before(last) {
+		last = utils.TruncateToDay(*subscription.EndDate)
+	}
+	lower := from
+	if first.After(lower) {
+		lower = first
+	}
+	if last.Before(lower) {
+		return occurrences
+	}
+
+	// Walk back from the anchor to the first cycle inside the range, then forward to its end
+	step := 0
+	for step > -maxOccurrences && !Step(subscription, anchor, step-1).Before(lower) {
+		step--
+	}
+	for ; len(occurrences) < maxOccurrences; step++ {
+		date := Step(subscription, anchor, step)
+		if date.After(last) {
+			break
+		}
+		if !date.Before(lower) {
+			occurrences = append(occurrences, date)
+		}
+	}
+
+	return occurrences
+}
+
+// NextOccurrence returns the first billing date on or after date, if there is one within a year and a day
+func NextOccurrence(subscription models.Subscription_Details, date time.Time) (time.Time, bool) {
+	occurrences := Occurrences(subscription, date, date.AddDate(1, 0, 1))
+	if len(occurrences) == 0 {
+		return time.Time{}, false
+	}
+	return occurrences[0], true
+}
+
+// StoppedOn returns the day a subscription that is no longer active stopped billing: the day of its latest
+// stop event (stopEvents are its cancelled and paused events, oldest first), or else its end date.
+// ok is false for active subscriptions, and for stopped ones with neither since then it is not known
+// when they stopped.
+func StoppedOn(subscription models.Subscription_Details, stopEvents []models.Subscription_Event) (time.Time, bool) {
+	if subscription.Status == "active" {
+		return time.Time{}, false
+	}
+	if len(stopEvents) > 0 {
+		return utils.TruncateToDay(stopEvents[len(stopEvents)-1].CreatedAt), true
+	}
+	if subscription.EndDate != nil {
+		return utils.TruncateToDay(*subscription.EndDate), true
+	}
+	return time.Time{}, false
+}
+
+// BilledOccurrences returns the billing dates between from and to, inclusive, the subscription was or will
+// be billed on. Active subscriptions bill on every one; stopped ones up to and including the day they
+// stopped, and not at all when it is not known when they stopped.
+func BilledOccurrences(subscription models.Subscription_Details, stopEvents []models.Subscription_Event, from, to time.Time) []time.Time {
+	if subscription.Status == "active" {
+		return Occurrences(subscription, from, to)
+	}
+
+	stopped, ok := StoppedOn(subscription, stopEvents)
+	if !ok {
+		return []time.Time{}
+	}
+	if stopped.Before(utils.TruncateToDay(to)) {
+		to = stopped
+	}
+	return Occurrences(subscription, from, to)
+}
+
+// FirstChargeDate is the first date the subscription can be billed: the end of the free trial, or the start date
+func FirstChargeDate(subscription models.Subscription_Details) time.Time {
+	first := utils.TruncateToDay(subscription.StartDate)
+	if subscription.TrialEndDate != nil && utils.TruncateToDay(*subscription.TrialEndDate).After(first) {
+		first = utils.TruncateToDay(*subscription.TrialEndDate)
+	}
+	return first
+}
+
+// FirstDueDate is the first billing date of the subscription: its first chargeable date, moved on to the
+// next DueDayOfMonth for monthly subscriptions that set one, clamped to the end of shorter months, as Step
+// does. Other subscriptions repeat from their first chargeable date.
+func FirstDueDate(subscription models.Subscription_Details) time.Time {
+	first := FirstChargeDate(subscription)
+	switch subscription.DueType {
+	case "daily", "weekly", "yearly":
+		return first
+	}
+	if subscription.DueDayOfMonth <= 1 {
+		return first
+	}
+
+	due := addMonthsClamped(first, 0, subscription.DueDayOfMonth)
+	if due.Before(first) {
+		due = addMonthsClamped(first, 1, subscription.DueDayOfMonth)
+	}
+	return due
+}
+
+// Step moves a billing date by n cycles (n may be negative). Monthly subscriptions bill on DueDayOfMonth
+// when set, clamped to the end of shorter months; yearly ones on the anniversary of the anchor.
+func Step(subscription models.Subscription_Details, anchor time.Time, n int) time.Time {
+	switch subscription.DueType {
+	case "daily":
+		return anchor.AddDate(0, 0, n)
+	case "weekly":
+		return anchor.AddDate(0, 0, 7*n)
+	case "yearly":
+		return addMonthsClamped(anchor, 12*n, billingDay(subscription, anchor))
+	default:
+		day := billingDay(subscription, anchor)
+		if subscription.DueDayOfMonth > 1 {
+			day = subscription.DueDayOfMonth
+		}
+		return addMonthsClamped(anchor, n, day)
+	}
+}
+
+// billingDay is the day of the month the anchor stands for. An anchor clamped to the end of a short month,
+// such as Feb 28 for a subscription first billed on Jan 31, keeps billing on the day of the first charge.
+func billingDay(subscription models.Subscription_Details, anchor time.Time) int {
+	day := anchor.Day()
+	first := FirstChargeDate(subscription)
+	if anchor.AddDate(0, 0, 1).Day() != 1 || first.Day() <= day {
+		return day
+	}
+	if subscription.DueType == "yearly" && first.Month() != anchor.Month() {
+		return day
+	}
+	return first.Day()
+}
+
+// addMonthsClamped moves date by months and sets the day, clamped to the last day of the target month
+func addMonthsClamped(date time.Time, months, day int) time.Time {
+	firstOfMonth := time.Date(date.Year(), date.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	return time.Date(firstOfMonth.Year(), firstOfMonth.Month(), min(day, lastDay), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,174 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+
+	"subscritracker/pkg/models"
+)
+
+func date(value string) time.Time {
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+func datePointer(value string) *time.Time {
+	parsed := date(value)
+	return &parsed
+}
+
+func formatDates(dates []time.Time) []string {
+	formatted := make([]string, len(dates))
+	for i, value := range dates {
+		formatted[i] = value.Format("2006-01-02")
+	}
+	return formatted
+}
+
+func equalDates(got []time.Time, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if !got[i].Equal(date(want[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestOccurrences(t *testing.T) {
+	tests := []struct {
+		name         string
+		subscription models.Subscription_Details
+		from, to     string
+		want         []string
+	}{
+		{
+			"monthly from the end of January is clamped to shorter months",
+			models.Subscription_Details{DueType: "monthly", DueDayOfMonth: 1, StartDate: date("2026-01-31")},
+			"2026-01-01", "2026-05-31",
+			[]string{"2026-01-31", "2026-02-28", "2026-03-31", "2026-04-30", "2026-05-31"},
+		},
+		{
+			"monthly anchored on a clamped due date keeps the first charge day",
+			models.Subscription_Details{DueType: "monthly", DueDayOfMonth: 1, StartDate: date("2026-01-31"), NextDueDate: date("2026-02-28")},
+			"2026-02-01", "2026-04-30",
+			[]string{"2026-02-28", "2026-03-31", "2026-04-30"},
+		},
+		{
+			"monthly on a due day of the month",
+			models.Subscription_Details{DueType: "monthly", DueDayOfMonth: 31, StartDate: date("2026-01-15")},
+			"2026-01-01", "2026-04-30",
+			[]string{"2026-01-31", "2026-02-28", "2026-03-31", "2026-04-30"},
+		},
+		{
+			"monthly after a free trial",
+			models.Subscription_Details{DueType: "monthly", DueDayOfMonth: 1, StartDate: date("2026-01-01"), TrialEndDate: datePointer("2026-01-20")},
+			"2026-01-01", "2026-03-31",
+			[]string{"2026-01-20", "2026-02-20", "2026-03-20"},
+		},
+		{
+			"weekly",
+			models.Subscription_Details{DueType: "weekly", StartDate: date("2026-01-01")},
+			"2026-01-01", "2026-01-31",
+			[]string{"2026-01-01", "2026-01-08", "2026-01-15", "2026-01-22", "2026-01-29"},
+		},
+		{
+			"daily up to the end date",
+			models.Subscription_Details{DueType: "daily", StartDate: date("2026-01-01"), EndDate: datePointer("2026-01-03")},
+			"2026-01-01", "2026-01-10",
+			[]string{"2026-01-01", "2026-01-02", "2026-01-03"},
+		},
+		{
+			"yearly on February 29 is clamped outside leap years",
+			models.Subscription_Details{DueType: "yearly", StartDate: date("2024-02-29")},
+			"2024-01-01", "2028-12-31",
+			[]string{"2024-02-29", "2025-02-28", "2026-02-28", "2027-02-28", "2028-02-29"},
+		},
+		{
+			"range starting mid-series",
+			models.Subscription_Details{DueType: "monthly", DueDayOfMonth: 1, StartDate: date("2025-06-10")},
+			"2026-01-11", "2026-03-10",
+			[]string{"2026-02-10", "2026-03-10"},
+		},
+		{
+			"range before the start date",
+			models.Subscription_Details{DueType: "monthly", DueDayOfMonth: 1, StartDate: date("2026-06-01")},
+			"2026-01-01", "2026-05-31",
+			[]string{},
+		},
+		{
+			"to before from",
+			models.Subscription_Details{DueType: "monthly", DueDayOfMonth: 1, StartDate: date("2026-01-01")},
+			"2026-03-01", "2026-02-01",
+			[]string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Occurrences(test.subscription, date(test.from), date(test.to))
+			if !equalDates(got, test.want) {
+				t.Errorf("Occurrences() = %v, want %v", formatDates(got), test.want)
+			}
+		})
+	}
+}
+
+func TestStep(t *testing.T) {
+	tests := []struct {
+		name         string
+		subscription models.Subscription_Details
+		anchor       string
+		n            int
+		want         string
+	}{
+		{"daily forward", models.Subscription_Details{DueType: "daily"}, "2026-02-27", 3, "2026-03-02"},
+		{"weekly backward", models.Subscription_Details{DueType: "weekly"}, "2026-01-08", -2, "2025-12-25"},
+		{"monthly clamped to February", models.Subscription_Details{DueType: "monthly", StartDate: date("2026-01-31")}, "2026-01-31", 1, "2026-02-28"},
+		{"monthly clamped to a leap February", models.Subscription_Details{DueType: "monthly", StartDate: date("2028-01-31")}, "2028-01-31", 1, "2028-02-29"},
+		{"monthly back across a year", models.Subscription_Details{DueType: "monthly", StartDate: date("2025-01-31")}, "2026-03-31", -4, "2025-11-30"},
+		{"monthly due day of the month", models.Subscription_Details{DueType: "monthly", DueDayOfMonth: 30, StartDate: date("2026-01-05")}, "2026-01-30", 1, "2026-02-28"},
+		{"unset due type is monthly", models.Subscription_Details{StartDate: date("2026-01-15")}, "2026-01-15", 2, "2026-03-15"},
+		{"yearly from a leap day", models.Subscription_Details{DueType: "yearly", StartDate: date("2024-02-29")}, "2024-02-29", 1, "2025-02-28"},
+		{"yearly back to a leap day", models.Subscription_Details{DueType: "yearly", StartDate: date("2024-02-29")}, "2025-02-28", 3, "2028-02-29"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Step(test.subscription, date(test.anchor), test.n)
+			if !got.Equal(date(test.want)) {
+				t.Errorf("Step() = %s, want %s", got.Format("2006-01-02"), test.want)
+			}
+		})
+	}
+}
+
+func TestBillingDay(t *testing.T) {
+	tests := []struct {
+		name         string
+		subscription models.Subscription_Details
+		anchor       string
+		want         int
+	}{
+		{"anchor in the middle of the month", models.Subscription_Details{DueType: "monthly", StartDate: date("2026-01-31")}, "2026-02-15", 15},
+		{"anchor clamped to the end of February", models.Subscription_Details{DueType: "monthly", StartDate: date("2026-01-31")}, "2026-02-28", 31},
+		{"anchor clamped to the end of April", models.Subscription_Details{DueType: "monthly", StartDate: date("2026-01-30")}, "2026-04-30", 30},
+		{"end of month on the first charge day", models.Subscription_Details{DueType: "monthly", StartDate: date("2026-01-28")}, "2026-02-28", 28},
+		{"first charge after a trial", models.Subscription_Details{DueType: "monthly", StartDate: date("2026-01-01"), TrialEndDate: datePointer("2026-01-31")}, "2026-02-28", 31},
+		{"yearly anchor clamped from a leap day", models.Subscription_Details{DueType: "yearly", StartDate: date("2024-02-29")}, "2025-02-28", 29},
+		{"yearly end of month in another month", models.Subscription_Details{DueType: "yearly", StartDate: date("2024-01-31")}, "2025-02-28", 28},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := billingDay(test.subscription, date(test.anchor)); got != test.want {
+				t.Errorf("billingDay() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package reminders
+
+import (
+	"net/http"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/validator"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetRemindersHandler lists the user's queued and delivered reminders with their delivery status
+func GetRemindersHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	filters, err := validator.ValidateReminderFilters(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	page, err := GetReminders(app, accountID, filters)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get reminders"})
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,359 @@
+package reminders
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/models"
+	"subscritracker/pkg/recurrence"
+	"subscritracker/pkg/stream"
+	subscriptionevents "subscritracker/pkg/subscription-events"
+	"subscritracker/pkg/utils"
+	"subscritracker/pkg/validator"
+
+	"github.com/uptrace/bun"
+)
+
+// PlanReminders returns the reminders of a subscription firing between from and to, in the given timezone.
+//   - reminder_days_before fires that many days before every due date
+//   - otherwise reminder_date is a one-off reminder for the next due date
+//   - otherwise reminder_time alone fires on every due date
+//
+// Reminders fire at reminder_time local time, or 09:00 when it is not set.
+func PlanReminders(subscription models.Subscription_Details, location *time.Location, from, to time.Time) []PlannedReminder {
+	planned := []PlannedReminder{}
+	hour, minute := defaultReminderHour, 0
+	if subscription.ReminderTime != nil {
+		hour, minute = subscription.ReminderTime.Hour(), subscription.ReminderTime.Minute()
+	}
+	at := func(day time.Time) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, location)
+	}
+	inWindow := func(remindAt time.Time) bool {
+		return !remindAt.Before(from) && !remindAt.After(to)
+	}
+
+	daysBefore := -1
+	switch {
+	case subscription.ReminderDaysBefore != nil:
+		daysBefore = *subscription.ReminderDaysBefore
+	case subscription.ReminderDate != nil:
+		dueDate := subscription.NextDueDate
+		if dueDate.IsZero() {
+			dueDate = *subscription.ReminderDate
+		}
+		if remindAt := at(*subscription.ReminderDate); inWindow(remindAt) {
+			planned = append(planned, PlannedReminder{DueDate: utils.TruncateToDay(dueDate), RemindAt: remindAt})
+		}
+		return planned
+	case subscription.ReminderTime != nil:
+		daysBefore = 0
+	default:
+		return planned
+	}
+
+	// Due dates whose reminder can fall in the window, with a day of slack for timezone offsets
+	firstDue := from.AddDate(0, 0, daysBefore-1)
+	lastDue := to.AddDate(0, 0, daysBefore+1)
+	for _, dueDate := range recurrence.Occurrences(subscription, firstDue, lastDue) {
+		if remindAt := at(dueDate.AddDate(0, 0, -daysBefore)); inWindow(remindAt) {
+			planned = append(planned, PlannedReminder{DueDate: dueDate, RemindAt: remindAt})
+		}
+	}
+
+	return planned
+}
+
+// EnqueueDueReminders writes the reminders firing soon to the queue. The unique key on
+// (subscription_details_id, due_date) makes this safe to run from every replica and after restarts;
+// a pending reminder that has not been attempted yet follows changes to the subscription's settings.
+func EnqueueDueReminders(ctx context.Context, db bun.IDB, now time.Time) (int64, error) {
+	rows := []struct {
+		models.Subscription_Details `bun:",extend"`
+		Timezone                    string `bun:"timezone"`
+	}{}
+	err := db.NewRaw(`
+		SELECT sd.*, a.timezone
+		FROM subscription_details sd
+		JOIN account a ON sd.account_id = a.id
+		WHERE sd.status = 'active'
+			AND (sd.reminder_days_before IS NOT NULL OR sd.reminder_date IS NOT NULL OR sd.reminder_time IS NOT NULL)
+	`).Scan(ctx, &rows)
+	if err != nil {
+		return 0, err
+	}
+
+	reminders := []models.Reminder{}
+	for _, row := range rows {
+		location, err := time.LoadLocation(row.Timezone)
+		if err != nil {
+			location = time.UTC
+		}
+		for _, planned := range PlanReminders(row.Subscription_Details, location, now.Add(-missedGrace), now.Add(enqueueAhead)) {
+			reminders = append(reminders, models.Reminder{
+				AccountID:             row.AccountID,
+				SubscriptionDetailsID: row.ID,
+				DueDate:               planned.DueDate,
+				RemindAt:              planned.RemindAt,
+				Status:                StatusPending,
+				NextAttemptAt:         planned.RemindAt,
+				CreatedAt:             now,
+				UpdatedAt:             now,
+			})
+		}
+	}
+	if len(reminders) == 0 {
+		return 0, nil
+	}
+
+	scheduled := []models.Reminder{}
+	_, err = db.NewInsert().
+		Model(&reminders).
+		On("CONFLICT (subscription_details_id, due_date) DO UPDATE").
+		Set("remind_at = EXCLUDED.remind_at").
+		Set("next_attempt_at = EXCLUDED.next_attempt_at").
+		Set("updated_at = EXCLUDED.updated_at").
+		Where("reminder.status = ? AND reminder.attempts = 0 AND reminder.remind_at IS DISTINCT FROM EXCLUDED.remind_at", StatusPending).
+		Returning("*").
+		Exec(ctx, &scheduled)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, reminder := range scheduled {
+		err := stream.Publish(ctx, db, reminder.AccountID, stream.EventReminderScheduled, map[string]interface{}{
+			"reminder_id":             reminder.ID,
+			"subscription_details_id": reminder.SubscriptionDetailsID,
+			"due_date":                reminder.DueDate.Format("2006-01-02"),
+			"remind_at":               reminder.RemindAt,
+		})
+		if err != nil {
+			log.Printf("Error publishing reminder %d: %v", reminder.ID, err)
+		}
+	}
+
+	return int64(len(scheduled)), nil
+}
+
+// ClaimDueReminders locks a batch of reminders that are due for this worker. SKIP LOCKED lets replicas
+// claim different rows, and reminders stuck in processing past lockTimeout are claimed again.
+func ClaimDueReminders(ctx context.Context, db bun.IDB, now time.Time) ([]models.Reminder, error) {
+	claimed := []models.Reminder{}
+	err := db.NewRaw(`
+		UPDATE reminders
+		SET status = ?, locked_at = ?, attempts = attempts + 1, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM reminders
+			WHERE (status = ? AND next_attempt_at <= ?)
+				OR (status = ? AND locked_at < ?)
+			ORDER BY next_attempt_at ASC
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`, StatusProcessing, now, now, StatusPending, now, StatusProcessing, now.Add(-lockTimeout), claimBatchSize).Scan(ctx, &claimed)
+	if err != nil {
+		return nil, err
+	}
+
+	return claimed, nil
+}
+
+// ProcessDueReminders claims due reminders and delivers them, recording the outcome of each
+func ProcessDueReminders(ctx context.Context, db bun.IDB, deliverer Deliverer, now time.Time) (int, error) {
+	claimed, err := ClaimDueReminders(ctx, db, now)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, reminder := range claimed {
+		due, err := loadDueReminder(ctx, db, reminder)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && due.Subscription.Status != "active") {
+			markReminder(ctx, db, reminder.ID, StatusSkipped, "subscription is no longer active", now)
+			continue
+		}
+		if err != nil {
+			retryReminder(ctx, db, reminder, err, now)
+			continue
+		}
+
+		if err := deliverer.Deliver(ctx, due); err != nil {
+			retryReminder(ctx, db, reminder, err, now)
+			continue
+		}
+		markReminder(ctx, db, reminder.ID, StatusSent, "", now)
+		sent++
+
+		err = subscriptionevents.Record(ctx, db, &models.Subscription_Event{
+			SubscriptionDetailsID: reminder.SubscriptionDetailsID,
+			AccountID:             reminder.AccountID,
+			Type:                  subscriptionevents.EventReminderSent,
+			Payload: map[string]interface{}{
+				"reminder_id": reminder.ID,
+				"due_date":    reminder.DueDate.Format("2006-01-02"),
+			},
+			Actor: subscriptionevents.ActorSystem,
+		})
+		if err != nil {
+			log.Printf("Error recording sent reminder %d: %v", reminder.ID, err)
+		}
+	}
+
+	return sent, nil
+}
+
+func loadDueReminder(ctx context.Context, db bun.IDB, reminder models.Reminder) (DueReminder, error) {
+	due := DueReminder{Reminder: reminder}
+	err := db.NewSelect().
+		Model(&due.Subscription).
+		Where("id = ?", reminder.SubscriptionDetailsID).
+		Scan(ctx)
+	if err != nil {
+		return DueReminder{}, err
+	}
+
+	err = db.NewRaw(`
+		SELECT sc.channel_name, a.timezone
+		FROM subscription_channels sc, account a
+		WHERE sc.id = ? AND a.id = ?
+	`, due.Subscription.SubscriptionChannelID, reminder.AccountID).Scan(ctx, &due.ChannelName, &due.Timezone)
+	if err != nil {
+		return DueReminder{}, err
+	}
+
+	return due, nil
+}
+
+func markReminder(ctx context.Context, db bun.IDB, reminderID int, status, reason string, now time.Time) {
+	query := db.NewUpdate().
+		Model((*models.Reminder)(nil)).
+		Set("status = ?", status).
+		Set("locked_at = NULL").
+		Set("updated_at = ?", now).
+		Where("id = ?", reminderID)
+	if status == StatusSent {
+		query = query.Set("sent_at = ?", now)
+	}
+	if reason != "" {
+		query = query.Set("last_error = ?", reason)
+	}
+	if _, err := query.Exec(ctx); err != nil {
+		log.Printf("Error marking reminder %d as %s: %v", reminderID, status, err)
+	}
+}
+
+// retryReminder puts a failed reminder back in the queue with exponential backoff, or marks it failed
+func retryReminder(ctx context.Context, db bun.IDB, reminder models.Reminder, cause error, now time.Time) {
+	log.Printf("Error delivering reminder %d (attempt %d): %v", reminder.ID, reminder.Attempts, cause)
+	if reminder.Attempts >= maxAttempts {
+		markReminder(ctx, db, reminder.ID, StatusFailed, cause.Error(), now)
+		return
+	}
+
+	_, err := db.NewUpdate().
+		Model((*models.Reminder)(nil)).
+		Set("status = ?", StatusPending).
+		Set("locked_at = NULL").
+		Set("next_attempt_at = ?", now.Add(Backoff(reminder.Attempts))).
+		Set("last_error = ?", cause.Error()).
+		Set("updated_at = ?", now).
+		Where("id = ?", reminder.ID).
+		Exec(ctx)
+	if err != nil {
+		log.Printf("Error rescheduling reminder %d: %v", reminder.ID, err)
+	}
+}
+
+// Backoff is the delay before the next attempt: 1, 2, 4, 8... minutes, capped at maxBackoff
+func Backoff(attempts int) time.Duration {
+	delay := time.Minute << max(attempts-1, 0)
+	if delay <= 0 || delay > maxBackoff {
+		return maxBackoff
+	}
+	return delay
+}
+
+// GetReminders lists the account's reminders, newest first
+func GetReminders(app *application.App, accountID int, filters *validator.ReminderFilters) (*ReminderPage, error) {
+	query := `
+		SELECT r.*, sc.channel_name
+		FROM reminders r
+		JOIN subscription_details sd ON r.subscription_details_id = sd.id
+		JOIN subscription_channels sc ON sd.subscription_channel_id = sc.id
+		WHERE r.account_id = ?
+	`
+	args := []interface{}{accountID}
+
+	if filters.Status != "" {
+		query += ` AND r.status = ?`
+		args = append(args, filters.Status)
+	}
+	if filters.ParsedCursor != nil {
+		query += ` AND (r.remind_at, r.id) < (?, ?)`
+		args = append(args, filters.CursorTime, filters.ParsedCursor.ID)
+	}
+	query += ` ORDER BY r.remind_at DESC, r.id DESC LIMIT ?`
+	args = append(args, filters.Limit+1)
+
+	items := []ReminderListItem{}
+	if err := app.Database.NewRaw(query, args...).Scan(context.Background(), &items); err != nil {
+		log.Println("Error getting reminders: because of database error", err)
+		return nil, err
+	}
+
+	page := &ReminderPage{Data: items}
+	if len(items) > filters.Limit {
+		page.Data = items[:filters.Limit]
+		last := page.Data[len(page.Data)-1]
+		nextCursor := validator.EncodeCursor(validator.Cursor{
+			Key: last.RemindAt.UTC().Format(time.RFC3339Nano),
+			ID:  last.ID,
+		})
+		page.NextCursor = &nextCursor
+	}
+
+	return page, nil
+}
+
+// StartReminderWorker enqueues and delivers reminders once at startup and then every interval
+func StartReminderWorker(ctx context.Context, app *application.App, interval time.Duration, deliverer Deliverer) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			now := time.Now()
+			if enqueued, err := EnqueueDueReminders(ctx, app.Database, now); err != nil {
+				log.Printf("Error enqueueing reminders: %v", err)
+			} else if enqueued > 0 {
+				log.Printf("Enqueued %d reminders", enqueued)
+			}
+
+			if sent, err := ProcessDueReminders(ctx, app.Database, deliverer, now); err != nil {
+				log.Printf("Error processing reminders: %v", err)
+			} else if sent > 0 {
+				log.Printf("Sent %d reminders", sent)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// LogDeliverer writes reminders to the server log
+type LogDeliverer struct{}
+
+func (LogDeliverer) Deliver(ctx context.Context, reminder DueReminder) error {
+	log.Printf("Reminder for account %d: %s is due on %s",
+		reminder.Reminder.AccountID, reminder.ChannelName, reminder.Reminder.DueDate.Format("2006-01-02"))
+	return nil
+}
@@ -0,0 +1,10 @@
+package reminders
+
+import (
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/utils"
+)
+
+func RegisterRoutes(app *application.App) {
+	app.Echo.GET("/v1/reminders", GetRemindersHandler, utils.AuthMiddleware)
+}
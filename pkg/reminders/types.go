@@ -0,0 +1,63 @@
+package reminders
+
+import (
+	"context"
+	"time"
+
+	"subscritracker/pkg/models"
+)
+
+const (
+	StatusPending    = "pending"
+	StatusProcessing = "processing"
+	StatusSent       = "sent"
+	StatusFailed     = "failed"
+	StatusSkipped    = "skipped"
+)
+
+const (
+	// defaultReminderHour is the local time reminders without a reminder_time fire at
+	defaultReminderHour = 9
+	// enqueueAhead is how far ahead reminders are written to the queue
+	enqueueAhead = 24 * time.Hour
+	// missedGrace still sends reminders that were due while the server was down, up to this long ago
+	missedGrace = 12 * time.Hour
+	// lockTimeout releases reminders claimed by a worker that died before finishing
+	lockTimeout = 10 * time.Minute
+	// claimBatchSize is how many reminders one worker tick claims
+	claimBatchSize = 50
+	// maxAttempts before a reminder is marked failed
+	maxAttempts = 5
+	// maxBackoff caps the delay between attempts
+	maxBackoff = time.Hour
+)
+
+// PlannedReminder is a reminder computed from a subscription's settings, before it is queued
+type PlannedReminder struct {
+	DueDate  time.Time
+	RemindAt time.Time
+}
+
+// DueReminder is a claimed reminder with what a deliverer needs to describe it
+type DueReminder struct {
+	Reminder     models.Reminder
+	Subscription models.Subscription_Details
+	ChannelName  string
+	Timezone     string
+}
+
+// Deliverer sends a due reminder to the user. Returning an error retries it with backoff.
+type Deliverer interface {
+	Deliver(ctx context.Context, reminder DueReminder) error
+}
+
+// ReminderListItem is a reminder with its subscription's channel, as listed to the user
+type ReminderListItem struct {
+	models.Reminder `bun:",extend"`
+	ChannelName     string `bun:"channel_name" json:"channel_name"`
+}
+
+type ReminderPage struct {
+	Data       []ReminderListItem `json:"data"`
+	NextCursor *string            `json:"next_cursor"`
+}
@@ -0,0 +1,48 @@
+package securitylog
+
+import (
+	"log"
+	"net/http"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/validator"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetAccountSecurityLogHandler lists the security events of the user's own account
+func GetAccountSecurityLogHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	filters, err := validator.ValidateSecurityLogFilters(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	page, err := GetSecurityLog(app, accountID, filters)
+	if err != nil {
+		log.Println("Error getting security log:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get security log"})
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+// GetAdminSecurityLogHandler searches the security events of every account
+func GetAdminSecurityLogHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+
+	filters, err := validator.ValidateSecurityLogFilters(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	page, err := GetSecurityLog(app, 0, filters)
+	if err != nil {
+		log.Println("Error searching security log:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to search security log"})
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
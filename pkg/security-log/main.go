@@ -0,0 +1,11 @@
+package securitylog
+
+import (
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/utils"
+)
+
+func RegisterRoutes(app *application.App) {
+	app.Echo.GET("/v1/account/security-log", GetAccountSecurityLogHandler, utils.AuthMiddleware)
+	app.Echo.GET("/v1/admin/security-log", GetAdminSecurityLogHandler, utils.AuthMiddleware, utils.AdminMiddleware(app.Config.Admin.Emails))
+}
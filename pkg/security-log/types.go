@@ -0,0 +1,57 @@
+package securitylog
+
+import "subscritracker/pkg/models"
+
+// Event types
+const (
+	EventSignup                 = "signup"
+	EventLogin                  = "login"
+	EventOAuthLogin             = "oauth_login"
+	EventOAuthLinked            = "oauth_linked"
+	EventLogout                 = "logout"
+	EventEmailVerified          = "email_verified"
+	EventPasswordResetRequested = "password_reset_requested"
+	EventAccountUpdated         = "account_updated"
+)
+
+// Outcomes
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Failure reasons. They are only recorded, never returned, so responses keep not telling
+// an unknown email from a wrong password.
+const (
+	ReasonUnknownEmail     = "unknown_email"
+	ReasonInvalidPassword  = "invalid_password"
+	ReasonEmailNotVerified = "email_not_verified"
+	ReasonAccountInactive  = "account_inactive"
+	ReasonInvalidToken     = "invalid_token"
+	ReasonOAuthFailed      = "oauth_failed"
+)
+
+// Entry is an event to record. AccountID is 0 when the event matched no account, like a login
+// with an unknown email; Email is what was entered then.
+type Entry struct {
+	AccountID int
+	Email     string
+	EventType string
+	Outcome   string
+	Reason    string
+	Metadata  map[string]interface{}
+}
+
+type SecurityLogPage struct {
+	Data       []models.Security_Event `json:"data"`
+	NextCursor *string                 `json:"next_cursor"`
+}
+
+// maxUserAgentLength is how much of the User-Agent header is kept
+const maxUserAgentLength = 512
+
+// Column sizes of values that come from the client, so an oversized one cannot make the insert fail
+const (
+	maxEmailLength     = 255
+	maxRequestIDLength = 100
+)
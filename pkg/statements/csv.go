@@ -0,0 +1,267 @@
+package statements
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"subscritracker/pkg/money"
+)
+
+// Header names used by common bank and card exports
+var (
+	csvDateColumns        = []string{"date", "transaction date", "posted date", "posting date", "booking date", "value date", "trans. date", "completed date"}
+	csvDescriptionColumns = []string{"description", "payee", "merchant", "name", "details", "narrative", "transaction description", "memo", "reference"}
+	csvAmountColumns      = []string{"amount", "transaction amount", "amount (gbp)", "amount (usd)", "amount (eur)", "value"}
+	csvDebitColumns       = []string{"debit", "debit amount", "withdrawal", "withdrawals", "money out", "paid out"}
+	csvCreditColumns      = []string{"credit", "credit amount", "deposit", "deposits", "money in", "paid in"}
+	csvCurrencyColumns    = []string{"currency"}
+)
+
+// Date layouts tried in order; the first that parses every row wins, so 03/04 is read consistently per file
+var csvDateLayouts = []string{
+	"2006-01-02",
+	"2006/01/02",
+	"01/02/2006",
+	"1/2/2006",
+	"02/01/2006",
+	"2/1/2006",
+	"01/02/06",
+	"02/01/06",
+	"02.01.2006",
+	"02-01-2006",
+	"01-02-2006",
+	"02 Jan 2006",
+	"2 Jan 2006",
+	"Jan 2, 2006",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05Z07:00",
+}
+
+type csvLayout struct {
+	date        int
+	description int
+	amount      int
+	debit       int
+	credit      int
+	currency    int
+}
+
+// ParseCSV reads a statement export with a header row. Amounts come from a single signed column or from
+// separate debit and credit columns. With a single column, whichever sign is used by most rows is taken as
+// the charge sign, since banks export charges as negative and card issuers often as positive.
+func ParseCSV(data []byte) (*Statement, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = detectDelimiter(data)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+
+	// Some exports put account details above the header, so look for it in the first lines
+	headerIndex, layout := -1, csvLayout{}
+	for i, record := range records[:min(len(records), 20)] {
+		if found, ok := findCSVLayout(record); ok {
+			headerIndex, layout = i, found
+			break
+		}
+	}
+	if headerIndex < 0 {
+		return nil, errors.New("CSV must have a header with date, description and amount (or debit/credit) columns")
+	}
+
+	rows := [][]string{}
+	for _, record := range records[headerIndex+1:] {
+		if len(record) > layout.date && strings.TrimSpace(record[layout.date]) != "" {
+			rows = append(rows, record)
+		}
+	}
+	if len(rows) == 0 {
+		return nil, ErrNoTransactions
+	}
+
+	dateLayout, err := detectDateLayout(rows, layout.date)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &Statement{Format: "csv", Transactions: []Transaction{}}
+	negativeRows := 0
+	for i, record := range rows {
+		date, _ := time.Parse(dateLayout, strings.TrimSpace(record[layout.date]))
+
+		amount, err := csvRowAmount(record, layout)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", headerIndex+i+2, err)
+		}
+		if amount.IsNegative() {
+			negativeRows++
+		}
+
+		statement.Transactions = append(statement.Transactions, Transaction{
+			Date:        time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC),
+			Description: strings.TrimSpace(csvValue(record, layout.description)),
+			Amount:      amount,
+			Currency:    strings.ToUpper(strings.TrimSpace(csvValue(record, layout.currency))),
+		})
+	}
+
+	// Store charges as positive amounts
+	if layout.amount >= 0 && negativeRows*2 >= len(rows) {
+		for i := range statement.Transactions {
+			statement.Transactions[i].Amount = -statement.Transactions[i].Amount
+		}
+	}
+
+	return statement, nil
+}
+
+func findCSVLayout(header []string) (csvLayout, bool) {
+	layout := csvLayout{date: -1, description: -1, amount: -1, debit: -1, credit: -1, currency: -1}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch {
+		case layout.date < 0 && containsString(csvDateColumns, name):
+			layout.date = i
+		case layout.description < 0 && containsString(csvDescriptionColumns, name):
+			layout.description = i
+		case layout.amount < 0 && containsString(csvAmountColumns, name):
+			layout.amount = i
+		case layout.debit < 0 && containsString(csvDebitColumns, name):
+			layout.debit = i
+		case layout.credit < 0 && containsString(csvCreditColumns, name):
+			layout.credit = i
+		case layout.currency < 0 && containsString(csvCurrencyColumns, name):
+			layout.currency = i
+		}
+	}
+
+	hasAmount := layout.amount >= 0 || layout.debit >= 0
+	return layout, layout.date >= 0 && layout.description >= 0 && hasAmount
+}
+
+// csvRowAmount returns the signed amount of a row. With debit and credit columns, debits are charges
+// and become positive while credits become negative.
+func csvRowAmount(record []string, layout csvLayout) (money.Amount, error) {
+	if layout.amount >= 0 {
+		return parseStatementAmount(csvValue(record, layout.amount))
+	}
+
+	if debit := strings.TrimSpace(csvValue(record, layout.debit)); debit != "" {
+		amount, err := parseStatementAmount(debit)
+		if err != nil {
+			return 0, err
+		}
+		if amount.IsNegative() {
+			return -amount, nil
+		}
+		return amount, nil
+	}
+	if credit := strings.TrimSpace(csvValue(record, layout.credit)); credit != "" {
+		amount, err := parseStatementAmount(credit)
+		if err != nil {
+			return 0, err
+		}
+		if amount.IsNegative() {
+			return amount, nil
+		}
+		return -amount, nil
+	}
+
+	return 0, nil
+}
+
+// parseStatementAmount accepts "1,234.56", "1.234,56", "(12.00)", "-$9.99" and "9.99 EUR"
+func parseStatementAmount(value string) (money.Amount, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	negative := false
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		negative = true
+		value = value[1 : len(value)-1]
+	}
+
+	var builder strings.Builder
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9', r == '.', r == ',':
+			builder.WriteRune(r)
+		case r == '-':
+			negative = !negative
+		}
+	}
+	cleaned := builder.String()
+
+	// The last separator is the decimal one when it is followed by 1 or 2 digits
+	lastSeparator := strings.LastIndexAny(cleaned, ".,")
+	if lastSeparator >= 0 && len(cleaned)-lastSeparator-1 <= 2 {
+		integerPart := strings.NewReplacer(".", "", ",", "").Replace(cleaned[:lastSeparator])
+		cleaned = integerPart + "." + cleaned[lastSeparator+1:]
+	} else {
+		cleaned = strings.NewReplacer(".", "", ",", "").Replace(cleaned)
+	}
+
+	amount, err := money.ParseAmount(cleaned)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+func detectDateLayout(rows [][]string, column int) (string, error) {
+	for _, layout := range csvDateLayouts {
+		parsesAll := true
+		for _, record := range rows {
+			if _, err := time.Parse(layout, strings.TrimSpace(record[column])); err != nil {
+				parsesAll = false
+				break
+			}
+		}
+		if parsesAll {
+			return layout, nil
+		}
+	}
+	return "", fmt.Errorf("unrecognized date format %q", strings.TrimSpace(rows[0][column]))
+}
+
+// detectDelimiter picks the most common of comma, semicolon and tab in the first lines,
+// which may include account details above the header
+func detectDelimiter(data []byte) rune {
+	head := data[:min(len(data), 4096)]
+	best, bestCount := ',', 0
+	for _, delimiter := range []rune{',', ';', '\t'} {
+		if count := bytes.Count(head, []byte(string(delimiter))); count > bestCount {
+			best, bestCount = delimiter, count
+		}
+	}
+	return best
+}
+
+func csvValue(record []string, index int) string {
+	if index < 0 || index >= len(record) {
+		return ""
+	}
+	return record[index]
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
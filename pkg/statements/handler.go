@@ -0,0 +1,106 @@
+package statements
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"subscritracker/pkg/account"
+	"subscritracker/pkg/application"
+	subscription_channels "subscritracker/pkg/subscription-channels"
+	subscriptiondetails "subscritracker/pkg/subscription-details"
+	"subscritracker/pkg/utils"
+	"subscritracker/pkg/validator"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AnalyzeStatementHandler parses an uploaded statement and returns the recurring charges found in it.
+// Nothing is stored; the file is only read for this request.
+func AnalyzeStatementHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	request, err := validator.ValidateStatementUpload(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	statement, err := ParseStatement(request.Data, request.Format)
+	if err != nil {
+		if errors.Is(err, ErrUnknownFormat) || errors.Is(err, ErrNoTransactions) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Failed to parse statement: " + err.Error()})
+	}
+	if request.Currency != "" {
+		statement.Currency = request.Currency
+	}
+
+	channels, err := subscription_channels.GetAllChannels(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get subscription channels"})
+	}
+
+	accountDetails, err := account.GetAccountById(app, accountID)
+	if err != nil {
+		log.Println("Error getting account for default currency:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get account"})
+	}
+
+	result, err := AnalyzeStatement(app, accountID, statement, channels, accountDetails.DefaultCurrency)
+	if err != nil {
+		log.Println("Error analyzing statement:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to analyze statement"})
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// AcceptSuggestionsHandler creates subscription details from accepted suggestions in one transaction,
+// using the same validation and per-row report as the CSV import
+func AcceptSuggestionsHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	request, err := validator.ValidateStatementAcceptRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	rows := make([]subscriptiondetails.ImportRow, 0, len(request.Subscriptions))
+	for i, suggestion := range request.Subscriptions {
+		rows = append(rows, subscriptiondetails.ImportRow{
+			Line:                  i + 1,
+			SubscriptionChannelID: suggestion.SubscriptionChannelID,
+			Amount:                suggestion.MonthlyBill.String(),
+			Cycle:                 suggestion.DueType,
+			StartDate:             suggestion.StartDate,
+			NextDueDate:           suggestion.NextDueDate,
+			Currency:              suggestion.Currency,
+			Notes:                 suggestion.Notes,
+		})
+	}
+
+	channels, err := subscription_channels.GetAllChannels(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get subscription channels"})
+	}
+
+	accountDetails, err := account.GetAccountById(app, accountID)
+	if err != nil {
+		log.Println("Error getting account for default currency:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get account"})
+	}
+
+	result, err := subscriptiondetails.ImportSubscriptionDetails(utils.RequestContext(c), app, accountID, accountDetails.DefaultCurrency, rows, channels, request.DryRun)
+	if err != nil {
+		log.Println("Error accepting statement suggestions:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create subscription details"})
+	}
+
+	if result.Imported > 0 {
+		return c.JSON(http.StatusCreated, result)
+	}
+	return c.JSON(http.StatusOK, result)
+}
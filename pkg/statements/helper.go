@@ -0,0 +1,301 @@
+package statements
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/models"
+	subscription_channels "subscritracker/pkg/subscription-channels"
+)
+
+// Words that card processors and banks add around merchant names
+var merchantNoiseWords = map[string]bool{
+	"pos": true, "purchase": true, "debit": true, "credit": true, "card": true, "visa": true, "mastercard": true,
+	"recurring": true, "payment": true, "direct": true, "dd": true, "sepa": true, "ach": true, "bill": true,
+	"www": true, "com": true, "net": true, "org": true, "co": true, "uk": true, "us": true, "inc": true,
+	"ltd": true, "llc": true, "gmbh": true, "sq": true, "tst": true, "paypal": true, "pp": true,
+	"subscription": true, "monthly": true, "online": true, "help": true, "the": true,
+}
+
+const maxMerchantWords = 3
+
+// ParseStatement parses an OFX, QFX or CSV statement. An empty format is detected from the content.
+func ParseStatement(data []byte, format string) (*Statement, error) {
+	switch format {
+	case "ofx", "qfx":
+		return ParseOFX(data)
+	case "csv":
+		return ParseCSV(data)
+	case "":
+		if isOFX(data) {
+			return ParseOFX(data)
+		}
+		if bytes.ContainsAny(data[:min(len(data), 4096)], ",;\t") {
+			return ParseCSV(data)
+		}
+		return nil, ErrUnknownFormat
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// NormalizeMerchant reduces a statement description such as "SQ *SPOTIFY P0A1B2 STOCKHOLM" to a
+// merchant key like "spotify stockholm": lowercase words without digits or processor noise
+func NormalizeMerchant(description string) string {
+	words := strings.FieldsFunc(strings.ToLower(description), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	kept := []string{}
+	for _, word := range words {
+		if len(word) < 2 || merchantNoiseWords[word] || strings.ContainsFunc(word, unicode.IsDigit) {
+			continue
+		}
+		kept = append(kept, word)
+		if len(kept) == maxMerchantWords {
+			break
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// DetectRecurring groups charges by merchant and similar amount and keeps the groups that repeat on a
+// weekly, monthly or yearly cycle. Suggestions are sorted by confidence, most likely first.
+func DetectRecurring(statement *Statement, now time.Time) []Suggestion {
+	byMerchant := map[string][]Transaction{}
+	for _, transaction := range statement.Transactions {
+		if transaction.Amount <= 0 {
+			continue
+		}
+		merchant := NormalizeMerchant(transaction.Description)
+		if merchant == "" {
+			continue
+		}
+		byMerchant[merchant] = append(byMerchant[merchant], transaction)
+	}
+
+	suggestions := []Suggestion{}
+	for merchant, transactions := range byMerchant {
+		for _, cluster := range clusterByAmount(transactions) {
+			suggestion, ok := detectCycle(merchant, cluster, now)
+			if !ok {
+				continue
+			}
+			if suggestion.Currency == "" {
+				suggestion.Currency = statement.Currency
+			}
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Confidence != suggestions[j].Confidence {
+			return suggestions[i].Confidence > suggestions[j].Confidence
+		}
+		return suggestions[i].Merchant < suggestions[j].Merchant
+	})
+
+	return suggestions
+}
+
+// clusterByAmount splits a merchant's charges into groups whose amounts are within amountTolerance
+// of each other, so e.g. a streaming plan and a one-off rental from the same merchant are kept apart
+func clusterByAmount(transactions []Transaction) [][]Transaction {
+	sorted := append([]Transaction(nil), transactions...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Amount < sorted[j].Amount })
+
+	clusters := [][]Transaction{}
+	current := []Transaction{}
+	for _, transaction := range sorted {
+		if len(current) > 0 {
+			base := current[0].Amount
+			if float64(transaction.Amount-base) > float64(base)*amountTolerance {
+				clusters = append(clusters, current)
+				current = []Transaction{}
+			}
+		}
+		current = append(current, transaction)
+	}
+	if len(current) > 0 {
+		clusters = append(clusters, current)
+	}
+
+	for _, cluster := range clusters {
+		sort.Slice(cluster, func(i, j int) bool { return cluster[i].Date.Before(cluster[j].Date) })
+	}
+	return clusters
+}
+
+// detectCycle infers the billing cycle of a cluster from the median gap between charges.
+// Confidence is the share of gaps that fit the cycle, reduced when there are few charges.
+func detectCycle(merchant string, transactions []Transaction, now time.Time) (Suggestion, bool) {
+	transactions = dedupeSameDay(transactions)
+	if len(transactions) < 2 {
+		return Suggestion{}, false
+	}
+
+	gaps := make([]int, 0, len(transactions)-1)
+	for i := 1; i < len(transactions); i++ {
+		gaps = append(gaps, int(transactions[i].Date.Sub(transactions[i-1].Date).Hours()/24))
+	}
+	medianGap := median(gaps)
+
+	for _, candidate := range cycles {
+		if medianGap < candidate.minDays || medianGap > candidate.maxDays || len(transactions) < candidate.minHits {
+			continue
+		}
+
+		fitting := 0
+		for _, gap := range gaps {
+			// A missed month still counts when the gap is a whole number of cycles
+			cyclesInGap := math.Round(float64(gap) / candidate.days)
+			if cyclesInGap >= 1 && math.Abs(float64(gap)-cyclesInGap*candidate.days) <= float64(candidate.maxDays-candidate.minDays)/2 {
+				fitting++
+			}
+		}
+		regularity := float64(fitting) / float64(len(gaps))
+		coverage := math.Min(1, float64(len(transactions))/float64(candidate.minHits+1))
+		confidence := math.Round(regularity*coverage*100) / 100
+		if confidence < MinSuggestionConfidence {
+			return Suggestion{}, false
+		}
+
+		first := transactions[0]
+		last := transactions[len(transactions)-1]
+		return Suggestion{
+			Merchant:       displayMerchant(merchant),
+			MonthlyBill:    last.Amount,
+			Currency:       last.Currency,
+			DueType:        candidate.dueType,
+			StartDate:      first.Date.Format("2006-01-02"),
+			NextDueDate:    nextChargeDate(last.Date, candidate.dueType, now).Format("2006-01-02"),
+			Occurrences:    len(transactions),
+			LastChargeDate: last.Date.Format("2006-01-02"),
+			Confidence:     confidence,
+			Transactions:   transactions,
+		}, true
+	}
+
+	return Suggestion{}, false
+}
+
+// nextChargeDate steps from the last charge by whole cycles until it reaches today or later
+func nextChargeDate(last time.Time, dueType string, now time.Time) time.Time {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	next := last
+	for steps := 1; !next.After(last) || next.Before(today); steps++ {
+		switch dueType {
+		case "weekly":
+			next = last.AddDate(0, 0, 7*steps)
+		case "yearly":
+			next = addMonthsClamped(last, 12*steps)
+		default:
+			next = addMonthsClamped(last, steps)
+		}
+	}
+	return next
+}
+
+// addMonthsClamped adds months keeping the day of month, clamped to the month's last day (Jan 31 -> Feb 28)
+func addMonthsClamped(date time.Time, months int) time.Time {
+	firstOfMonth := time.Date(date.Year(), date.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	return time.Date(firstOfMonth.Year(), firstOfMonth.Month(), min(date.Day(), lastDay), 0, 0, 0, 0, time.UTC)
+}
+
+// dedupeSameDay keeps one charge per day so a retried card authorization is not read as a weekly cycle
+func dedupeSameDay(transactions []Transaction) []Transaction {
+	deduped := []Transaction{}
+	for _, transaction := range transactions {
+		if len(deduped) > 0 && deduped[len(deduped)-1].Date.Equal(transaction.Date) {
+			continue
+		}
+		deduped = append(deduped, transaction)
+	}
+	return deduped
+}
+
+func median(values []int) int {
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+func displayMerchant(merchant string) string {
+	words := strings.Fields(merchant)
+	for i, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, " ")
+}
+
+// AnalyzeStatement finds recurring charges in a statement and matches each merchant to a subscription channel
+func AnalyzeStatement(app *application.App, accountID int, statement *Statement, channels []*models.Subscription_Channels, defaultCurrency string) (*AnalysisResult, error) {
+	subscribedChannelIDs := []int{}
+	err := app.Database.NewSelect().
+		Model((*models.Subscription_Details)(nil)).
+		Column("subscription_channel_id").
+		Where("account_id = ?", accountID).
+		Scan(context.Background(), &subscribedChannelIDs)
+	if err != nil {
+		return nil, err
+	}
+	subscribed := map[int]bool{}
+	for _, channelID := range subscribedChannelIDs {
+		subscribed[channelID] = true
+	}
+
+	result := &AnalysisResult{
+		Format:           statement.Format,
+		TransactionCount: len(statement.Transactions),
+		Suggestions:      DetectRecurring(statement, time.Now()),
+	}
+	if len(statement.Transactions) > 0 {
+		periodStart, periodEnd := statement.Transactions[0].Date, statement.Transactions[0].Date
+		for _, transaction := range statement.Transactions {
+			if transaction.Date.Before(periodStart) {
+				periodStart = transaction.Date
+			}
+			if transaction.Date.After(periodEnd) {
+				periodEnd = transaction.Date
+			}
+		}
+		result.PeriodStart = periodStart.Format("2006-01-02")
+		result.PeriodEnd = periodEnd.Format("2006-01-02")
+	}
+
+	matcher := subscription_channels.NewChannelMatcher(channels)
+	for i := range result.Suggestions {
+		suggestion := &result.Suggestions[i]
+		if suggestion.Currency == "" {
+			suggestion.Currency = defaultCurrency
+		}
+
+		match, err := matcher.Match(suggestion.Merchant)
+		if err != nil {
+			continue
+		}
+		channelID := match.Channel.ID
+		suggestion.SubscriptionChannelID = &channelID
+		suggestion.MatchedChannel = &SuggestedChannel{
+			ID:          match.Channel.ID,
+			ChannelName: match.Channel.ChannelName,
+			Score:       match.Score,
+		}
+		suggestion.AlreadySubscribed = subscribed[channelID]
+	}
+
+	return result, nil
+}
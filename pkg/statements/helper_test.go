@@ -0,0 +1,168 @@
+package statements
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"subscritracker/pkg/money"
+)
+
+func date(value string) time.Time {
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// charges returns a charge of amount from description on each of dates
+func charges(description, amount string, dates ...string) []Transaction {
+	transactions := []Transaction{}
+	for _, value := range dates {
+		transactions = append(transactions, Transaction{Date: date(value), Description: description, Amount: money.MustParse(amount)})
+	}
+	return transactions
+}
+
+func describe(suggestion Suggestion) string {
+	return fmt.Sprintf("%s %s %s %s next %s x%d %.2f", suggestion.Merchant, suggestion.DueType, suggestion.MonthlyBill,
+		suggestion.Currency, suggestion.NextDueDate, suggestion.Occurrences, suggestion.Confidence)
+}
+
+func TestDetectRecurring(t *testing.T) {
+	now := date("2026-03-20")
+
+	tests := []struct {
+		name         string
+		transactions []Transaction
+		want         []string
+	}{
+		{
+			"merchants sorted by confidence",
+			append(charges("NETFLIX.COM 866-579-7172", "15.49", "2026-02-10", "2026-03-10"),
+				charges("SQ *SPOTIFY P0A1B2", "9.99", "2026-01-05", "2026-02-05", "2026-03-05")...),
+			[]string{
+				"Spotify monthly 9.99 EUR next 2026-04-05 x3 1.00",
+				"Netflix monthly 15.49 EUR next 2026-04-10 x2 0.67",
+			},
+		},
+		{
+			"credits and one-off charges are ignored",
+			append(append(charges("SPOTIFY", "9.99", "2026-01-05", "2026-02-05", "2026-03-05"),
+				charges("SPOTIFY", "-9.99", "2026-03-06")...),
+				charges("AMAZON MKTP", "42.10", "2026-02-17")...),
+			[]string{"Spotify monthly 9.99 EUR next 2026-04-05 x3 1.00"},
+		},
+		{
+			"plans of one merchant with different amounts are kept apart",
+			append(charges("APPLE.COM/BILL", "0.99", "2026-01-01", "2026-02-01", "2026-03-01"),
+				charges("APPLE.COM/BILL", "12.99", "2026-02-15", "2026-03-15")...),
+			[]string{
+				"Apple monthly 0.99 EUR next 2026-04-01 x3 1.00",
+				"Apple monthly 12.99 EUR next 2026-04-15 x2 0.67",
+			},
+		},
+		{
+			"small price change stays in the same cluster",
+			append(charges("DISNEY PLUS", "8.99", "2025-12-12", "2026-01-12"),
+				charges("DISNEY PLUS", "9.99", "2026-02-12", "2026-03-12")...),
+			[]string{"Disney Plus monthly 9.99 EUR next 2026-04-12 x4 1.00"},
+		},
+		{
+			"transaction currency wins over the statement's",
+			[]Transaction{
+				{Date: date("2026-01-03"), Description: "GITHUB", Amount: money.MustParse("4.00"), Currency: "USD"},
+				{Date: date("2026-02-03"), Description: "GITHUB", Amount: money.MustParse("4.00"), Currency: "USD"},
+				{Date: date("2026-03-03"), Description: "GITHUB", Amount: money.MustParse("4.00"), Currency: "USD"},
+			},
+			[]string{"Github monthly 4.00 USD next 2026-04-03 x3 1.00"},
+		},
+		{
+			"no recurring charges",
+			charges("CORNER SHOP", "3.50", "2026-01-02", "2026-01-19", "2026-03-11"),
+			[]string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			suggestions := DetectRecurring(&Statement{Currency: "EUR", Transactions: test.transactions}, now)
+			got := []string{}
+			for _, suggestion := range suggestions {
+				got = append(got, describe(suggestion))
+			}
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("DetectRecurring() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestDetectCycle(t *testing.T) {
+	tests := []struct {
+		name         string
+		transactions []Transaction
+		now          string
+		want         string
+		wantOK       bool
+	}{
+		{"monthly", charges("x", "9.99", "2026-01-05", "2026-02-05", "2026-03-05"), "2026-03-20",
+			"Spotify monthly 9.99  next 2026-04-05 x3 1.00", true},
+		{"monthly with two charges is less certain", charges("x", "9.99", "2026-02-05", "2026-03-05"), "2026-03-20",
+			"Spotify monthly 9.99  next 2026-04-05 x2 0.67", true},
+		{"a missed month still fits", charges("x", "9.99", "2026-01-05", "2026-02-05", "2026-04-05", "2026-05-05"), "2026-05-20",
+			"Spotify monthly 9.99  next 2026-06-05 x4 1.00", true},
+		{"weekly", charges("x", "2.00", "2026-01-01", "2026-01-08", "2026-01-15", "2026-01-22"), "2026-01-30",
+			"Spotify weekly 2.00  next 2026-02-05 x4 1.00", true},
+		{"weekly needs three charges", charges("x", "2.00", "2026-01-01", "2026-01-08"), "2026-01-30", "", false},
+		{"yearly", charges("x", "99.00", "2024-03-01", "2025-03-01"), "2026-01-10",
+			"Spotify yearly 99.00  next 2026-03-01 x2 0.67", true},
+		{"same-day retries count once", charges("x", "9.99", "2026-01-05", "2026-01-05", "2026-02-05", "2026-03-05"), "2026-03-20",
+			"Spotify monthly 9.99  next 2026-04-05 x3 1.00", true},
+		{"irregular gaps", charges("x", "9.99", "2026-01-01", "2026-01-29", "2026-03-20", "2026-04-10"), "2026-04-20", "", false},
+		{"gap fitting no cycle", charges("x", "9.99", "2026-01-01", "2026-03-15"), "2026-04-20", "", false},
+		{"single charge", charges("x", "9.99", "2026-01-05"), "2026-03-20", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			suggestion, ok := detectCycle("spotify", test.transactions, date(test.now))
+			if ok != test.wantOK {
+				t.Fatalf("detectCycle() ok = %v, want %v", ok, test.wantOK)
+			}
+			if ok && describe(suggestion) != test.want {
+				t.Errorf("detectCycle() = %s, want %s", describe(suggestion), test.want)
+			}
+		})
+	}
+}
+
+func TestNextChargeDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		last    string
+		dueType string
+		now     time.Time
+		want    string
+	}{
+		{"monthly clamped to February", "2026-01-31", "monthly", date("2026-02-10"), "2026-02-28"},
+		{"monthly back on the 31st after February", "2026-01-31", "monthly", date("2026-03-01"), "2026-03-31"},
+		{"monthly is after the last charge even when it is in the future", "2026-01-31", "monthly", date("2026-01-01"), "2026-02-28"},
+		{"monthly due today", "2026-01-31", "monthly", date("2026-02-28").Add(15 * time.Hour), "2026-02-28"},
+		{"several cycles after the last charge", "2025-10-15", "monthly", date("2026-02-16"), "2026-03-15"},
+		{"weekly due today", "2026-01-01", "weekly", date("2026-01-15"), "2026-01-15"},
+		{"yearly from a leap day", "2024-02-29", "yearly", date("2025-01-01"), "2025-02-28"},
+		{"yearly back on a leap day", "2024-02-29", "yearly", date("2027-03-01"), "2028-02-29"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := nextChargeDate(date(test.last), test.dueType, test.now)
+			if !got.Equal(date(test.want)) {
+				t.Errorf("nextChargeDate() = %s, want %s", got.Format("2006-01-02"), test.want)
+			}
+		})
+	}
+}
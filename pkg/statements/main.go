@@ -0,0 +1,11 @@
+package statements
+
+import (
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/utils"
+)
+
+func RegisterRoutes(app *application.App) {
+	app.Echo.POST("/v1/statements/analyze", AnalyzeStatementHandler, utils.AuthMiddleware)
+	app.Echo.POST("/v1/statements/accept", AcceptSuggestionsHandler, utils.AuthMiddleware)
+}
@@ -0,0 +1,112 @@
+package statements
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"subscritracker/pkg/money"
+)
+
+var (
+	ofxTransactionStart = regexp.MustCompile(`(?i)<STMTTRN>`)
+	ofxTransactionEnd   = regexp.MustCompile(`(?i)</STMTTRN>|</BANKTRANLIST>`)
+	ofxCurrencyRegex    = regexp.MustCompile(`(?i)<CURDEF>\s*([A-Za-z]{3})`)
+)
+
+// isOFX reports whether the data looks like OFX or QFX, in either the SGML (v1) or XML (v2) flavour
+func isOFX(data []byte) bool {
+	head := strings.ToUpper(string(data[:min(len(data), 4096)]))
+	return strings.Contains(head, "OFXHEADER") || strings.Contains(head, "<OFX>")
+}
+
+// ParseOFX reads the STMTTRN blocks of an OFX or QFX file. SGML files leave most tags unclosed,
+// so a tag's value is the text up to the next tag.
+func ParseOFX(data []byte) (*Statement, error) {
+	text := string(data)
+	statement := &Statement{Format: "ofx", Transactions: []Transaction{}}
+
+	if match := ofxCurrencyRegex.FindStringSubmatch(text); match != nil {
+		statement.Currency = strings.ToUpper(match[1])
+	}
+
+	// Each block runs to its closing tag, or to the next <STMTTRN> when closing tags are missing
+	for _, block := range ofxTransactionStart.Split(text, -1)[1:] {
+		if end := ofxTransactionEnd.FindStringIndex(block); end != nil {
+			block = block[:end[0]]
+		}
+		fields := ofxFields(block)
+
+		date, err := parseOFXDate(fields["DTPOSTED"])
+		if err != nil {
+			return nil, err
+		}
+		amount, err := money.ParseAmount(fields["TRNAMT"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid TRNAMT %q: %w", fields["TRNAMT"], err)
+		}
+
+		description := fields["NAME"]
+		if description == "" {
+			description = fields["MEMO"]
+		}
+		currency := statement.Currency
+		if fields["CURRENCY"] != "" {
+			currency = strings.ToUpper(fields["CURRENCY"])
+		}
+
+		// OFX debits are negative; store charges as positive amounts
+		statement.Transactions = append(statement.Transactions, Transaction{
+			Date:        date,
+			Description: strings.TrimSpace(description),
+			Amount:      -amount,
+			Currency:    currency,
+		})
+	}
+
+	if len(statement.Transactions) == 0 {
+		return nil, ErrNoTransactions
+	}
+
+	return statement, nil
+}
+
+// ofxFields collects the leaf tags of a block, e.g. <TRNAMT>-9.99
+func ofxFields(block string) map[string]string {
+	fields := map[string]string{}
+	for _, part := range strings.Split(block, "<")[1:] {
+		end := strings.Index(part, ">")
+		if end <= 0 || part[0] == '/' {
+			continue
+		}
+		name := strings.ToUpper(strings.TrimSpace(part[:end]))
+		value := strings.TrimSpace(part[end+1:])
+		if value == "" {
+			continue
+		}
+		if _, ok := fields[name]; !ok {
+			fields[name] = unescapeOFX(value)
+		}
+	}
+	return fields
+}
+
+// parseOFXDate parses YYYYMMDD[HHMMSS[.XXX]][TZ] and keeps only the date
+func parseOFXDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 8 {
+		return time.Time{}, errors.New("transaction is missing DTPOSTED")
+	}
+	date, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid DTPOSTED %q", value)
+	}
+	return date, nil
+}
+
+func unescapeOFX(value string) string {
+	replacer := strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'")
+	return replacer.Replace(value)
+}
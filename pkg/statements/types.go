@@ -0,0 +1,81 @@
+package statements
+
+import (
+	"errors"
+	"time"
+
+	"subscritracker/pkg/money"
+)
+
+// MinSuggestionConfidence hides clusters that repeat too irregularly to be a subscription
+const MinSuggestionConfidence = 0.5
+
+// amountTolerance is how far, as a fraction, a charge may differ from its cluster and still count as
+// the same subscription, so small price changes and currency conversion noise do not split a merchant
+const amountTolerance = 0.25
+
+var (
+	ErrUnknownFormat  = errors.New("could not detect the statement format, pass format=ofx or format=csv")
+	ErrNoTransactions = errors.New("statement has no transactions")
+)
+
+// Transaction is one charge or credit read from a statement. Charges have a positive Amount.
+type Transaction struct {
+	Date        time.Time    `json:"date"`
+	Description string       `json:"description"`
+	Amount      money.Amount `json:"amount"`
+	Currency    string       `json:"currency,omitempty"`
+}
+
+// Statement is the parsed content of an uploaded file
+type Statement struct {
+	Format       string
+	Currency     string
+	Transactions []Transaction
+}
+
+type cycle struct {
+	dueType string
+	days    float64
+	minDays int
+	maxDays int
+	minHits int
+}
+
+// Billing cycles that can be detected, matching the subscription_details due types
+var cycles = []cycle{
+	{dueType: "weekly", days: 7, minDays: 6, maxDays: 8, minHits: 3},
+	{dueType: "monthly", days: 30.44, minDays: 26, maxDays: 35, minHits: 2},
+	{dueType: "yearly", days: 365.25, minDays: 350, maxDays: 380, minHits: 2},
+}
+
+type SuggestedChannel struct {
+	ID          int     `json:"id"`
+	ChannelName string  `json:"channel_name"`
+	Score       float64 `json:"score"`
+}
+
+// Suggestion is a recurring charge found in the statement, shaped so it can be accepted as subscription details
+type Suggestion struct {
+	Merchant              string            `json:"merchant"`
+	MatchedChannel        *SuggestedChannel `json:"matched_channel"`
+	AlreadySubscribed     bool              `json:"already_subscribed"`
+	SubscriptionChannelID *int              `json:"subscription_channel_id"`
+	MonthlyBill           money.Amount      `json:"monthly_bill"`
+	Currency              string            `json:"currency"`
+	DueType               string            `json:"due_type"`
+	StartDate             string            `json:"start_date"`
+	NextDueDate           string            `json:"next_due_date"`
+	Occurrences           int               `json:"occurrences"`
+	LastChargeDate        string            `json:"last_charge_date"`
+	Confidence            float64           `json:"confidence"`
+	Transactions          []Transaction     `json:"transactions"`
+}
+
+type AnalysisResult struct {
+	Format           string       `json:"format"`
+	TransactionCount int          `json:"transaction_count"`
+	PeriodStart      string       `json:"period_start"`
+	PeriodEnd        string       `json:"period_end"`
+	Suggestions      []Suggestion `json:"suggestions"`
+}
@@ -0,0 +1,118 @@
+package stream
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// StreamHandler pushes the user's changes as server-sent events until the client disconnects.
+// A reconnecting client resumes after the id in its Last-Event-ID header (or the last_event_id
+// query parameter); without one the stream starts with events created from now on.
+func StreamHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+	ctx := c.Request().Context()
+
+	if hub == nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "Event stream is not available"})
+	}
+
+	lastEventID := c.Request().Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.QueryParam("last_event_id")
+	}
+
+	wake, unsubscribe := hub.subscribe(accountID)
+	defer unsubscribe()
+
+	// The position is read after subscribing so no event falls between the two
+	var current *cursor
+	resync := false
+	if lastEventID != "" {
+		lastID, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || lastID < 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Last-Event-ID must be a non-negative integer"})
+		}
+		resync, err = NeedsResync(ctx, app.Database, lastID)
+		if err != nil {
+			log.Println("Error checking stream resume position:", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to open event stream"})
+		}
+		current = newCursor(lastID)
+	}
+	if current == nil || resync {
+		latestID, err := LatestEventID(ctx, app.Database)
+		if err != nil {
+			log.Println("Error getting latest stream event:", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to open event stream"})
+		}
+		current = newCursor(latestID)
+
+		// Recent events are already part of the state the client loads now
+		recent, err := current.fetch(ctx, app.Database, accountID, time.Now())
+		if err != nil {
+			log.Println("Error getting recent stream events:", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to open event stream"})
+		}
+		for _, event := range recent {
+			current.markSent(event)
+		}
+	}
+
+	response := c.Response()
+	response.Header().Set(echo.HeaderContentType, "text/event-stream")
+	response.Header().Set(echo.HeaderCacheControl, "no-cache")
+	response.Header().Set(echo.HeaderConnection, "keep-alive")
+	response.Header().Set("X-Accel-Buffering", "no")
+	response.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(response, "retry: %d\n\n", retryAfter.Milliseconds()); err != nil {
+		return nil
+	}
+	if resync {
+		if err := writeEvent(response, models.Stream_Event{ID: current.lastID, Type: EventResync, Payload: map[string]interface{}{}, CreatedAt: time.Now()}); err != nil {
+			return nil
+		}
+	}
+	response.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		events, err := current.fetch(ctx, app.Database, accountID, time.Now())
+		if err != nil && ctx.Err() == nil {
+			log.Println("Error getting stream events:", err)
+		}
+		for _, event := range events {
+			if err := writeEvent(response, event); err != nil {
+				return nil
+			}
+			current.markSent(event)
+		}
+		if len(events) > 0 {
+			// Fetch again straight away in case the batch was full
+			response.Flush()
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-wake:
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(response, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+			response.Flush()
+		}
+	}
+}
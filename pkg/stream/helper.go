@@ -0,0 +1,223 @@
+package stream
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"time"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/models"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+// hub is the Hub of this instance, set by StartHub
+var hub *Hub
+
+// Publish records a stream event for the account and announces it to every API instance.
+// Run inside a transaction it is only announced, and only kept, if the transaction commits.
+func Publish(ctx context.Context, db bun.IDB, accountID int, eventType string, payload map[string]interface{}) error {
+	if payload == nil {
+		payload = map[string]interface{}{}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.NewRaw(`
+		WITH event AS (
+			INSERT INTO stream_events (account_id, type, payload, created_at)
+			VALUES (?, ?, ?::jsonb, ?)
+			RETURNING account_id
+		)
+		SELECT pg_notify(?, account_id::text) FROM event
+	`, accountID, eventType, string(data), time.Now(), notifyChannel).Exec(ctx)
+	return err
+}
+
+// StartHub listens for stream events announced by any instance and wakes the matching local
+// connections. It also prunes events past the retention period every hour.
+func StartHub(ctx context.Context, app *application.App) {
+	hub = &Hub{subscribers: map[int]map[chan struct{}]struct{}{}}
+
+	// The listener reconnects and listens again on its own when the connection drops;
+	// connections poll on every heartbeat, so nothing is lost in between
+	listener := pgdriver.NewListener(app.Database)
+	if err := listener.Listen(ctx, notifyChannel); err != nil {
+		log.Printf("Error listening for stream events: %v", err)
+	}
+	announcements := listener.CreateChannel()
+
+	go func() {
+		defer listener.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case announcement, ok := <-announcements:
+				if !ok {
+					return
+				}
+				accountID, err := strconv.Atoi(announcement.Payload)
+				if err != nil {
+					log.Printf("Invalid stream event announcement %q", announcement.Payload)
+					continue
+				}
+				hub.wake(accountID)
+			}
+		}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			pruned, err := PruneEvents(ctx, app.Database, time.Now().Add(-retention))
+			if err != nil {
+				log.Printf("Error pruning stream events: %v", err)
+			} else if pruned > 0 {
+				log.Printf("Pruned %d stream events", pruned)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// PruneEvents deletes stream events created before the cutoff
+func PruneEvents(ctx context.Context, db bun.IDB, before time.Time) (int64, error) {
+	result, err := db.NewDelete().
+		Model((*models.Stream_Event)(nil)).
+		Where("created_at < ?", before).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// subscribe registers a connection of the account. The returned channel receives a signal
+// when the account has new events; call the returned function when the connection closes.
+func (h *Hub) subscribe(accountID int) (<-chan struct{}, func()) {
+	wake := make(chan struct{}, 1)
+
+	h.mu.Lock()
+	if h.subscribers[accountID] == nil {
+		h.subscribers[accountID] = map[chan struct{}]struct{}{}
+	}
+	h.subscribers[accountID][wake] = struct{}{}
+	h.mu.Unlock()
+
+	return wake, func() {
+		h.mu.Lock()
+		delete(h.subscribers[accountID], wake)
+		if len(h.subscribers[accountID]) == 0 {
+			delete(h.subscribers, accountID)
+		}
+		h.mu.Unlock()
+	}
+}
+
+// wake signals every connection of the account. A connection that has not picked up
+// its previous signal yet will fetch the new events along with it.
+func (h *Hub) wake(accountID int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for wake := range h.subscribers[accountID] {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// LatestEventID returns the id of the newest stream event, or 0 when there is none
+func LatestEventID(ctx context.Context, db bun.IDB) (int64, error) {
+	var id sql.NullInt64
+	err := db.NewRaw("SELECT MAX(id) FROM stream_events").Scan(ctx, &id)
+	return id.Int64, err
+}
+
+// NeedsResync reports whether events after lastID may have been pruned, so resuming from it
+// could silently miss changes
+func NeedsResync(ctx context.Context, db bun.IDB, lastID int64) (bool, error) {
+	var oldest sql.NullInt64
+	if err := db.NewRaw("SELECT MIN(id) FROM stream_events").Scan(ctx, &oldest); err != nil {
+		return false, err
+	}
+	if !oldest.Valid {
+		latest, err := LatestEventID(ctx, db)
+		return latest == 0 && lastID > 0, err
+	}
+	return lastID+1 < oldest.Int64, nil
+}
+
+func newCursor(lastID int64) *cursor {
+	return &cursor{lastID: lastID, sent: map[int64]time.Time{}}
+}
+
+// fetch returns the account's events the connection has not sent yet, oldest first
+func (c *cursor) fetch(ctx context.Context, db bun.IDB, accountID int, now time.Time) ([]models.Stream_Event, error) {
+	for id, createdAt := range c.sent {
+		if createdAt.Before(now.Add(-2 * lateCommitWindow)) {
+			delete(c.sent, id)
+		}
+	}
+
+	events := []models.Stream_Event{}
+	err := db.NewSelect().
+		Model(&events).
+		Where("account_id = ?", accountID).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("id > ?", c.lastID).
+				WhereOr("created_at > ?", now.Add(-lateCommitWindow))
+		}).
+		Order("id ASC").
+		Limit(fetchBatchSize).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	unsent := events[:0]
+	for _, event := range events {
+		if _, ok := c.sent[event.ID]; ok {
+			continue
+		}
+		unsent = append(unsent, event)
+	}
+	return unsent, nil
+}
+
+// markSent records an event written to the connection
+func (c *cursor) markSent(event models.Stream_Event) {
+	c.sent[event.ID] = event.CreatedAt
+	if event.ID > c.lastID {
+		c.lastID = event.ID
+	}
+}
+
+// writeEvent writes an event in the text/event-stream format. Its id is what the browser
+// sends back in Last-Event-ID when it reconnects.
+func writeEvent(w io.Writer, event models.Stream_Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -0,0 +1,10 @@
+package stream
+
+import (
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/utils"
+)
+
+func RegisterRoutes(app *application.App) {
+	app.Echo.GET("/v1/stream", StreamHandler, utils.AuthMiddleware)
+}
@@ -0,0 +1,53 @@
+package stream
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types pushed on the stream. Clients refetch what the event points at, so payloads
+// carry ids and the fields that changed rather than whole records.
+const (
+	EventSubscriptionCreated         = "subscription.created"
+	EventSubscriptionUpdated         = "subscription.updated"
+	EventSubscriptionPriceChanged    = "subscription.price_changed"
+	EventSubscriptionDueDateAdvanced = "subscription.due_date_advanced"
+	EventReminderScheduled           = "reminder.scheduled"
+	EventNotificationCreated         = "notification.created"
+	EventNotificationRead            = "notification.read"
+	EventPaymentRecorded             = "payment.recorded"
+	EventPaymentVoided               = "payment.voided"
+
+	// EventResync tells a resuming client that events it missed have expired, so it should reload everything
+	EventResync = "resync"
+)
+
+const (
+	// notifyChannel is the Postgres channel new stream events are announced on
+	notifyChannel = "stream_events"
+	// heartbeatInterval keeps idle connections open through proxies and catches missed wake-ups
+	heartbeatInterval = 25 * time.Second
+	// retryAfter is how long browsers wait before reconnecting a dropped stream
+	retryAfter = 5 * time.Second
+	// lateCommitWindow is how far back a connection looks for events whose transaction committed
+	// after an event with a higher id was already sent
+	lateCommitWindow = 30 * time.Second
+	// retention is how long events are kept for clients resuming with Last-Event-ID
+	retention = 24 * time.Hour
+	// fetchBatchSize caps the events sent per wake-up; the rest follow on the next fetch
+	fetchBatchSize = 500
+)
+
+// Hub wakes the stream connections of an account on this instance when a stream event is
+// announced for it on any instance
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[int]map[chan struct{}]struct{}
+}
+
+// cursor tracks what a stream connection has sent. Ids come from a sequence and can commit out
+// of order, so events in the late commit window are checked against the ids already sent.
+type cursor struct {
+	lastID int64
+	sent   map[int64]time.Time
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/uptrace/bun"
 )
 
 func GetChannelById(c echo.Context, id string) (*models.Subscription_Channels, error) {
@@ -72,3 +73,30 @@ func CreateChannel(c echo.Context, channel models.Subscription_Channels) (*model
 
 	return &channel, nil
 }
+
+// GetChannelsBySubscription returns the channel of each of the subscriptions, by channel id
+func GetChannelsBySubscription(ctx context.Context, db bun.IDB, subscriptions []models.Subscription_Details) (map[int]models.Subscription_Channels, error) {
+	channelsByID := map[int]models.Subscription_Channels{}
+	if len(subscriptions) == 0 {
+		return channelsByID, nil
+	}
+
+	channelIDs := make([]int, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		channelIDs = append(channelIDs, subscription.SubscriptionChannelID)
+	}
+
+	channels := []models.Subscription_Channels{}
+	err := db.NewSelect().
+		Model(&channels).
+		Where("id IN (?)", bun.In(channelIDs)).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, channel := range channels {
+		channelsByID[channel.ID] = channel
+	}
+	return channelsByID, nil
+}
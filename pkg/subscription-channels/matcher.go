@@ -0,0 +1,187 @@
+package subscription_channels
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"subscritracker/pkg/models"
+)
+
+// MinChannelMatchScore is the lowest similarity accepted as a fuzzy channel match
+const MinChannelMatchScore = 0.8
+
+var (
+	ErrNoChannelMatch        = errors.New("no subscription channel matches")
+	ErrAmbiguousChannelMatch = errors.New("several subscription channels match")
+)
+
+type ChannelMatch struct {
+	Channel *models.Subscription_Channels
+	Score   float64
+}
+
+// ChannelMatcher finds channels by name or URL, tolerating case, punctuation, "www." and small typos
+type ChannelMatcher struct {
+	channels []*models.Subscription_Channels
+	names    []string
+	hosts    []string
+}
+
+func NewChannelMatcher(channels []*models.Subscription_Channels) *ChannelMatcher {
+	matcher := &ChannelMatcher{channels: channels}
+	for _, channel := range channels {
+		matcher.names = append(matcher.names, normalizeChannelName(channel.ChannelName))
+		matcher.hosts = append(matcher.hosts, normalizeHost(channel.ChannelURL))
+	}
+	return matcher
+}
+
+// Match returns the channel that best matches a name or URL. An exact match always wins,
+// otherwise the best fuzzy match is used if it is unique and scores at least MinChannelMatchScore.
+func (m *ChannelMatcher) Match(input string) (ChannelMatch, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return ChannelMatch{}, ErrNoChannelMatch
+	}
+
+	inputName := normalizeChannelName(input)
+	inputHost := ""
+	if looksLikeURL(input) {
+		inputHost = normalizeHost(input)
+	}
+
+	best := ChannelMatch{}
+	tied := []string{}
+	for i, channel := range m.channels {
+		score := m.score(i, inputName, inputHost)
+		switch {
+		case score > best.Score:
+			best = ChannelMatch{Channel: channel, Score: score}
+			tied = []string{channel.ChannelName}
+		case score == best.Score && score > 0:
+			tied = append(tied, channel.ChannelName)
+		}
+	}
+
+	if best.Channel == nil || best.Score < MinChannelMatchScore {
+		return ChannelMatch{}, fmt.Errorf("%w %q", ErrNoChannelMatch, input)
+	}
+	if len(tied) > 1 {
+		return ChannelMatch{}, fmt.Errorf("%w %q: %s", ErrAmbiguousChannelMatch, input, strings.Join(tied, ", "))
+	}
+
+	return best, nil
+}
+
+func (m *ChannelMatcher) score(index int, inputName, inputHost string) float64 {
+	name := m.names[index]
+	host := m.hosts[index]
+
+	if inputHost != "" {
+		if inputHost == host {
+			return 1
+		}
+		// Fall back to comparing the host without its top level domain, e.g. "netflix"
+		inputName = normalizeChannelName(hostLabel(inputHost))
+	}
+	if inputName == "" {
+		return 0
+	}
+	if inputName == name {
+		return 1
+	}
+
+	score := similarity(inputName, name)
+	if host != "" {
+		if label := normalizeChannelName(hostLabel(host)); label != "" {
+			if inputName == label {
+				return 0.95
+			}
+			score = max(score, similarity(inputName, label))
+		}
+	}
+	// "Disney+" typed as "Disney Plus Premium" or just "Disney" still names the channel
+	if len(inputName) >= 4 && len(name) >= 4 && (strings.Contains(name, inputName) || strings.Contains(inputName, name)) {
+		score = max(score, 0.85)
+	}
+
+	return score
+}
+
+// normalizeChannelName lowercases and keeps only letters and digits
+func normalizeChannelName(name string) string {
+	var builder strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+func looksLikeURL(value string) bool {
+	if strings.Contains(value, "://") {
+		return true
+	}
+	return !strings.ContainsAny(value, " \t") && strings.Contains(value, ".")
+}
+
+// normalizeHost reduces a URL to its lowercase host without "www." or a port
+func normalizeHost(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return ""
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
+
+// hostLabel returns the part of a host before its top level domain, e.g. "netflix" for "netflix.com"
+func hostLabel(host string) string {
+	parts := strings.Split(host, ".")
+	if len(parts) < 2 {
+		return host
+	}
+	return parts[len(parts)-2]
+}
+
+// similarity is 1 minus the Levenshtein distance relative to the longer string
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
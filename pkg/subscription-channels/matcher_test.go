@@ -0,0 +1,59 @@
+package subscription_channels
+
+import (
+	"errors"
+	"testing"
+
+	"subscritracker/pkg/models"
+)
+
+func TestChannelMatcher(t *testing.T) {
+	matcher := NewChannelMatcher([]*models.Subscription_Channels{
+		{ChannelName: "Netflix", ChannelURL: "https://www.netflix.com"},
+		{ChannelName: "Disney+", ChannelURL: "https://www.disneyplus.com"},
+		{ChannelName: "Spotify", ChannelURL: "https://open.spotify.com"},
+		{ChannelName: "Amazon Music", ChannelURL: "https://music.amazon.com"},
+		{ChannelName: "Apple TV"},
+		{ChannelName: "Apple Music"},
+	})
+
+	tests := []struct {
+		name        string
+		input       string
+		wantChannel string
+		wantErr     error
+	}{
+		{"exact name", "Netflix", "Netflix", nil},
+		{"case, spaces and punctuation are ignored", "  NETFLIX! ", "Netflix", nil},
+		{"URL of the channel", "https://www.netflix.com/browse", "Netflix", nil},
+		{"host without a scheme", "netflix.com", "Netflix", nil},
+		{"host on another subdomain", "spotify.com", "Spotify", nil},
+		{"name of the host", "Disney Plus", "Disney+", nil},
+		{"small typo", "Netflx", "Netflix", nil},
+		{"typo in a longer name", "Spotfy", "Spotify", nil},
+		{"part of a longer name", "Apple TV 4K", "Apple TV", nil},
+		{"label of a subdomain host", "amazon", "Amazon Music", nil},
+		{"part of several names", "apple", "", ErrAmbiguousChannelMatch},
+		{"unknown channel", "Hulu", "", ErrNoChannelMatch},
+		{"unknown host", "https://www.hulu.com", "", ErrNoChannelMatch},
+		{"empty input", "   ", "", ErrNoChannelMatch},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			match, err := matcher.Match(test.input)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("Match(%q) error = %v, want %v", test.input, err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if match.Channel.ChannelName != test.wantChannel {
+				t.Errorf("Match(%q) = %s, want %s", test.input, match.Channel.ChannelName, test.wantChannel)
+			}
+			if match.Score < MinChannelMatchScore || match.Score > 1 {
+				t.Errorf("Match(%q) score = %v, want between %v and 1", test.input, match.Score, MinChannelMatchScore)
+			}
+		})
+	}
+}
@@ -1,12 +1,14 @@
 package subscriptiondetails
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
+	"subscritracker/pkg/account"
 	"subscritracker/pkg/application"
-	"subscritracker/pkg/models"
 	subscription_channels "subscritracker/pkg/subscription-channels"
+	"subscritracker/pkg/utils"
 	"subscritracker/pkg/validator"
 
 	"github.com/labstack/echo/v4"
@@ -27,6 +29,18 @@ func PostSubscriptionDetailsHandler(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get subscription channel: " + err.Error()})
 	}
 
+	// Check the category belongs to the user
+	if request.CategoryID != nil {
+		ownsCategory, err := CategoryBelongsToAccount(app, accountID, *request.CategoryID)
+		if err != nil {
+			log.Println("Error checking category:", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to check category"})
+		}
+		if !ownsCategory {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "category_id cannot be found"})
+		}
+	}
+
 	// Check if user already has a subscription to this channel
 	existingSubscription, err := CheckExistingSubscriptionByChannel(app, accountID, subscriptionChannel.ID)
 	if err != nil {
@@ -38,49 +52,18 @@ func PostSubscriptionDetailsHandler(c echo.Context) error {
 		return c.JSON(http.StatusConflict, map[string]string{"error": "You already have a subscription to this channel"})
 	}
 
-	subscriptionDetails := models.Subscription_Details{
-		AccountID:             accountID,
-		SubscriptionChannelID: request.SubscriptionChannelID,
-		Status:                request.Status,
-		MonthlyBill:           request.MonthlyBill,
-		DueType:               request.DueType,
-		DueDayOfMonth:         request.DueDayOfMonth,
-		// Initialize optional time fields to nil explicitly
-		EndDate:      nil,
-		StartTime:    nil,
-		DueTime:      nil,
-		ReminderDate: nil,
-		ReminderTime: nil,
-	}
-
-	// Handle optional time fields - only set if they exist
-	if request.StartDate != nil {
-		subscriptionDetails.StartDate = *request.StartDate
-	}
-	if request.NextDueDate != nil {
-		subscriptionDetails.NextDueDate = *request.NextDueDate
-	}
-	if request.EndDate != nil {
-		subscriptionDetails.EndDate = request.EndDate
-	}
-	if request.StartTime != nil {
-		subscriptionDetails.StartTime = request.StartTime
-	}
-	if request.DueTime != nil {
-		subscriptionDetails.DueTime = request.DueTime
-	}
-	if request.ReminderDate != nil {
-		subscriptionDetails.ReminderDate = request.ReminderDate
-	}
-	if request.ReminderTime != nil {
-		subscriptionDetails.ReminderTime = request.ReminderTime
+	// Amounts without a currency are in the account's default currency
+	currency := request.Currency
+	if currency == "" {
+		accountDetails, err := account.GetAccountById(app, accountID)
+		if err != nil {
+			log.Println("Error getting account for default currency:", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get account"})
+		}
+		currency = accountDetails.DefaultCurrency
 	}
 
-	// Calculate NextDueDate only if it's not provided in the request
-	// Note: StartDate must be set before calling CalculateNextDueDate
-	if request.NextDueDate == nil {
-		subscriptionDetails.NextDueDate = CalculateNextDueDate(subscriptionDetails.DueType, subscriptionDetails.DueDayOfMonth, subscriptionDetails.StartDate)
-	}
+	subscriptionDetails := BuildSubscriptionDetails(accountID, request, currency)
 
 	createdSubscriptionDetails, err := CreateSubscriptionDetails(c, subscriptionDetails)
 	if err != nil {
@@ -90,6 +73,38 @@ func PostSubscriptionDetailsHandler(c echo.Context) error {
 	return c.JSON(http.StatusCreated, createdSubscriptionDetails)
 }
 
+// UpdateSubscriptionStatusHandler pauses, resumes or cancels one of the user's subscriptions
+func UpdateSubscriptionStatusHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	subscriptionDetailsID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid subscription details ID"})
+	}
+
+	status, err := validator.ValidateSubscriptionStatusRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	subscriptionDetails, err := GetOwnedSubscriptionDetails(app, accountID, subscriptionDetailsID)
+	if err != nil {
+		if errors.Is(err, ErrSubscriptionDetailsNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error getting subscription details:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get subscription details"})
+	}
+
+	if err := UpdateSubscriptionStatus(utils.RequestContext(c), app, &subscriptionDetails, status); err != nil {
+		log.Println("Error updating subscription status:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update subscription status"})
+	}
+
+	return c.JSON(http.StatusOK, subscriptionDetails)
+}
+
 func GetSubscriptionDetailsHandler(c echo.Context) error {
 	return nil
 }
@@ -111,3 +126,66 @@ func GetUserSubscriptionDetailsHandler(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, subscriptionDetails)
 }
+
+// GetTrialsEndingSoonHandler lists the user's subscriptions whose free trial ends within `days` (default 7)
+// so they can cancel before the first real charge
+func GetTrialsEndingSoonHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	days := DefaultTrialEndingSoonDays
+	if daysStr := c.QueryParam("days"); daysStr != "" {
+		parsedDays, err := strconv.Atoi(daysStr)
+		if err != nil || parsedDays < 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "days must be a non-negative integer"})
+		}
+		days = parsedDays
+	}
+
+	trials, err := GetTrialsEndingSoon(app, accountID, days)
+	if err != nil {
+		log.Println("Error getting trials ending soon:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get trials ending soon"})
+	}
+
+	return c.JSON(http.StatusOK, trials)
+}
+
+// ImportSubscriptionDetailsHandler creates subscriptions from a CSV upload. With dry_run=true nothing
+// is stored and the response shows how each row would be matched and which rows have errors.
+func ImportSubscriptionDetailsHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	request, err := validator.ValidateImportRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	rows, err := ParseImportCSV(request.Data)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	channels, err := subscription_channels.GetAllChannels(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get subscription channels"})
+	}
+
+	accountDetails, err := account.GetAccountById(app, accountID)
+	if err != nil {
+		log.Println("Error getting account for default currency:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get account"})
+	}
+
+	result, err := ImportSubscriptionDetails(utils.RequestContext(c), app, accountID, accountDetails.DefaultCurrency, rows, channels, request.DryRun)
+	if err != nil {
+		log.Println("Error importing subscription details:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to import subscription details"})
+	}
+
+	if result.Imported > 0 {
+		return c.JSON(http.StatusCreated, result)
+	}
+	return c.JSON(http.StatusOK, result)
+}
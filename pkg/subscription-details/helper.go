@@ -2,6 +2,7 @@ package subscriptiondetails
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"log"
 	"strings"
@@ -9,22 +10,82 @@ import (
 
 	"subscritracker/pkg/application"
 	"subscritracker/pkg/models"
-
+	pricehistory "subscritracker/pkg/price-history"
+	"subscritracker/pkg/recurrence"
+	"subscritracker/pkg/stream"
+	subscriptionevents "subscritracker/pkg/subscription-events"
+	subscriptionversions "subscritracker/pkg/subscription-versions"
+	"subscritracker/pkg/utils"
 	"subscritracker/pkg/validator"
 
 	"github.com/labstack/echo/v4"
+	"github.com/uptrace/bun"
 )
 
+// BuildSubscriptionDetails turns a validated request into a subscription for the account,
+// calculating the next due date when the request does not set one
+func BuildSubscriptionDetails(accountID int, request *validator.ParsedSubscriptionDetails, currency string) models.Subscription_Details {
+	subscriptionDetails := models.Subscription_Details{
+		AccountID:             accountID,
+		SubscriptionChannelID: request.SubscriptionChannelID,
+		CategoryID:            request.CategoryID,
+		Status:                request.Status,
+		MonthlyBill:           request.MonthlyBill,
+		Currency:              currency,
+		DueType:               request.DueType,
+		DueDayOfMonth:         request.DueDayOfMonth,
+		TrialEndDate:          request.TrialEndDate,
+		IntroPrice:            request.IntroPrice,
+		IntroPriceUntil:       request.IntroPriceUntil,
+		Notes:                 request.Notes,
+		// Initialize optional time fields to nil explicitly
+		EndDate:      nil,
+		StartTime:    nil,
+		DueTime:      nil,
+		ReminderDate: nil,
+		ReminderTime: nil,
+	}
+
+	// Handle optional time fields - only set if they exist
+	if request.StartDate != nil {
+		subscriptionDetails.StartDate = *request.StartDate
+	}
+	if request.NextDueDate != nil {
+		subscriptionDetails.NextDueDate = *request.NextDueDate
+	}
+	if request.EndDate != nil {
+		subscriptionDetails.EndDate = request.EndDate
+	}
+	if request.StartTime != nil {
+		subscriptionDetails.StartTime = request.StartTime
+	}
+	if request.DueTime != nil {
+		subscriptionDetails.DueTime = request.DueTime
+	}
+	if request.ReminderDate != nil {
+		subscriptionDetails.ReminderDate = request.ReminderDate
+	}
+	if request.ReminderTime != nil {
+		subscriptionDetails.ReminderTime = request.ReminderTime
+	}
+	subscriptionDetails.ReminderDaysBefore = request.ReminderDaysBefore
+
+	// Calculate NextDueDate only if it's not provided in the request
+	// Note: StartDate must be set before calling CalculateNextDueDate
+	if request.NextDueDate == nil {
+		subscriptionDetails.NextDueDate = CalculateNextDueDate(subscriptionDetails.DueType, subscriptionDetails.DueDayOfMonth, subscriptionDetails.StartDate, subscriptionDetails.TrialEndDate)
+	}
+
+	return subscriptionDetails
+}
+
 func CreateSubscriptionDetails(c echo.Context, subscriptionDetails models.Subscription_Details) (models.Subscription_Details, error) {
 	app := c.Get("app").(*application.App)
 
-	// Set timestamps
-	subscriptionDetails.CreatedAt = time.Now()
-	subscriptionDetails.UpdatedAt = time.Now()
-
-	_, err := app.Database.NewInsert().
-		Model(&subscriptionDetails).
-		Exec(context.Background())
+	// Store the subscription and its starting price together
+	err := app.Database.RunInTx(utils.RequestContext(c), nil, func(ctx context.Context, tx bun.Tx) error {
+		return InsertSubscriptionDetails(ctx, tx, &subscriptionDetails)
+	})
 	if err != nil {
 		log.Println("Error creating subscription details:", err)
 		return models.Subscription_Details{}, err
@@ -33,6 +94,48 @@ func CreateSubscriptionDetails(c echo.Context, subscriptionDetails models.Subscr
 	return subscriptionDetails, nil
 }
 
+// InsertSubscriptionDetails inserts a subscription and records its initial price. Run it inside a transaction.
+func InsertSubscriptionDetails(ctx context.Context, db bun.IDB, subscriptionDetails *models.Subscription_Details) error {
+	subscriptionDetails.CreatedAt = time.Now()
+	subscriptionDetails.UpdatedAt = time.Now()
+
+	_, err := db.NewInsert().
+		Model(subscriptionDetails).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := pricehistory.RecordInitialPrice(ctx, db, *subscriptionDetails); err != nil {
+		return err
+	}
+
+	if err := subscriptionversions.Record(ctx, db, nil, *subscriptionDetails); err != nil {
+		return err
+	}
+
+	err = subscriptionevents.Record(ctx, db, &models.Subscription_Event{
+		SubscriptionDetailsID: subscriptionDetails.ID,
+		AccountID:             subscriptionDetails.AccountID,
+		Type:                  subscriptionevents.EventCreated,
+		Payload: map[string]interface{}{
+			"status":        subscriptionDetails.Status,
+			"monthly_bill":  subscriptionDetails.MonthlyBill,
+			"currency":      subscriptionDetails.Currency,
+			"due_type":      subscriptionDetails.DueType,
+			"next_due_date": subscriptionDetails.NextDueDate.Format("2006-01-02"),
+		},
+		Actor: subscriptionevents.ActorUser,
+	})
+	if err != nil {
+		return err
+	}
+
+	return stream.Publish(ctx, db, subscriptionDetails.AccountID, stream.EventSubscriptionCreated, map[string]interface{}{
+		"subscription_details_id": subscriptionDetails.ID,
+	})
+}
+
 func GetSubscriptionDetailsByID(c echo.Context, id int) (models.Subscription_Details, error) {
 	app := c.Get("app").(*application.App)
 
@@ -52,6 +155,111 @@ func GetSubscriptionDetailsByID(c echo.Context, id int) (models.Subscription_Det
 	return subscriptionDetails, nil
 }
 
+// UpdateSubscriptionStatus changes the status of a subscription, recording a paused, resumed
+// or cancelled event when the change is one
+func UpdateSubscriptionStatus(ctx context.Context, app *application.App, subscriptionDetails *models.Subscription_Details, status string) error {
+	previousStatus := subscriptionDetails.Status
+	if previousStatus == status {
+		return nil
+	}
+
+	before := *subscriptionDetails
+	return app.Database.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		subscriptionDetails.Status = status
+		subscriptionDetails.UpdatedAt = time.Now()
+		_, err := tx.NewUpdate().
+			Model(subscriptionDetails).
+			Column("status", "updated_at").
+			WherePK().
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if err := subscriptionversions.Record(ctx, tx, &before, *subscriptionDetails); err != nil {
+			return err
+		}
+
+		if eventType := subscriptionevents.StatusChangeEvent(previousStatus, status); eventType != "" {
+			err := subscriptionevents.Record(ctx, tx, &models.Subscription_Event{
+				SubscriptionDetailsID: subscriptionDetails.ID,
+				AccountID:             subscriptionDetails.AccountID,
+				Type:                  eventType,
+				Payload:               map[string]interface{}{"from": previousStatus, "to": status},
+				Actor:                 subscriptionevents.ActorUser,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return stream.Publish(ctx, tx, subscriptionDetails.AccountID, stream.EventSubscriptionUpdated, map[string]interface{}{
+			"subscription_details_id": subscriptionDetails.ID,
+			"fields":                  []string{"status"},
+		})
+	})
+}
+
+// GetOwnedSubscriptionDetails loads a subscription only if it belongs to the account
+func GetOwnedSubscriptionDetails(app *application.App, accountID, id int) (models.Subscription_Details, error) {
+	subscriptionDetails := models.Subscription_Details{}
+	err := app.Database.NewSelect().
+		Model(&subscriptionDetails).
+		Where("id = ? AND account_id = ?", id, accountID).
+		Scan(context.Background())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Subscription_Details{}, ErrSubscriptionDetailsNotFound
+		}
+		return models.Subscription_Details{}, err
+	}
+
+	return subscriptionDetails, nil
+}
+
+// CategoryBelongsToAccount checks that a category exists and is owned by the account
+func CategoryBelongsToAccount(app *application.App, accountID, categoryID int) (bool, error) {
+	return app.Database.NewSelect().
+		Model((*models.Category)(nil)).
+		Where("id = ? AND account_id = ?", categoryID, accountID).
+		Exists(context.Background())
+}
+
+// GetTagsForSubscriptions returns the tags of each subscription, keyed by subscription id
+func GetTagsForSubscriptions(app *application.App, subscriptionDetailsIDs []int) (map[int][]TagSummary, error) {
+	tagsBySubscription := map[int][]TagSummary{}
+	if len(subscriptionDetailsIDs) == 0 {
+		return tagsBySubscription, nil
+	}
+
+	rows := []struct {
+		SubscriptionDetailsID int    `bun:"subscription_details_id"`
+		ID                    int    `bun:"id"`
+		Name                  string `bun:"name"`
+		Color                 string `bun:"color"`
+	}{}
+	err := app.Database.NewRaw(`
+		SELECT sdt.subscription_details_id, t.id, t.name, COALESCE(t.color, '') AS color
+		FROM subscription_details_tags sdt
+		JOIN tags t ON sdt.tag_id = t.id
+		WHERE sdt.subscription_details_id IN (?)
+		ORDER BY t.name ASC
+	`, bun.In(subscriptionDetailsIDs)).Scan(context.Background(), &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		tagsBySubscription[row.SubscriptionDetailsID] = append(tagsBySubscription[row.SubscriptionDetailsID], TagSummary{
+			ID:    row.ID,
+			Name:  row.Name,
+			Color: row.Color,
+		})
+	}
+
+	return tagsBySubscription, nil
+}
+
 func CheckSubscriptionDetailsExists(c echo.Context, id int) (bool, error) {
 	_, err := GetSubscriptionDetailsByID(c, id)
 	if err != nil {
@@ -85,120 +293,273 @@ func CheckExistingSubscriptionByChannel(app *application.App, accountID, channel
 	return true, nil
 }
 
-func GetSubscriptionDetailsByUserIdWithFilters(app *application.App, accountID int, filters *validator.FilterOptions) ([]SubscriptionDetailsByAccountID, error) {
-	var subscriptionDetailsByAccountID []SubscriptionDetailsByAccountID
+// subscriptionSortColumns maps sort_by to a NULL-safe sort expression and the SQL type used to
+// compare it against the cursor, so keyset pagination stays stable for every sort option
+var subscriptionSortColumns = map[string]struct {
+	expression string
+	sqlType    string
+}{
+	"monthly_bill":  {"COALESCE(sd.monthly_bill, 0)", "numeric"},
+	"next_due_date": {"COALESCE(sd.next_due_date, DATE '0001-01-01')", "date"},
+	"start_date":    {"COALESCE(sd.start_date, DATE '0001-01-01')", "date"},
+	"status":        {"COALESCE(sd.status, '')", "text"},
+	"channel_name":  {"sc.channel_name", "text"},
+}
 
-	// Build the base query
-	query := `
-		SELECT 
-			sd.id,
-			sd.account_id,
-			sd.subscription_channel_id,
-			sc.channel_name as subscription_channel_name,
-			sc.channel_image_url,
-			sd.start_date,
-			sd.next_due_date,
-			sd.status,
-			sd.monthly_bill,
-			sd.reminder_date,
-			sd.reminder_time
-		FROM subscription_details sd
-		JOIN subscription_channels sc ON sd.subscription_channel_id = sc.id
-		WHERE sd.account_id = ?
-	`
+// GetSubscriptionDetailsByUserIdWithFilters returns one page of the user's subscriptions.
+// Pages are keyset paginated on (sort column, id) and total_count ignores the cursor.
+func GetSubscriptionDetailsByUserIdWithFilters(app *application.App, accountID int, filters *validator.FilterOptions) (*SubscriptionDetailsPage, error) {
+	subscriptionDetailsByAccountID := []SubscriptionDetailsByAccountID{}
 
-	args := []interface{}{accountID}
+	// Build the filters shared by the page and the count query
+	where := ` WHERE sd.account_id = ?`
+	whereArgs := []interface{}{accountID}
 
 	// Add status filter
 	if filters.Status != "" {
-		query += ` AND sd.status = ?`
-		args = append(args, filters.Status)
+		where += ` AND sd.status = ?`
+		whereArgs = append(whereArgs, filters.Status)
 	}
 
 	// Add cost range filters
 	if filters.MinCost != nil {
-		query += ` AND sd.monthly_bill >= ?`
-		args = append(args, *filters.MinCost)
+		where += ` AND sd.monthly_bill >= ?`
+		whereArgs = append(whereArgs, *filters.MinCost)
 	}
 
 	if filters.MaxCost != nil {
-		query += ` AND sd.monthly_bill <= ?`
-		args = append(args, *filters.MaxCost)
+		where += ` AND sd.monthly_bill <= ?`
+		whereArgs = append(whereArgs, *filters.MaxCost)
 	}
 
 	// Add start date range filters
 	if filters.StartDateFrom != nil {
-		query += ` AND sd.start_date >= ?`
-		args = append(args, *filters.StartDateFrom)
+		where += ` AND sd.start_date >= ?`
+		whereArgs = append(whereArgs, *filters.StartDateFrom)
 	}
 
 	if filters.StartDateTo != nil {
-		query += ` AND sd.start_date <= ?`
-		args = append(args, *filters.StartDateTo)
+		where += ` AND sd.start_date <= ?`
+		whereArgs = append(whereArgs, *filters.StartDateTo)
 	}
 
 	// Add due date range filters
 	if filters.NextDueDateFrom != nil {
-		query += ` AND sd.next_due_date >= ?`
-		args = append(args, *filters.NextDueDateFrom)
+		where += ` AND sd.next_due_date >= ?`
+		whereArgs = append(whereArgs, *filters.NextDueDateFrom)
 	}
 
 	if filters.NextDueDateTo != nil {
-		query += ` AND sd.next_due_date <= ?`
-		args = append(args, *filters.NextDueDateTo)
+		where += ` AND sd.next_due_date <= ?`
+		whereArgs = append(whereArgs, *filters.NextDueDateTo)
 	}
 
-	// Add sorting
-	if filters.SortBy != "" {
-		// Map user-friendly sort field names to actual database column names
-		sortFieldMapping := map[string]string{
-			"monthly_bill":  "sd.monthly_bill",
-			"next_due_date": "sd.next_due_date",
-			"start_date":    "sd.start_date",
-			"status":        "sd.status",
-			"channel_name":  "sc.channel_name",
-		}
+	// Add category filter
+	if filters.CategoryID != nil {
+		where += ` AND sd.category_id = ?`
+		whereArgs = append(whereArgs, *filters.CategoryID)
+	}
+
+	// Add tag filter, matching subscriptions that have any of the tags
+	if len(filters.TagIDs) > 0 {
+		where += ` AND EXISTS (SELECT 1 FROM subscription_details_tags sdt WHERE sdt.subscription_details_id = sd.id AND sdt.tag_id IN (?))`
+		whereArgs = append(whereArgs, bun.In(filters.TagIDs))
+	}
+
+	// Add search over channel name and notes: full-text match on notes, trigram similarity or substring.
+	// Each side is matched against its own indexed expression so the GIN indexes can be used.
+	if filters.Q != "" {
+		like := "%" + escapeLike(filters.Q) + "%"
+		where += ` AND (
+			to_tsvector('simple', COALESCE(sd.notes, '')) @@ plainto_tsquery('simple', ?)
+			OR sc.channel_name % ?
+			OR sc.channel_name ILIKE ?
+			OR sd.notes ILIKE ?
+		)`
+		whereArgs = append(whereArgs, filters.Q, filters.Q, like, like)
+	}
+
+	from := `
+		FROM subscription_details sd
+		JOIN subscription_channels sc ON sd.subscription_channel_id = sc.id
+		LEFT JOIN categories cat ON sd.category_id = cat.id
+	`
+
+	// Count every matching row, independent of the cursor
+	var totalCount int
+	err := app.Database.NewRaw(`SELECT COUNT(*)`+from+where, whereArgs...).Scan(context.Background(), &totalCount)
+	if err != nil {
+		log.Println("Error counting subscription details: because of database error", err)
+		return nil, err
+	}
 
-		sortField := sortFieldMapping[filters.SortBy]
-		query += ` ORDER BY ` + sortField
+	// Sort on the requested column, breaking ties on id so the order is total
+	sortExpression := "sd.id"
+	sortType := "int"
+	if filters.SortBy != "" {
+		sortExpression = subscriptionSortColumns[filters.SortBy].expression
+		sortType = subscriptionSortColumns[filters.SortBy].sqlType
+	}
+	direction, comparison := "ASC", ">"
+	if filters.SortOrder == "desc" {
+		direction, comparison = "DESC", "<"
+	}
 
-		// Add sort order
-		if filters.SortOrder == "desc" {
-			query += ` DESC`
+	query := `
+		SELECT 
+			sd.id,
+			sd.account_id,
+			sd.subscription_channel_id,
+			sc.channel_name as subscription_channel_name,
+			sc.channel_image_url,
+			sd.start_date,
+			sd.next_due_date,
+			sd.status,
+			sd.monthly_bill,
+			sd.currency,
+			sd.trial_end_date,
+			sd.intro_price,
+			sd.intro_price_until,
+			(sd.trial_end_date IS NOT NULL AND sd.trial_end_date BETWEEN CURRENT_DATE AND CURRENT_DATE + ?::int) AS trial_ending_soon,
+			sd.notes,
+			sd.category_id,
+			cat.name AS category_name,
+			sd.reminder_date,
+			sd.reminder_time,
+			sd.reminder_days_before,
+			(` + sortExpression + `)::text AS sort_key
+	` + from + where
+
+	args := append([]interface{}{DefaultTrialEndingSoonDays}, whereArgs...)
+
+	// Continue after the cursor row
+	if filters.ParsedCursor != nil {
+		if filters.SortBy != "" {
+			query += ` AND (` + sortExpression + `, sd.id) ` + comparison + ` (CAST(? AS ` + sortType + `), ?)`
+			args = append(args, filters.ParsedCursor.Key, filters.ParsedCursor.ID)
 		} else {
-			query += ` ASC` // Default to ascending
+			query += ` AND sd.id ` + comparison + ` ?`
+			args = append(args, filters.ParsedCursor.ID)
 		}
 	}
 
-	err := app.Database.NewRaw(query, args...).Scan(context.Background(), &subscriptionDetailsByAccountID)
+	query += ` ORDER BY ` + sortExpression + ` ` + direction
+	if filters.SortBy != "" {
+		query += `, sd.id ` + direction
+	}
+
+	// Fetch one extra row to know whether there is a next page
+	query += ` LIMIT ?`
+	args = append(args, filters.Limit+1)
+
+	err = app.Database.NewRaw(query, args...).Scan(context.Background(), &subscriptionDetailsByAccountID)
 	if err != nil {
 		log.Println("Error getting subscription details: because of database error", err)
 		return nil, err
 	}
 
-	return subscriptionDetailsByAccountID, nil
-}
+	// Attach the tags of every row on the page
+	subscriptionDetailsIDs := make([]int, 0, len(subscriptionDetailsByAccountID))
+	for _, row := range subscriptionDetailsByAccountID {
+		subscriptionDetailsIDs = append(subscriptionDetailsIDs, row.ID)
+	}
+	tagsBySubscription, err := GetTagsForSubscriptions(app, subscriptionDetailsIDs)
+	if err != nil {
+		log.Println("Error getting subscription tags: because of database error", err)
+		return nil, err
+	}
+	for i := range subscriptionDetailsByAccountID {
+		subscriptionDetailsByAccountID[i].Tags = tagsBySubscription[subscriptionDetailsByAccountID[i].ID]
+		if subscriptionDetailsByAccountID[i].Tags == nil {
+			subscriptionDetailsByAccountID[i].Tags = []TagSummary{}
+		}
+	}
 
-// CalculateNextDueDate calculates the next due date based on start date, due type, and due day of month
-// This function should be used for new subscriptions to determine when the first billing should occur
-func CalculateNextDueDate(dueType string, dueDayOfMonth int, startDate time.Time) time.Time {
-	currentDate := time.Now()
-	// If the due day of month is 1, then simply add a month to the current date and return the 1st of the next month
-	if dueDayOfMonth == 1 {
-		nextDueDate := currentDate.AddDate(0, 1, 0)
-		return time.Date(nextDueDate.Year(), nextDueDate.Month(), 1, 0, 0, 0, 0, nextDueDate.Location())
+	page := &SubscriptionDetailsPage{
+		Data:       subscriptionDetailsByAccountID,
+		TotalCount: totalCount,
 	}
 
-	// If the due day of month is not 1, then we need to calculate the next due date based on the current date.
-	// If current date > due day of month, then add a month to the current date and return the due day of the next month.
+	if len(subscriptionDetailsByAccountID) > filters.Limit {
+		page.Data = subscriptionDetailsByAccountID[:filters.Limit]
+		last := page.Data[len(page.Data)-1]
+		nextCursor := validator.EncodeCursor(validator.Cursor{
+			Sort: filters.SortSignature(),
+			Key:  last.SortKey,
+			ID:   last.ID,
+		})
+		page.NextCursor = &nextCursor
+	}
+
+	return page, nil
+}
+
+// escapeLike escapes LIKE wildcards so user input is matched literally
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// GetTrialsEndingSoon returns the account's subscriptions whose trial ends between today and today + days
+func GetTrialsEndingSoon(app *application.App, accountID int, days int) ([]SubscriptionDetailsByAccountID, error) {
+	trials := []SubscriptionDetailsByAccountID{}
+
+	query := `
+		SELECT 
+			sd.id,
+			sd.account_id,
+			sd.subscription_channel_id,
+			sc.channel_name as subscription_channel_name,
+			sc.channel_image_url,
+			sd.start_date,
+			sd.next_due_date,
+			sd.status,
+			sd.monthly_bill,
+			sd.currency,
+			sd.trial_end_date,
+			sd.intro_price,
+			sd.intro_price_until,
+			TRUE AS trial_ending_soon,
+			sd.reminder_date,
+			sd.reminder_time
+		FROM subscription_details sd
+		JOIN subscription_channels sc ON sd.subscription_channel_id = sc.id
+		WHERE sd.account_id = ?
+			AND sd.status = 'active'
+			AND sd.trial_end_date BETWEEN CURRENT_DATE AND CURRENT_DATE + ?::int
+		ORDER BY sd.trial_end_date ASC
+	`
 
-	if currentDate.Day() > dueDayOfMonth {
-		nextDueDate := currentDate.AddDate(0, 1, 0)
-		return time.Date(nextDueDate.Year(), nextDueDate.Month(), dueDayOfMonth, 0, 0, 0, 0, nextDueDate.Location())
+	err := app.Database.NewRaw(query, accountID, days).Scan(context.Background(), &trials)
+	if err != nil {
+		log.Println("Error getting trials ending soon: because of database error", err)
+		return nil, err
 	}
 
-	// If current date < due day of month, then return the due day of the current month.
-	nextDueDate := time.Date(currentDate.Year(), currentDate.Month(), dueDayOfMonth, 0, 0, 0, 0, currentDate.Location())
-	return nextDueDate
+	return trials, nil
+}
+
+// CalculateNextDueDate calculates the first due date on or after today of a new subscription. The series
+// starts at recurrence.FirstDueDate, so nothing is due before the start date or while the free trial lasts,
+// and repeats by dueType like every other expansion of the subscription.
+func CalculateNextDueDate(dueType string, dueDayOfMonth int, startDate time.Time, trialEndDate *time.Time) time.Time {
+	return nextDueDate(dueType, dueDayOfMonth, startDate, trialEndDate, time.Now())
+}
+
+func nextDueDate(dueType string, dueDayOfMonth int, startDate time.Time, trialEndDate *time.Time, now time.Time) time.Time {
+	subscription := models.Subscription_Details{
+		DueType:       dueType,
+		DueDayOfMonth: dueDayOfMonth,
+		StartDate:     startDate,
+		TrialEndDate:  trialEndDate,
+	}
+	subscription.NextDueDate = recurrence.FirstDueDate(subscription)
 
+	from := utils.TruncateToDay(now)
+	if subscription.NextDueDate.After(from) {
+		return subscription.NextDueDate
+	}
+	if next, ok := recurrence.NextOccurrence(subscription, from); ok {
+		return next
+	}
+	return subscription.NextDueDate
 }
@@ -0,0 +1,51 @@
+package subscriptiondetails
+
+import (
+	"testing"
+	"time"
+)
+
+func date(value string) time.Time {
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+func TestNextDueDate(t *testing.T) {
+	now := date("2026-02-10")
+	trialEnd := date("2026-03-05")
+	pastTrialEnd := date("2026-01-25")
+
+	tests := []struct {
+		name          string
+		dueType       string
+		dueDayOfMonth int
+		startDate     string
+		trialEndDate  *time.Time
+		want          string
+	}{
+		{"monthly on the day of the start date", "monthly", 1, "2026-01-20", nil, "2026-02-20"},
+		{"monthly due day clamped in February", "monthly", 31, "2026-01-20", nil, "2026-02-28"},
+		{"monthly due day clamped in the first month", "monthly", 31, "2026-02-01", nil, "2026-02-28"},
+		{"monthly due day later this month", "monthly", 15, "2026-01-03", nil, "2026-02-15"},
+		{"monthly due day that already passed", "monthly", 5, "2026-01-03", nil, "2026-03-05"},
+		{"monthly starting in the future", "monthly", 15, "2026-05-03", nil, "2026-05-15"},
+		{"yearly on the start date anniversary", "yearly", 1, "2025-06-15", nil, "2026-06-15"},
+		{"yearly starting in the future", "yearly", 1, "2027-01-20", nil, "2027-01-20"},
+		{"weekly from the start date", "weekly", 1, "2026-01-01", nil, "2026-02-12"},
+		{"daily is due today", "daily", 1, "2025-01-01", nil, "2026-02-10"},
+		{"trial ending in the future", "monthly", 1, "2026-01-20", &trialEnd, "2026-03-05"},
+		{"trial that already ended", "monthly", 1, "2026-01-20", &pastTrialEnd, "2026-02-25"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := nextDueDate(test.dueType, test.dueDayOfMonth, date(test.startDate), test.trialEndDate, now)
+			if !got.Equal(date(test.want)) {
+				t.Errorf("nextDueDate() = %s, want %s", got.Format("2006-01-02"), test.want)
+			}
+		})
+	}
+}
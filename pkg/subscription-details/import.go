@@ -0,0 +1,264 @@
+package subscriptiondetails
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/models"
+	subscription_channels "subscritracker/pkg/subscription-channels"
+	"subscritracker/pkg/validator"
+
+	"github.com/uptrace/bun"
+)
+
+// MaxImportRows caps how many subscriptions one CSV can create
+const MaxImportRows = 500
+
+// importColumnAliases maps accepted CSV header names to the import field they fill
+var importColumnAliases = map[string]string{
+	"channel":       "channel",
+	"channel_name":  "channel",
+	"channel_url":   "channel",
+	"name":          "channel",
+	"service":       "channel",
+	"url":           "channel",
+	"amount":        "amount",
+	"price":         "amount",
+	"monthly_bill":  "amount",
+	"cycle":         "cycle",
+	"billing_cycle": "cycle",
+	"due_type":      "cycle",
+	"start_date":    "start_date",
+	"status":        "status",
+	"currency":      "currency",
+	"next_due_date": "next_due_date",
+	"notes":         "notes",
+}
+
+var requiredImportColumns = []string{"channel", "amount"}
+
+// ParseImportCSV reads the CSV into rows keyed by import field. The first line must be a header.
+func ParseImportCSV(data []byte) ([]ImportRow, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("CSV file is empty")
+		}
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for index, name := range header {
+		field, ok := importColumnAliases[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			continue
+		}
+		if _, duplicate := columns[field]; duplicate {
+			return nil, fmt.Errorf("CSV header has more than one %s column", field)
+		}
+		columns[field] = index
+	}
+	for _, field := range requiredImportColumns {
+		if _, ok := columns[field]; !ok {
+			return nil, fmt.Errorf("CSV header must include a %s column", field)
+		}
+	}
+
+	rows := []ImportRow{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV on line %d: %w", line, err)
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+		if len(rows) == MaxImportRows {
+			return nil, fmt.Errorf("CSV cannot have more than %d rows", MaxImportRows)
+		}
+
+		value := func(field string) string {
+			index, ok := columns[field]
+			if !ok || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+		rows = append(rows, ImportRow{
+			Line:        line,
+			Channel:     value("channel"),
+			Amount:      value("amount"),
+			Cycle:       value("cycle"),
+			StartDate:   value("start_date"),
+			Status:      value("status"),
+			Currency:    value("currency"),
+			NextDueDate: value("next_due_date"),
+			Notes:       value("notes"),
+		})
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New("CSV has no rows to import")
+	}
+
+	return rows, nil
+}
+
+// ImportSubscriptionDetails matches and validates every row. Unless dryRun is set, all valid rows
+// are created in one transaction; invalid rows are reported and skipped.
+func ImportSubscriptionDetails(ctx context.Context, app *application.App, accountID int, defaultCurrency string, rows []ImportRow, channels []*models.Subscription_Channels, dryRun bool) (*ImportResult, error) {
+	subscribedChannelIDs := []int{}
+	err := app.Database.NewSelect().
+		Model((*models.Subscription_Details)(nil)).
+		Column("subscription_channel_id").
+		Where("account_id = ?", accountID).
+		Scan(context.Background(), &subscribedChannelIDs)
+	if err != nil {
+		return nil, err
+	}
+	subscribed := map[int]bool{}
+	for _, channelID := range subscribedChannelIDs {
+		subscribed[channelID] = true
+	}
+
+	matcher := subscription_channels.NewChannelMatcher(channels)
+	channelsByID := map[int]*models.Subscription_Channels{}
+	for _, channel := range channels {
+		channelsByID[channel.ID] = channel
+	}
+	result := &ImportResult{DryRun: dryRun, TotalRows: len(rows), Rows: []ImportRowResult{}}
+	toCreate := []models.Subscription_Details{}
+	toCreateRows := []int{}
+
+	for _, row := range rows {
+		rowResult := ImportRowResult{Line: row.Line, Channel: row.Channel, Errors: []string{}}
+
+		match, matchErr := matchImportChannel(matcher, channelsByID, row)
+		channelID := 0
+		if matchErr != nil {
+			rowResult.Errors = append(rowResult.Errors, matchErr.Error())
+		} else {
+			channelID = match.Channel.ID
+			rowResult.MatchedChannel = &ImportMatchedChannel{
+				ID:          match.Channel.ID,
+				ChannelName: match.Channel.ChannelName,
+				Score:       match.Score,
+			}
+			if subscribed[channelID] {
+				rowResult.Errors = append(rowResult.Errors, fmt.Sprintf("you already have a subscription to %s", match.Channel.ChannelName))
+			}
+		}
+
+		// Validate the rest of the row even when the channel is unknown so every problem is reported at once
+		request := importRowToRequest(row)
+		request.SubscriptionChannelID = max(channelID, 1)
+		parsed, err := validator.ValidateSubscriptionDetails(request)
+		if err != nil {
+			rowResult.Errors = append(rowResult.Errors, err.Error())
+		}
+
+		if len(rowResult.Errors) > 0 {
+			rowResult.Status = ImportRowInvalid
+			result.InvalidRows++
+			result.Rows = append(result.Rows, rowResult)
+			continue
+		}
+
+		// Later rows for the same channel would be duplicates of this one
+		subscribed[channelID] = true
+		parsed.SubscriptionChannelID = channelID
+
+		currency := parsed.Currency
+		if currency == "" {
+			currency = defaultCurrency
+		}
+		subscription := BuildSubscriptionDetails(accountID, parsed, currency)
+		rowResult.Status = ImportRowValid
+		rowResult.Subscription = &subscription
+		result.ValidRows++
+
+		toCreate = append(toCreate, subscription)
+		toCreateRows = append(toCreateRows, len(result.Rows))
+		result.Rows = append(result.Rows, rowResult)
+	}
+
+	if dryRun || len(toCreate) == 0 {
+		return result, nil
+	}
+
+	err = app.Database.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		for i := range toCreate {
+			if err := InsertSubscriptionDetails(ctx, tx, &toCreate[i]); err != nil {
+				return fmt.Errorf("line %d: %w", result.Rows[toCreateRows[i]].Line, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, rowIndex := range toCreateRows {
+		result.Rows[rowIndex].Status = ImportRowImported
+		result.Rows[rowIndex].Subscription = &toCreate[i]
+	}
+	result.Imported = len(toCreate)
+
+	return result, nil
+}
+
+// matchImportChannel resolves the channel of a row by id when given, otherwise by fuzzy matching its name or URL
+func matchImportChannel(matcher *subscription_channels.ChannelMatcher, channelsByID map[int]*models.Subscription_Channels, row ImportRow) (subscription_channels.ChannelMatch, error) {
+	if row.SubscriptionChannelID == 0 {
+		return matcher.Match(row.Channel)
+	}
+
+	channel, ok := channelsByID[row.SubscriptionChannelID]
+	if !ok {
+		return subscription_channels.ChannelMatch{}, fmt.Errorf("subscription channel %d cannot be found", row.SubscriptionChannelID)
+	}
+	return subscription_channels.ChannelMatch{Channel: channel, Score: 1}, nil
+}
+
+// importRowToRequest fills a subscription request from a CSV row. Monthly rows are due on the day of their
+// start date; the other cycles repeat from the start date and keep the default due day.
+func importRowToRequest(row ImportRow) validator.SubscriptionDetailsRequest {
+	request := validator.SubscriptionDetailsRequest{
+		StartDate:   row.StartDate,
+		NextDueDate: row.NextDueDate,
+		DueType:     validator.NormalizeDueType(row.Cycle),
+		Status:      strings.ToLower(row.Status),
+		MonthlyBill: json.Number(strings.TrimLeft(row.Amount, "$€£¥ ")),
+		Currency:    row.Currency,
+		Notes:       row.Notes,
+	}
+	if startDate, err := time.Parse("2006-01-02", row.StartDate); err == nil && (request.DueType == "" || request.DueType == "monthly") {
+		request.DueDayOfMonth = startDate.Day()
+	}
+	return request
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
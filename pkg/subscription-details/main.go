@@ -7,7 +7,10 @@ import (
 
 func RegisterRoutes(app *application.App) {
 	// app.Echo.GET("/v1/subscription-details", GetAllSubscriptionDetailsHandler, utils.AuthMiddleware)
+	app.Echo.GET("/v1/subscription-details/trials-ending", GetTrialsEndingSoonHandler, utils.AuthMiddleware)
 	app.Echo.GET("/v1/subscription-details/:id", GetSubscriptionDetailsHandler, utils.AuthMiddleware)
 	app.Echo.POST("/v1/subscription-details", PostSubscriptionDetailsHandler, utils.AuthMiddleware)
+	app.Echo.POST("/v1/subscription-details/import", ImportSubscriptionDetailsHandler, utils.AuthMiddleware)
+	app.Echo.PUT("/v1/subscription-details/:id/status", UpdateSubscriptionStatusHandler, utils.AuthMiddleware)
 	app.Echo.GET("/v1/user-subscription-details", GetUserSubscriptionDetailsHandler, utils.AuthMiddleware)
 }
@@ -1,17 +1,98 @@
 package subscriptiondetails
 
-import "time"
+import (
+	"errors"
+	"time"
+
+	"subscritracker/pkg/models"
+	"subscritracker/pkg/money"
+)
+
+// DefaultTrialEndingSoonDays is how far ahead a trial end is flagged as ending soon
+const DefaultTrialEndingSoonDays = 7
+
+var ErrSubscriptionDetailsNotFound = errors.New("subscription details not found")
 
 type SubscriptionDetailsByAccountID struct {
-	ID                      int        `json:"id"`
-	AccountID               int        `json:"account_id"`
-	SubscriptionChannelID   int        `json:"subscription_channel_id"`
-	SubscriptionChannelName string     `json:"subscription_channel_name"`
-	ChannelImageURL         string     `json:"channel_image_url"`
-	StartDate               time.Time  `json:"start_date"`
-	NextDueDate             time.Time  `json:"next_due_date"`
-	Status                  string     `json:"status"`
-	MonthlyBill             float64    `json:"monthly_bill"`
-	ReminderDate            *time.Time `bun:",nullzero" json:"reminder_date,omitempty"`
-	ReminderTime            *time.Time `bun:",nullzero" json:"reminder_time,omitempty"`
+	ID                      int           `json:"id"`
+	AccountID               int           `json:"account_id"`
+	SubscriptionChannelID   int           `json:"subscription_channel_id"`
+	SubscriptionChannelName string        `json:"subscription_channel_name"`
+	ChannelImageURL         string        `json:"channel_image_url"`
+	StartDate               time.Time     `json:"start_date"`
+	NextDueDate             time.Time     `json:"next_due_date"`
+	Status                  string        `json:"status"`
+	MonthlyBill             money.Amount  `json:"monthly_bill"`
+	Currency                string        `json:"currency"`
+	TrialEndDate            *time.Time    `bun:",nullzero" json:"trial_end_date,omitempty"`
+	IntroPrice              *money.Amount `json:"intro_price,omitempty"`
+	IntroPriceUntil         *time.Time    `bun:",nullzero" json:"intro_price_until,omitempty"`
+	TrialEndingSoon         bool          `json:"trial_ending_soon"`
+	Notes                   string        `bun:",nullzero" json:"notes,omitempty"`
+	CategoryID              *int          `json:"category_id,omitempty"`
+	CategoryName            *string       `json:"category_name,omitempty"`
+	Tags                    []TagSummary  `bun:"-" json:"tags"`
+	ReminderDate            *time.Time    `bun:",nullzero" json:"reminder_date,omitempty"`
+	ReminderTime            *time.Time    `bun:",nullzero" json:"reminder_time,omitempty"`
+	ReminderDaysBefore      *int          `json:"reminder_days_before,omitempty"`
+	SortKey                 string        `bun:"sort_key" json:"-"`
+}
+
+type TagSummary struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color,omitempty"`
+}
+
+// SubscriptionDetailsPage is one page of the user's subscriptions.
+// next_cursor is null on the last page, total_count counts every row matching the filters.
+type SubscriptionDetailsPage struct {
+	Data       []SubscriptionDetailsByAccountID `json:"data"`
+	NextCursor *string                          `json:"next_cursor"`
+	TotalCount int                              `json:"total_count"`
+}
+
+const (
+	ImportRowValid    = "valid"
+	ImportRowInvalid  = "invalid"
+	ImportRowImported = "imported"
+)
+
+// ImportRow is one CSV line of a subscription import, before validation.
+// Rows with a SubscriptionChannelID skip fuzzy matching of Channel.
+type ImportRow struct {
+	Line                  int
+	Channel               string
+	SubscriptionChannelID int
+	Amount                string
+	Cycle                 string
+	StartDate             string
+	Status                string
+	Currency              string
+	NextDueDate           string
+	Notes                 string
+}
+
+type ImportMatchedChannel struct {
+	ID          int     `json:"id"`
+	ChannelName string  `json:"channel_name"`
+	Score       float64 `json:"score"`
+}
+
+type ImportRowResult struct {
+	Line           int                          `json:"line"`
+	Channel        string                       `json:"channel"`
+	Status         string                       `json:"status"`
+	MatchedChannel *ImportMatchedChannel        `json:"matched_channel,omitempty"`
+	Subscription   *models.Subscription_Details `json:"subscription,omitempty"`
+	Errors         []string                     `json:"errors"`
+}
+
+type ImportResult struct {
+	DryRun      bool              `json:"dry_run"`
+	TotalRows   int               `json:"total_rows"`
+	ValidRows   int               `json:"valid_rows"`
+	InvalidRows int               `json:"invalid_rows"`
+	Imported    int               `json:"imported"`
+	Rows        []ImportRowResult `json:"rows"`
 }
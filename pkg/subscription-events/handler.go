@@ -1,22 +1,30 @@
 package subscriptionevents
 
 import (
+	"log"
 	"net/http"
+	"strconv"
+	"subscritracker/pkg/application"
 	"subscritracker/pkg/models"
-	subscriptiondetails "subscritracker/pkg/subscription-details"
 	"subscritracker/pkg/validator"
 
 	"github.com/labstack/echo/v4"
 )
 
 func PostSubscriptionEventsHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
 	request, err := validator.ValidateSubscriptionEventRequest(c)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
+	if request.AccountID != accountID {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "account_id does not match the authenticated user"})
+	}
 
-	// Validate subscription details id exists
-	exists, err := subscriptiondetails.CheckSubscriptionDetailsExists(c, request.SubscriptionDetailsID)
+	// Validate subscription details id exists and is the user's
+	exists, err := SubscriptionBelongsToAccount(app, accountID, request.SubscriptionDetailsID)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
@@ -27,6 +35,9 @@ func PostSubscriptionEventsHandler(c echo.Context) error {
 	subscriptionEvent := models.Subscription_Event{
 		SubscriptionDetailsID: request.SubscriptionDetailsID,
 		AccountID:             request.AccountID,
+		Type:                  EventNote,
+		Payload:               request.Payload,
+		Actor:                 ActorUser,
 	}
 
 	createdSubscriptionEvent, err := CreateSubscriptionEvent(c, subscriptionEvent)
@@ -36,3 +47,55 @@ func PostSubscriptionEventsHandler(c echo.Context) error {
 
 	return c.JSON(http.StatusCreated, createdSubscriptionEvent)
 }
+
+// GetAccountSubscriptionEventsHandler lists the events of all the user's subscriptions, newest first
+func GetAccountSubscriptionEventsHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	filters, err := validator.ValidateSubscriptionEventFilters(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	page, err := GetSubscriptionEvents(app, accountID, 0, filters)
+	if err != nil {
+		log.Println("Error getting subscription events:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get subscription events"})
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+// GetSubscriptionEventsHandler lists the events of one of the user's subscriptions, newest first
+func GetSubscriptionEventsHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	subscriptionDetailsID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid subscription details ID"})
+	}
+
+	filters, err := validator.ValidateSubscriptionEventFilters(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	exists, err := SubscriptionBelongsToAccount(app, accountID, subscriptionDetailsID)
+	if err != nil {
+		log.Println("Error checking subscription details:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get subscription details"})
+	}
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": ErrSubscriptionNotFound.Error()})
+	}
+
+	page, err := GetSubscriptionEvents(app, accountID, subscriptionDetailsID, filters)
+	if err != nil {
+		log.Println("Error getting subscription events:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get subscription events"})
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
@@ -2,28 +2,133 @@ package subscriptionevents
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"subscritracker/pkg/application"
 	"subscritracker/pkg/models"
+	"subscritracker/pkg/outbox"
+	"subscritracker/pkg/validator"
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/uptrace/bun"
 )
 
 func CreateSubscriptionEvent(c echo.Context, request models.Subscription_Event) (models.Subscription_Event, error) {
 	app := c.Get("app").(*application.App)
 
-	// Set timestamps
-	request.CreatedAt = time.Now()
-	request.UpdatedAt = time.Now()
-
-	_, err := app.Database.NewInsert().
-		Model(&request).
-		Exec(context.Background())
-	if err != nil {
+	if err := Record(context.Background(), app.Database, &request); err != nil {
 		log.Println("Error creating subscription event:", err)
 		return models.Subscription_Event{}, err
 	}
 
 	return request, nil
 }
+
+// Record adds an event to a subscription's history. Write paths call it in the transaction
+// of the change itself, so the history only has events for changes that were stored.
+func Record(ctx context.Context, db bun.IDB, event *models.Subscription_Event) error {
+	if event.Payload == nil {
+		event.Payload = map[string]interface{}{}
+	}
+	if event.Actor == "" {
+		event.Actor = ActorUser
+	}
+	event.CreatedAt = time.Now()
+	event.UpdatedAt = time.Now()
+
+	// The event and its outbox message are stored together, so consumers such as webhooks
+	// only hear about recorded events and hear about every one of them
+	return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewInsert().
+			Model(event).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return outbox.Write(ctx, tx, event.AccountID, outbox.TopicSubscriptionEventRecorded,
+			fmt.Sprintf("subscription_details:%d", event.SubscriptionDetailsID), event)
+	})
+}
+
+// StatusChangeEvent returns the event type of a subscription going from one status to another,
+// or "" when the change is not an event of its own (e.g. paused to inactive)
+func StatusChangeEvent(from, to string) string {
+	switch {
+	case from == to:
+		return ""
+	case to == "cancelled":
+		return EventCancelled
+	case to == "active":
+		return EventResumed
+	case from == "active":
+		return EventPaused
+	default:
+		return ""
+	}
+}
+
+// GetStopEvents returns the account's cancelled and paused events grouped by subscription, oldest first,
+// for recurrence.StoppedOn
+func GetStopEvents(ctx context.Context, db bun.IDB, accountID int) (map[int][]models.Subscription_Event, error) {
+	events := []models.Subscription_Event{}
+	err := db.NewSelect().
+		Model(&events).
+		Where("account_id = ? AND type IN (?)", accountID, bun.In([]string{EventCancelled, EventPaused})).
+		Order("created_at ASC", "id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	eventsBySubscription := map[int][]models.Subscription_Event{}
+	for _, event := range events {
+		eventsBySubscription[event.SubscriptionDetailsID] = append(eventsBySubscription[event.SubscriptionDetailsID], event)
+	}
+	return eventsBySubscription, nil
+}
+
+// SubscriptionBelongsToAccount reports whether the subscription exists and is the account's
+func SubscriptionBelongsToAccount(app *application.App, accountID, subscriptionDetailsID int) (bool, error) {
+	return app.Database.NewSelect().
+		Model((*models.Subscription_Details)(nil)).
+		Where("id = ? AND account_id = ?", subscriptionDetailsID, accountID).
+		Exists(context.Background())
+}
+
+// GetSubscriptionEvents lists the account's events, newest first. A subscriptionDetailsID other
+// than 0 limits the list to that subscription.
+func GetSubscriptionEvents(app *application.App, accountID, subscriptionDetailsID int, filters *validator.SubscriptionEventFilters) (*SubscriptionEventPage, error) {
+	events := []models.Subscription_Event{}
+	query := app.Database.NewSelect().
+		Model(&events).
+		Where("account_id = ?", accountID)
+
+	if subscriptionDetailsID != 0 {
+		query = query.Where("subscription_details_id = ?", subscriptionDetailsID)
+	}
+	if filters.Type != "" {
+		query = query.Where("type = ?", filters.Type)
+	}
+	if filters.ParsedCursor != nil {
+		query = query.Where("id < ?", filters.ParsedCursor.ID)
+	}
+
+	err := query.
+		Order("id DESC").
+		Limit(filters.Limit + 1).
+		Scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	page := &SubscriptionEventPage{Data: events}
+	if len(events) > filters.Limit {
+		page.Data = events[:filters.Limit]
+		nextCursor := validator.EncodeCursor(validator.Cursor{ID: page.Data[len(page.Data)-1].ID})
+		page.NextCursor = &nextCursor
+	}
+
+	return page, nil
+}
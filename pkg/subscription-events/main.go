@@ -6,7 +6,7 @@ import (
 )
 
 func RegisterRoutes(app *application.App) {
-	// app.Echo.GET("/v1/subscription-events", GetAllSubscriptionEventsHandler, utils.AuthMiddleware)
-	// app.Echo.GET("/v1/subscription-events/:id", GetSubscriptionEventsHandler, utils.AuthMiddleware)
+	app.Echo.GET("/v1/subscription-events", GetAccountSubscriptionEventsHandler, utils.AuthMiddleware)
+	app.Echo.GET("/v1/subscription-details/:id/events", GetSubscriptionEventsHandler, utils.AuthMiddleware)
 	app.Echo.POST("/v1/subscription-events", PostSubscriptionEventsHandler, utils.AuthMiddleware)
 }
@@ -0,0 +1,33 @@
+package subscriptionevents
+
+import (
+	"errors"
+
+	"subscritracker/pkg/models"
+)
+
+const (
+	EventCreated      = "created"
+	EventPriceChanged = "price_changed"
+	EventPaused       = "paused"
+	EventResumed      = "resumed"
+	EventCancelled    = "cancelled"
+	EventCharged      = "charged"
+	EventReminderSent = "reminder_sent"
+	// EventNote is a note the user posted; it is the only type users can post
+	EventNote = "note"
+)
+
+const (
+	// ActorUser is an event caused by the user through the API
+	ActorUser = "user"
+	// ActorSystem is an event caused by a background worker
+	ActorSystem = "system"
+)
+
+var ErrSubscriptionNotFound = errors.New("subscription details not found")
+
+type SubscriptionEventPage struct {
+	Data       []models.Subscription_Event `json:"data"`
+	NextCursor *string                     `json:"next_cursor"`
+}
@@ -0,0 +1,144 @@
+package subscriptionversions
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/models"
+	"subscritracker/pkg/validator"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetVersionsHandler lists every recorded version of one of the user's subscriptions, newest first
+func GetVersionsHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+
+	subscription, response := subscriptionFromParam(c)
+	if subscription == nil {
+		return response
+	}
+
+	filters, err := validator.ValidateSubscriptionVersionFilters(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	page, err := GetVersions(app, subscription.ID, filters)
+	if err != nil {
+		log.Println("Error getting subscription versions:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get subscription versions"})
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+// GetSubscriptionAsOfHandler returns one of the user's subscriptions as it was at the given moment
+func GetSubscriptionAsOfHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+
+	subscription, response := subscriptionFromParam(c)
+	if subscription == nil {
+		return response
+	}
+
+	request, err := validator.ValidateAsOfRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	asOf, err := GetSubscriptionAsOf(app, *subscription, request.ParsedAt)
+	if err != nil {
+		log.Println("Error reconstructing subscription:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to reconstruct subscription"})
+	}
+
+	return c.JSON(http.StatusOK, asOf)
+}
+
+// GetAccountAsOfHandler returns every subscription the user had at the given moment
+func GetAccountAsOfHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	request, err := validator.ValidateAsOfRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	asOf, err := GetAccountAsOf(app, accountID, request.ParsedAt)
+	if err != nil {
+		log.Println("Error reconstructing subscriptions:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to reconstruct subscriptions"})
+	}
+
+	return c.JSON(http.StatusOK, asOf)
+}
+
+// GetSubscriptionDiffHandler returns how one of the user's subscriptions changed between two moments
+func GetSubscriptionDiffHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+
+	subscription, response := subscriptionFromParam(c)
+	if subscription == nil {
+		return response
+	}
+
+	request, err := validator.ValidateVersionDiffRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	diff, err := GetSubscriptionDiff(app, *subscription, request.ParsedFrom, request.ParsedTo)
+	if err != nil {
+		log.Println("Error comparing subscription versions:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to compare subscription versions"})
+	}
+
+	return c.JSON(http.StatusOK, diff)
+}
+
+// GetAccountDiffHandler returns the user's subscriptions that were added or changed between two moments
+func GetAccountDiffHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	request, err := validator.ValidateVersionDiffRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	diff, err := GetAccountDiff(app, accountID, request.ParsedFrom, request.ParsedTo)
+	if err != nil {
+		log.Println("Error comparing subscription versions:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to compare subscription versions"})
+	}
+
+	return c.JSON(http.StatusOK, diff)
+}
+
+// subscriptionFromParam loads the user's subscription named by the id path parameter. When it returns nil
+// the error response has already been written and is returned as the second value.
+func subscriptionFromParam(c echo.Context) (*models.Subscription_Details, error) {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	subscriptionDetailsID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid subscription details ID"})
+	}
+
+	subscription, err := GetOwnedSubscription(app, accountID, subscriptionDetailsID)
+	if err != nil {
+		if errors.Is(err, ErrSubscriptionNotFound) {
+			return nil, c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error getting subscription details:", err)
+		return nil, c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get subscription details"})
+	}
+
+	return subscription, nil
+}
@@ -0,0 +1,390 @@
+package subscriptionversions
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"time"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/models"
+	subscriptionevents "subscritracker/pkg/subscription-events"
+	"subscritracker/pkg/utils"
+	"subscritracker/pkg/validator"
+
+	"github.com/uptrace/bun"
+)
+
+// Record stores a new version of a subscription. before is nil when the subscription was just created.
+// Call it in the transaction of the change with the state the subscription had before and has after it;
+// a write that changed nothing but updated_at records no version. The actor and request id come from
+// the context: a request context from utils.RequestContext means the user made the change.
+// Both states carry the tags the subscription has now, which the change did not touch.
+func Record(ctx context.Context, db bun.IDB, before *models.Subscription_Details, after models.Subscription_Details) error {
+	tagIDs, err := currentTagIDs(ctx, db, after.ID)
+	if err != nil {
+		return err
+	}
+
+	var beforeState *versionState
+	if before != nil {
+		beforeState = &versionState{Subscription_Details: *before, TagIDs: tagIDs}
+	}
+	return record(ctx, db, beforeState, versionState{Subscription_Details: after, TagIDs: tagIDs})
+}
+
+// RecordTags stores a new version of a subscription whose tags were changed from beforeTagIDs to the
+// ones it has now. Call it in the transaction of the change, after the new tags were stored.
+func RecordTags(ctx context.Context, db bun.IDB, subscription models.Subscription_Details, beforeTagIDs []int) error {
+	tagIDs, err := currentTagIDs(ctx, db, subscription.ID)
+	if err != nil {
+		return err
+	}
+
+	sortedBefore := append([]int{}, beforeTagIDs...)
+	sort.Ints(sortedBefore)
+	before := versionState{Subscription_Details: subscription, TagIDs: sortedBefore}
+	return record(ctx, db, &before, versionState{Subscription_Details: subscription, TagIDs: tagIDs})
+}
+
+func record(ctx context.Context, db bun.IDB, before *versionState, after versionState) error {
+	afterState, err := json.Marshal(after)
+	if err != nil {
+		return err
+	}
+
+	version := &models.Subscription_Version{
+		SubscriptionDetailsID: after.ID,
+		AccountID:             after.AccountID,
+		Operation:             OperationCreated,
+		After:                 afterState,
+		Actor:                 subscriptionevents.ActorSystem,
+		RecordedAt:            time.Now(),
+	}
+
+	changes := map[string]FieldChange{}
+	if before != nil {
+		if version.Before, err = json.Marshal(before); err != nil {
+			return err
+		}
+		version.Operation = OperationUpdated
+
+		if changes, err = diffStates(version.Before, afterState); err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+	}
+	if version.Changes, err = json.Marshal(changes); err != nil {
+		return err
+	}
+
+	if info, ok := utils.RequestInfoFrom(ctx); ok {
+		version.Actor = subscriptionevents.ActorUser
+		version.RequestID = info.RequestID
+	}
+
+	// The unique (subscription_details_id, version) constraint fails one of two concurrent writers
+	// instead of letting them record the same version
+	_, err = db.NewInsert().
+		Model(version).
+		Value("version", "(SELECT COALESCE(MAX(version), 0) + 1 FROM subscription_versions WHERE subscription_details_id = ?)", after.ID).
+		Exec(ctx)
+	return err
+}
+
+// currentTagIDs returns the ids of the tags a subscription has, in ascending order
+func currentTagIDs(ctx context.Context, db bun.IDB, subscriptionDetailsID int) ([]int, error) {
+	tagIDs := []int{}
+	err := db.NewSelect().
+		Model((*models.Subscription_Details_Tag)(nil)).
+		Column("tag_id").
+		Where("subscription_details_id = ?", subscriptionDetailsID).
+		Order("tag_id ASC").
+		Scan(ctx, &tagIDs)
+	if err != nil {
+		return nil, err
+	}
+	return tagIDs, nil
+}
+
+// GetVersions lists the versions of a subscription, newest first
+func GetVersions(app *application.App, subscriptionDetailsID int, filters *validator.SubscriptionVersionFilters) (*VersionPage, error) {
+	versions := []models.Subscription_Version{}
+	query := app.Database.NewSelect().
+		Model(&versions).
+		Where("subscription_details_id = ?", subscriptionDetailsID)
+
+	if filters.ParsedCursor != nil {
+		query = query.Where("version < ?", filters.ParsedCursor.ID)
+	}
+
+	err := query.
+		Order("version DESC").
+		Limit(filters.Limit + 1).
+		Scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	page := &VersionPage{Data: versions}
+	if len(versions) > filters.Limit {
+		page.Data = versions[:filters.Limit]
+		nextCursor := validator.EncodeCursor(validator.Cursor{ID: page.Data[len(page.Data)-1].Version})
+		page.NextCursor = &nextCursor
+	}
+
+	return page, nil
+}
+
+// GetOwnedSubscription returns one of the account's subscriptions as it is now
+func GetOwnedSubscription(app *application.App, accountID, subscriptionDetailsID int) (*models.Subscription_Details, error) {
+	subscription := &models.Subscription_Details{}
+	err := app.Database.NewSelect().
+		Model(subscription).
+		Where("id = ? AND account_id = ?", subscriptionDetailsID, accountID).
+		Scan(context.Background())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSubscriptionNotFound
+		}
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+// GetSubscriptionAsOf reconstructs a subscription as it was at a moment
+func GetSubscriptionAsOf(app *application.App, subscription models.Subscription_Details, at time.Time) (*SubscriptionAsOf, error) {
+	versions, err := loadVersions(app, subscription.AccountID, subscription.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := stateAt(subscription, versions[subscription.ID], at)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SubscriptionAsOf{At: at, Exists: state != nil, SubscriptionState: state}, nil
+}
+
+// GetAccountAsOf reconstructs every subscription the account had at a moment
+func GetAccountAsOf(app *application.App, accountID int, at time.Time) (*AccountAsOf, error) {
+	subscriptions, versions, err := loadAccount(app, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	asOf := &AccountAsOf{At: at, Subscriptions: []SubscriptionState{}}
+	for _, subscription := range subscriptions {
+		state, err := stateAt(subscription, versions[subscription.ID], at)
+		if err != nil {
+			return nil, err
+		}
+		if state != nil {
+			asOf.Subscriptions = append(asOf.Subscriptions, *state)
+		}
+	}
+
+	return asOf, nil
+}
+
+// GetSubscriptionDiff compares a subscription at two moments and lists the versions recorded in between
+func GetSubscriptionDiff(app *application.App, subscription models.Subscription_Details, from, to time.Time) (*SubscriptionDiff, error) {
+	versions, err := loadVersions(app, subscription.AccountID, subscription.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := diffBetween(subscription, versions[subscription.ID], from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diff.Versions = []models.Subscription_Version{}
+	for _, version := range versions[subscription.ID] {
+		if version.RecordedAt.After(from) && !version.RecordedAt.After(to) {
+			diff.Versions = append(diff.Versions, version)
+		}
+	}
+
+	return diff, nil
+}
+
+// GetAccountDiff compares the account's subscriptions at two moments, leaving out the ones that did not change
+func GetAccountDiff(app *application.App, accountID int, from, to time.Time) (*AccountDiff, error) {
+	subscriptions, versions, err := loadAccount(app, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	accountDiff := &AccountDiff{From: from, To: to, Subscriptions: []SubscriptionDiff{}}
+	for _, subscription := range subscriptions {
+		diff, err := diffBetween(subscription, versions[subscription.ID], from, to)
+		if err != nil {
+			return nil, err
+		}
+		if diff.Status != DiffUnchanged {
+			accountDiff.Subscriptions = append(accountDiff.Subscriptions, *diff)
+		}
+	}
+
+	return accountDiff, nil
+}
+
+func loadAccount(app *application.App, accountID int) ([]models.Subscription_Details, map[int][]models.Subscription_Version, error) {
+	subscriptions := []models.Subscription_Details{}
+	err := app.Database.NewSelect().
+		Model(&subscriptions).
+		Where("account_id = ?", accountID).
+		Order("id ASC").
+		Scan(context.Background())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	versions, err := loadVersions(app, accountID, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return subscriptions, versions, nil
+}
+
+// loadVersions returns the versions of the account's subscriptions, or of one of them when
+// subscriptionDetailsID is set, by subscription and oldest first
+func loadVersions(app *application.App, accountID, subscriptionDetailsID int) (map[int][]models.Subscription_Version, error) {
+	versions := []models.Subscription_Version{}
+	query := app.Database.NewSelect().
+		Model(&versions).
+		Where("account_id = ?", accountID)
+	if subscriptionDetailsID != 0 {
+		query = query.Where("subscription_details_id = ?", subscriptionDetailsID)
+	}
+
+	err := query.
+		Order("subscription_details_id ASC", "version ASC").
+		Scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	bySubscription := map[int][]models.Subscription_Version{}
+	for _, version := range versions {
+		bySubscription[version.SubscriptionDetailsID] = append(bySubscription[version.SubscriptionDetailsID], version)
+	}
+	return bySubscription, nil
+}
+
+// stateAt returns the state a subscription was in at a moment, or nil when it did not exist yet.
+// versions are the subscription's versions, oldest first. Before its first version a subscription
+// was in that version's before state; one without versions has not changed since the trail started.
+func stateAt(subscription models.Subscription_Details, versions []models.Subscription_Version, at time.Time) (*SubscriptionState, error) {
+	state := &SubscriptionState{SubscriptionDetailsID: subscription.ID}
+
+	latest := sort.Search(len(versions), func(i int) bool { return versions[i].RecordedAt.After(at) }) - 1
+	switch {
+	case latest >= 0:
+		state.Version = versions[latest].Version
+		state.Subscription = versions[latest].After
+	case subscription.CreatedAt.After(at) || (len(versions) > 0 && versions[0].Operation == OperationCreated):
+		return nil, nil
+	case len(versions) > 0:
+		state.Version = versions[0].Version - 1
+		state.Subscription = versions[0].Before
+	default:
+		current, err := json.Marshal(subscription)
+		if err != nil {
+			return nil, err
+		}
+		state.Subscription = current
+	}
+
+	return state, nil
+}
+
+// diffBetween compares the states a subscription was in at two moments
+func diffBetween(subscription models.Subscription_Details, versions []models.Subscription_Version, from, to time.Time) (*SubscriptionDiff, error) {
+	fromState, err := stateAt(subscription, versions, from)
+	if err != nil {
+		return nil, err
+	}
+	toState, err := stateAt(subscription, versions, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &SubscriptionDiff{SubscriptionDetailsID: subscription.ID, Status: DiffUnchanged, Changes: map[string]FieldChange{}}
+	var fromJSON, toJSON json.RawMessage
+	if fromState != nil {
+		diff.FromVersion = &fromState.Version
+		fromJSON = fromState.Subscription
+	}
+	if toState != nil {
+		diff.ToVersion = &toState.Version
+		toJSON = toState.Subscription
+	}
+
+	if diff.Changes, err = diffStates(fromJSON, toJSON); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case fromState == nil && toState != nil:
+		diff.Status = DiffAdded
+	case fromState != nil && toState == nil:
+		diff.Status = DiffRemoved
+	case len(diff.Changes) > 0:
+		diff.Status = DiffChanged
+	}
+
+	return diff, nil
+}
+
+// diffStates returns the fields that differ between two states. A missing state has no fields,
+// so every field of the other one is reported as changed from or to null.
+func diffStates(before, after json.RawMessage) (map[string]FieldChange, error) {
+	beforeFields, err := decodeState(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := decodeState(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]FieldChange{}
+	for field, from := range beforeFields {
+		if to := afterFields[field]; !ignoredFields[field] && !reflect.DeepEqual(from, to) {
+			changes[field] = FieldChange{From: from, To: to}
+		}
+	}
+	for field, to := range afterFields {
+		if _, ok := beforeFields[field]; !ok && !ignoredFields[field] && to != nil {
+			changes[field] = FieldChange{From: nil, To: to}
+		}
+	}
+
+	return changes, nil
+}
+
+// decodeState decodes a state keeping numbers as written, so amounts are compared and shown exactly
+func decodeState(state json.RawMessage) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if len(state) == 0 || string(state) == "null" {
+		return fields, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(state))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
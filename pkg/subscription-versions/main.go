@@ -0,0 +1,14 @@
+package subscriptionversions
+
+import (
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/utils"
+)
+
+func RegisterRoutes(app *application.App) {
+	app.Echo.GET("/v1/subscription-details/as-of", GetAccountAsOfHandler, utils.AuthMiddleware)
+	app.Echo.GET("/v1/subscription-details/diff", GetAccountDiffHandler, utils.AuthMiddleware)
+	app.Echo.GET("/v1/subscription-details/:id/versions", GetVersionsHandler, utils.AuthMiddleware)
+	app.Echo.GET("/v1/subscription-details/:id/as-of", GetSubscriptionAsOfHandler, utils.AuthMiddleware)
+	app.Echo.GET("/v1/subscription-details/:id/diff", GetSubscriptionDiffHandler, utils.AuthMiddleware)
+}
@@ -0,0 +1,83 @@
+package subscriptionversions
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"subscritracker/pkg/models"
+)
+
+// Version operations
+const (
+	OperationCreated = "created"
+	OperationUpdated = "updated"
+)
+
+// Diff statuses of a subscription between two moments
+const (
+	DiffAdded     = "added"
+	DiffRemoved   = "removed"
+	DiffChanged   = "changed"
+	DiffUnchanged = "unchanged"
+)
+
+var ErrSubscriptionNotFound = errors.New("subscription details not found")
+
+// ignoredFields change with every write and are left out of diffs
+var ignoredFields = map[string]bool{"updated_at": true}
+
+// versionState is a subscription as a version stores it: the subscription as the API returns it, with
+// the ids of its tags, which are stored apart from it
+type versionState struct {
+	models.Subscription_Details
+	TagIDs []int `json:"tag_ids"`
+}
+
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+type VersionPage struct {
+	Data       []models.Subscription_Version `json:"data"`
+	NextCursor *string                       `json:"next_cursor"`
+}
+
+// SubscriptionState is a subscription as it was at some moment. Version is the version it was in;
+// 0 is the state it had when the audit trail started, for subscriptions older than that.
+type SubscriptionState struct {
+	SubscriptionDetailsID int             `json:"subscription_details_id"`
+	Version               int             `json:"version"`
+	Subscription          json.RawMessage `json:"subscription"`
+}
+
+// SubscriptionAsOf is one subscription reconstructed at a moment. Exists is false before it was created.
+type SubscriptionAsOf struct {
+	At     time.Time `json:"at"`
+	Exists bool      `json:"exists"`
+	*SubscriptionState
+}
+
+// AccountAsOf is every subscription the account had at a moment
+type AccountAsOf struct {
+	At            time.Time           `json:"at"`
+	Subscriptions []SubscriptionState `json:"subscriptions"`
+}
+
+// SubscriptionDiff is how a subscription changed between two moments. Versions lists the versions
+// recorded in between and is only filled in for a single subscription's diff.
+type SubscriptionDiff struct {
+	SubscriptionDetailsID int                           `json:"subscription_details_id"`
+	Status                string                        `json:"status"`
+	FromVersion           *int                          `json:"from_version"`
+	ToVersion             *int                          `json:"to_version"`
+	Changes               map[string]FieldChange        `json:"changes"`
+	Versions              []models.Subscription_Version `json:"versions,omitempty"`
+}
+
+type AccountDiff struct {
+	From          time.Time          `json:"from"`
+	To            time.Time          `json:"to"`
+	Subscriptions []SubscriptionDiff `json:"subscriptions"`
+}
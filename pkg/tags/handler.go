@@ -0,0 +1,171 @@
+package tags
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/models"
+	subscriptiondetails "subscritracker/pkg/subscription-details"
+	"subscritracker/pkg/utils"
+	"subscritracker/pkg/validator"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetTagsHandler lists the tags of the user
+func GetTagsHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	tags, err := GetTags(app, accountID)
+	if err != nil {
+		log.Println("Error getting tags:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get tags"})
+	}
+
+	return c.JSON(http.StatusOK, tags)
+}
+
+// CreateTagHandler creates a tag for the user
+func CreateTagHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	request, err := validator.ValidateLabelRequest(c, "tag", MaxTagNameLength)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	tag := &models.Tag{
+		AccountID: accountID,
+		Name:      request.Name,
+		Color:     request.Color,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := CreateTag(app, tag); err != nil {
+		if errors.Is(err, ErrTagNameTaken) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error creating tag:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create tag"})
+	}
+
+	return c.JSON(http.StatusCreated, tag)
+}
+
+// UpdateTagHandler renames or recolors a tag
+func UpdateTagHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	tagID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tag ID"})
+	}
+
+	request, err := validator.ValidateLabelRequest(c, "tag", MaxTagNameLength)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	tag, err := GetTag(app, accountID, tagID)
+	if err != nil {
+		if errors.Is(err, ErrTagNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error getting tag:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get tag"})
+	}
+
+	tag.Name = request.Name
+	tag.Color = request.Color
+	if err := UpdateTag(app, tag); err != nil {
+		if errors.Is(err, ErrTagNameTaken) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error updating tag:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update tag"})
+	}
+
+	return c.JSON(http.StatusOK, tag)
+}
+
+// DeleteTagHandler deletes a tag and removes it from every subscription
+func DeleteTagHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	tagID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tag ID"})
+	}
+
+	if err := DeleteTag(app, accountID, tagID); err != nil {
+		if errors.Is(err, ErrTagNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error deleting tag:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete tag"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// SetSubscriptionTagsHandler replaces the tags of a subscription with the given set
+func SetSubscriptionTagsHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	subscriptionDetailsID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid subscription details ID"})
+	}
+
+	tagIDs, err := validator.ValidateSubscriptionTagsRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	subscription, err := subscriptiondetails.GetOwnedSubscriptionDetails(app, accountID, subscriptionDetailsID)
+	if err != nil {
+		if errors.Is(err, subscriptiondetails.ErrSubscriptionDetailsNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error getting subscription details:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get subscription details"})
+	}
+
+	ownedTags, err := CountOwnedTags(app, accountID, tagIDs)
+	if err != nil {
+		log.Println("Error checking tags:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to check tags"})
+	}
+	if ownedTags != len(tagIDs) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "tag_ids contains a tag that cannot be found"})
+	}
+
+	if err := ReplaceSubscriptionTags(utils.RequestContext(c), app, subscription, tagIDs); err != nil {
+		log.Println("Error setting subscription tags:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to set tags"})
+	}
+
+	tagsBySubscription, err := subscriptiondetails.GetTagsForSubscriptions(app, []int{subscription.ID})
+	if err != nil {
+		log.Println("Error getting subscription tags:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get tags"})
+	}
+	tags := tagsBySubscription[subscription.ID]
+	if tags == nil {
+		tags = []subscriptiondetails.TagSummary{}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"subscription_details_id": subscription.ID,
+		"tags":                    tags,
+	})
+}
@@ -0,0 +1,159 @@
+package tags
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/models"
+	"subscritracker/pkg/stream"
+	subscriptionversions "subscritracker/pkg/subscription-versions"
+
+	"github.com/uptrace/bun"
+)
+
+// GetTags returns the tags of the account with the number of subscriptions using each
+func GetTags(app *application.App, accountID int) ([]TagWithCount, error) {
+	tags := []TagWithCount{}
+	err := app.Database.NewRaw(`
+		SELECT
+			t.id,
+			t.name,
+			COALESCE(t.color, '') AS color,
+			COUNT(sdt.subscription_details_id) AS subscription_count
+		FROM tags t
+		LEFT JOIN subscription_details_tags sdt ON sdt.tag_id = t.id
+		WHERE t.account_id = ?
+		GROUP BY t.id
+		ORDER BY t.name ASC
+	`, accountID).Scan(context.Background(), &tags)
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// GetTag loads a tag only if it belongs to the account
+func GetTag(app *application.App, accountID, tagID int) (*models.Tag, error) {
+	tag := &models.Tag{}
+	err := app.Database.NewSelect().
+		Model(tag).
+		Where("id = ? AND account_id = ?", tagID, accountID).
+		Scan(context.Background())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTagNotFound
+		}
+		return nil, err
+	}
+
+	return tag, nil
+}
+
+func CreateTag(app *application.App, tag *models.Tag) error {
+	_, err := app.Database.NewInsert().
+		Model(tag).
+		Returning("*").
+		Exec(context.Background())
+	return mapUniqueViolation(err)
+}
+
+func UpdateTag(app *application.App, tag *models.Tag) error {
+	tag.UpdatedAt = time.Now()
+	_, err := app.Database.NewUpdate().
+		Model(tag).
+		Column("name", "color", "updated_at").
+		Where("id = ? AND account_id = ?", tag.ID, tag.AccountID).
+		Exec(context.Background())
+	return mapUniqueViolation(err)
+}
+
+// DeleteTag removes a tag and, through ON DELETE CASCADE, its assignments
+func DeleteTag(app *application.App, accountID, tagID int) error {
+	result, err := app.Database.NewDelete().
+		Model((*models.Tag)(nil)).
+		Where("id = ? AND account_id = ?", tagID, accountID).
+		Exec(context.Background())
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrTagNotFound
+	}
+
+	return nil
+}
+
+// CountOwnedTags returns how many of the tag ids belong to the account
+func CountOwnedTags(app *application.App, accountID int, tagIDs []int) (int, error) {
+	if len(tagIDs) == 0 {
+		return 0, nil
+	}
+	return app.Database.NewSelect().
+		Model((*models.Tag)(nil)).
+		Where("account_id = ? AND id IN (?)", accountID, bun.In(tagIDs)).
+		Count(context.Background())
+}
+
+// ReplaceSubscriptionTags sets the tags of a subscription to exactly tagIDs. The change is recorded as a
+// new version of the subscription and published to the account's stream in the same transaction.
+func ReplaceSubscriptionTags(ctx context.Context, app *application.App, subscription models.Subscription_Details, tagIDs []int) error {
+	return app.Database.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		beforeTagIDs := []int{}
+		err := tx.NewSelect().
+			Model((*models.Subscription_Details_Tag)(nil)).
+			Column("tag_id").
+			Where("subscription_details_id = ?", subscription.ID).
+			Scan(ctx, &beforeTagIDs)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewDelete().
+			Model((*models.Subscription_Details_Tag)(nil)).
+			Where("subscription_details_id = ?", subscription.ID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(tagIDs) > 0 {
+			assignments := make([]models.Subscription_Details_Tag, 0, len(tagIDs))
+			for _, tagID := range tagIDs {
+				assignments = append(assignments, models.Subscription_Details_Tag{
+					SubscriptionDetailsID: subscription.ID,
+					TagID:                 tagID,
+					CreatedAt:             time.Now(),
+				})
+			}
+			if _, err := tx.NewInsert().Model(&assignments).Exec(ctx); err != nil {
+				return err
+			}
+		}
+
+		if err := subscriptionversions.RecordTags(ctx, tx, subscription, beforeTagIDs); err != nil {
+			return err
+		}
+
+		return stream.Publish(ctx, tx, subscription.AccountID, stream.EventSubscriptionUpdated, map[string]interface{}{
+			"subscription_details_id": subscription.ID,
+			"fields":                  []string{"tags"},
+		})
+	})
+}
+
+func mapUniqueViolation(err error) error {
+	if err != nil && strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
+		return ErrTagNameTaken
+	}
+	return err
+}
@@ -0,0 +1,14 @@
+package tags
+
+import (
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/utils"
+)
+
+func RegisterRoutes(app *application.App) {
+	app.Echo.GET("/v1/tags", GetTagsHandler, utils.AuthMiddleware)
+	app.Echo.POST("/v1/tags", CreateTagHandler, utils.AuthMiddleware)
+	app.Echo.PUT("/v1/tags/:id", UpdateTagHandler, utils.AuthMiddleware)
+	app.Echo.DELETE("/v1/tags/:id", DeleteTagHandler, utils.AuthMiddleware)
+	app.Echo.PUT("/v1/subscription-details/:id/tags", SetSubscriptionTagsHandler, utils.AuthMiddleware)
+}
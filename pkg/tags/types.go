@@ -0,0 +1,18 @@
+package tags
+
+import "errors"
+
+// MaxTagNameLength matches the tags.name column
+const MaxTagNameLength = 50
+
+var (
+	ErrTagNotFound  = errors.New("tag not found")
+	ErrTagNameTaken = errors.New("a tag with this name already exists")
+)
+
+type TagWithCount struct {
+	ID                int    `json:"id"`
+	Name              string `json:"name"`
+	Color             string `json:"color,omitempty"`
+	SubscriptionCount int    `json:"subscription_count"`
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/labstack/echo/v4"
@@ -38,3 +39,17 @@ func AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 		return next(c)
 	}
 }
+
+// AdminMiddleware only lets through users whose email is one of the admin emails. Use it after AuthMiddleware.
+func AdminMiddleware(adminEmails []string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			email, _ := c.Get("user_email").(string)
+			if email == "" || !slices.Contains(adminEmails, strings.ToLower(email)) {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "Admin access required"})
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+)
+
+type requestInfoKey struct{}
+
+// RequestInfo identifies the request a change was made in, for audit records
+type RequestInfo struct {
+	RequestID string
+	AccountID int
+}
+
+// RequestContext returns a context carrying the request's id and user for write paths that record
+// who made a change. Like context.Background it is not cancelled when the client goes away,
+// so a started write is not cut short.
+func RequestContext(c echo.Context) context.Context {
+	info := RequestInfo{RequestID: c.Response().Header().Get(echo.HeaderXRequestID)}
+	if accountID, ok := c.Get("user_id").(int); ok {
+		info.AccountID = accountID
+	}
+	return context.WithValue(context.Background(), requestInfoKey{}, info)
+}
+
+// RequestInfoFrom returns the request a context was made for. ok is false for background work.
+func RequestInfoFrom(ctx context.Context) (RequestInfo, bool) {
+	info, ok := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return info, ok
+}
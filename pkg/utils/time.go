@@ -0,0 +1,8 @@
+package utils
+
+import "time"
+
+// TruncateToDay returns midnight UTC of t's calendar day, the form every date column and billing date is compared in
+func TruncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,70 @@
+package validator
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type DefaultCurrencyRequest struct {
+	Currency string `json:"currency" form:"currency"`
+}
+
+type TimezoneRequest struct {
+	Timezone string `json:"timezone" form:"timezone"`
+}
+
+// ValidateDefaultCurrencyRequest validates the user's default currency update
+func ValidateDefaultCurrencyRequest(c echo.Context) (string, error) {
+	var req DefaultCurrencyRequest
+	if err := c.Bind(&req); err != nil {
+		return "", err
+	}
+
+	return NormalizeCurrency(req.Currency, "currency")
+}
+
+// ValidateReportCurrency parses the optional `currency` query parameter of the reports.
+// An empty string means the account's default currency.
+func ValidateReportCurrency(c echo.Context) (string, error) {
+	code := c.QueryParam("currency")
+	if code == "" {
+		return "", nil
+	}
+
+	return NormalizeCurrency(code, "currency")
+}
+
+// ValidateTimezoneRequest validates an IANA timezone name such as "Europe/Berlin"
+func ValidateTimezoneRequest(c echo.Context) (string, error) {
+	var req TimezoneRequest
+	if err := c.Bind(&req); err != nil {
+		return "", err
+	}
+
+	timezone := strings.TrimSpace(req.Timezone)
+	if timezone == "" {
+		return "", errors.New("timezone is required")
+	}
+	location, err := parseTimezone(timezone)
+	if err != nil {
+		return "", err
+	}
+
+	return location.String(), nil
+}
+
+// parseTimezone loads an IANA timezone
+func parseTimezone(timezone string) (*time.Location, error) {
+	// time.LoadLocation treats "" and "Local" as the server's zone, which is not a user timezone
+	if timezone == "" || strings.EqualFold(timezone, "local") {
+		return nil, errors.New("invalid timezone. Expected an IANA name like Europe/Berlin")
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, errors.New("invalid timezone. Expected an IANA name like Europe/Berlin")
+	}
+	return location, nil
+}
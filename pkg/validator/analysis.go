@@ -0,0 +1,230 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// maxReportMonths bounds the months one report covers
+	maxReportMonths = 120
+	// defaultForecastMonths and maxForecastMonths bound how far ahead a forecast looks
+	defaultForecastMonths = 12
+	maxForecastMonths     = 36
+	// maxComparisonDays bounds each period of a custom comparison
+	maxComparisonDays = 3660
+)
+
+var validComparisonPeriods = []string{"month", "year", "custom"}
+
+type MonthRangeRequest struct {
+	From       string    `query:"from"`
+	To         string    `query:"to"`
+	ParsedFrom time.Time `query:"-"`
+	ParsedTo   time.Time `query:"-"`
+}
+
+// ValidateMonthRange parses the from and to months of a report, both YYYY-MM and inclusive.
+// They default to January and December of the current year; a single month is from and to alike.
+func ValidateMonthRange(c echo.Context) (*MonthRangeRequest, error) {
+	var req MonthRangeRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, errors.New("invalid month range parameters")
+	}
+
+	year := time.Now().Year()
+	req.ParsedFrom = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	req.ParsedTo = time.Date(year, time.December, 1, 0, 0, 0, 0, time.UTC)
+
+	var err error
+	if req.From != "" {
+		if req.ParsedFrom, err = parseMonth(req.From, "from"); err != nil {
+			return nil, err
+		}
+		if req.To == "" {
+			req.ParsedTo = req.ParsedFrom
+		}
+	}
+	if req.To != "" {
+		if req.ParsedTo, err = parseMonth(req.To, "to"); err != nil {
+			return nil, err
+		}
+		if req.From == "" {
+			req.ParsedFrom = req.ParsedTo
+		}
+	}
+
+	if req.ParsedTo.Before(req.ParsedFrom) {
+		return nil, errors.New("to must not be before from")
+	}
+	if months := MonthsBetween(req.ParsedFrom, req.ParsedTo) + 1; months > maxReportMonths {
+		return nil, fmt.Errorf("the range covers %d months. At most %d are allowed", months, maxReportMonths)
+	}
+
+	return &req, nil
+}
+
+type MonthRequest struct {
+	Month       string         `query:"month"`
+	Timezone    string         `query:"tz"`
+	ParsedMonth time.Time      `query:"-"`
+	Location    *time.Location `query:"-"`
+}
+
+// ValidateMonthRequest parses the month of a report, YYYY-MM, and the timezone that decides which
+// day is today. The timezone defaults to defaultTimezone, then UTC; the month to the current one there.
+func ValidateMonthRequest(c echo.Context, defaultTimezone string) (*MonthRequest, error) {
+	var req MonthRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, errors.New("invalid month parameters")
+	}
+
+	req.Location = time.UTC
+	if req.Timezone = strings.TrimSpace(req.Timezone); req.Timezone != "" {
+		location, err := parseTimezone(req.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		req.Location = location
+	} else if location, err := parseTimezone(defaultTimezone); err == nil {
+		req.Location = location
+	}
+
+	if req.Month == "" {
+		now := time.Now().In(req.Location)
+		req.ParsedMonth = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return &req, nil
+	}
+
+	month, err := parseMonth(req.Month, "month")
+	if err != nil {
+		return nil, err
+	}
+	req.ParsedMonth = month
+
+	return &req, nil
+}
+
+// ValidateForecastMonths parses how many months a forecast covers, the current one included
+func ValidateForecastMonths(c echo.Context) (int, error) {
+	value := c.QueryParam("months")
+	if value == "" {
+		return defaultForecastMonths, nil
+	}
+
+	months, err := strconv.Atoi(value)
+	if err != nil || months < 1 || months > maxForecastMonths {
+		return 0, fmt.Errorf("months must be a number between 1 and %d", maxForecastMonths)
+	}
+	return months, nil
+}
+
+// ComparisonRequest selects the two periods to compare: a month with the month before, a year with the
+// year before, or a custom from/to range with compare_from/compare_to, by default the range right before it
+type ComparisonRequest struct {
+	Period         string    `query:"period"`
+	Month          string    `query:"month"`
+	Year           int       `query:"year"`
+	From           string    `query:"from"`
+	To             string    `query:"to"`
+	CompareFrom    string    `query:"compare_from"`
+	CompareTo      string    `query:"compare_to"`
+	ParsedFrom     time.Time `query:"-"`
+	ParsedTo       time.Time `query:"-"`
+	ParsedPrevFrom time.Time `query:"-"`
+	ParsedPrevTo   time.Time `query:"-"`
+}
+
+// ValidateComparisonRequest parses the periods to compare. The month and year default to the ones
+// today falls in. Every bound is a day and inclusive.
+func ValidateComparisonRequest(c echo.Context, today time.Time) (*ComparisonRequest, error) {
+	var req ComparisonRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, errors.New("invalid comparison parameters")
+	}
+
+	req.Period = defaultIfEmpty(strings.ToLower(strings.TrimSpace(req.Period)), "month")
+	if !validateEnum(req.Period, validComparisonPeriods) {
+		return nil, errors.New("invalid period. Must be one of: month, year, custom")
+	}
+
+	switch req.Period {
+	case "month":
+		month := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+		if req.Month != "" {
+			parsed, err := parseMonth(req.Month, "month")
+			if err != nil {
+				return nil, err
+			}
+			month = parsed
+		}
+		req.ParsedFrom, req.ParsedTo = month, month.AddDate(0, 1, -1)
+		req.ParsedPrevFrom, req.ParsedPrevTo = month.AddDate(0, -1, 0), month.AddDate(0, 0, -1)
+	case "year":
+		year := today.Year()
+		if req.Year != 0 {
+			if req.Year < 1900 || req.Year > 9999 {
+				return nil, errors.New("invalid year")
+			}
+			year = req.Year
+		}
+		req.ParsedFrom = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		req.ParsedTo = time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+		req.ParsedPrevFrom, req.ParsedPrevTo = req.ParsedFrom.AddDate(-1, 0, 0), req.ParsedFrom.AddDate(0, 0, -1)
+	case "custom":
+		var err error
+		if req.ParsedFrom, req.ParsedTo, err = parseDayRange(req.From, req.To, "from", "to"); err != nil {
+			return nil, err
+		}
+		if req.CompareFrom == "" && req.CompareTo == "" {
+			days := int(req.ParsedTo.Sub(req.ParsedFrom).Hours()/24) + 1
+			req.ParsedPrevTo = req.ParsedFrom.AddDate(0, 0, -1)
+			req.ParsedPrevFrom = req.ParsedFrom.AddDate(0, 0, -days)
+		} else if req.ParsedPrevFrom, req.ParsedPrevTo, err = parseDayRange(req.CompareFrom, req.CompareTo, "compare_from", "compare_to"); err != nil {
+			return nil, err
+		}
+	}
+
+	return &req, nil
+}
+
+// parseDayRange parses a required YYYY-MM-DD range of at most maxComparisonDays days
+func parseDayRange(fromValue, toValue, fromField, toField string) (time.Time, time.Time, error) {
+	if fromValue == "" || toValue == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("%s and %s are required", fromField, toField)
+	}
+	from, err := parseDate(fromValue, fromField)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	to, err := parseDate(toValue, toField)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if to.Before(*from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%s must not be before %s", toField, fromField)
+	}
+	if to.Sub(*from) >= maxComparisonDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("a period can cover at most %d days", maxComparisonDays)
+	}
+	return *from, *to, nil
+}
+
+// MonthsBetween counts the whole months from one month to another
+func MonthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
+
+// parseMonth parses a YYYY-MM month into its first day in UTC
+func parseMonth(value, field string) (time.Time, error) {
+	month, err := time.Parse("2006-01", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s format. Expected YYYY-MM", field)
+	}
+	return month, nil
+}
@@ -0,0 +1,100 @@
+package validator
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"subscritracker/pkg/money"
+
+	"github.com/labstack/echo/v4"
+)
+
+var validBudgetScopes = []string{"overall", "category", "tag"}
+
+// BudgetRequest creates or updates a budget. The scope and its category or tag are set when the budget
+// is created and cannot change; on update, fields that are not given keep their current value.
+type BudgetRequest struct {
+	Scope                string      `json:"scope" form:"scope"`
+	CategoryID           *int        `json:"category_id" form:"category_id"`
+	TagID                *int        `json:"tag_id" form:"tag_id"`
+	Amount               json.Number `json:"amount" form:"amount"`
+	Currency             string      `json:"currency" form:"currency"`
+	NearThresholdPercent *int        `json:"near_threshold_percent" form:"near_threshold_percent"`
+}
+
+type ParsedBudget struct {
+	Scope                string
+	CategoryID           *int
+	TagID                *int
+	Amount               *money.Amount
+	Currency             string
+	NearThresholdPercent *int
+}
+
+// ValidateBudgetRequest validates a budget. Creating one requires the scope, the category_id or tag_id
+// it needs, and the amount; an empty currency means the account's default currency.
+func ValidateBudgetRequest(c echo.Context, creating bool) (*ParsedBudget, error) {
+	var req BudgetRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, err
+	}
+
+	parsed := &ParsedBudget{}
+	if creating {
+		parsed.Scope = strings.ToLower(strings.TrimSpace(req.Scope))
+		if !validateEnum(parsed.Scope, validBudgetScopes) {
+			return nil, errors.New("invalid scope. Must be one of: overall, category, tag")
+		}
+
+		switch parsed.Scope {
+		case "category":
+			if req.CategoryID == nil || *req.CategoryID <= 0 {
+				return nil, errors.New("category_id must be a positive integer for a category budget")
+			}
+			parsed.CategoryID = req.CategoryID
+		case "tag":
+			if req.TagID == nil || *req.TagID <= 0 {
+				return nil, errors.New("tag_id must be a positive integer for a tag budget")
+			}
+			parsed.TagID = req.TagID
+		}
+		if (parsed.Scope != "category" && req.CategoryID != nil) || (parsed.Scope != "tag" && req.TagID != nil) {
+			return nil, errors.New("only category budgets take a category_id and only tag budgets a tag_id")
+		}
+
+		if req.Amount == "" {
+			return nil, errors.New("amount is required")
+		}
+	} else if req.Scope != "" || req.CategoryID != nil || req.TagID != nil {
+		return nil, errors.New("the scope of a budget cannot be changed. Create a new budget instead")
+	}
+
+	if req.Amount != "" {
+		amount, err := ParseMoneyAmount(req.Amount.String(), "amount")
+		if err != nil {
+			return nil, err
+		}
+		if amount.IsZero() {
+			return nil, errors.New("amount must be greater than 0")
+		}
+		parsed.Amount = &amount
+	}
+
+	if req.Currency != "" {
+		currency, err := NormalizeCurrency(req.Currency, "currency")
+		if err != nil {
+			return nil, err
+		}
+		parsed.Currency = currency
+	}
+
+	if req.NearThresholdPercent != nil {
+		if *req.NearThresholdPercent < 1 || *req.NearThresholdPercent > 100 {
+			return nil, errors.New("near_threshold_percent must be between 1 and 100")
+		}
+		parsed.NearThresholdPercent = req.NearThresholdPercent
+	}
+
+	return parsed, nil
+}
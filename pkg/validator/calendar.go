@@ -0,0 +1,21 @@
+package validator
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ValidateCalendarFeedMonths parses how many months ahead the feed covers, defaulting to def
+func ValidateCalendarFeedMonths(c echo.Context, def int) (int, error) {
+	monthsStr := c.QueryParam("months")
+	if monthsStr == "" {
+		return def, nil
+	}
+	months, err := strconv.Atoi(monthsStr)
+	if err != nil || months <= 0 || months > 24 {
+		return 0, errors.New("months must be an integer between 1 and 24")
+	}
+	return months, nil
+}
@@ -0,0 +1,88 @@
+package validator
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+var hexColorRegex = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// LabelRequest is the body for creating or updating a category or a tag
+type LabelRequest struct {
+	Name  string `json:"name" form:"name"`
+	Color string `json:"color" form:"color"`
+}
+
+type SubscriptionCategoryRequest struct {
+	CategoryID *int `json:"category_id" form:"category_id"`
+}
+
+type SubscriptionTagsRequest struct {
+	TagIDs []int `json:"tag_ids" form:"tag_ids"`
+}
+
+// ValidateLabelRequest validates a category or tag name (max maxLength characters) and optional #RRGGBB color
+func ValidateLabelRequest(c echo.Context, fieldName string, maxLength int) (*LabelRequest, error) {
+	var req LabelRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, err
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if err := IsValidString(req.Name, fieldName+" name"); err != nil {
+		return nil, err
+	}
+	if err := IsValidLength(req.Name, fieldName+" name", 1, maxLength); err != nil {
+		return nil, err
+	}
+
+	req.Color = strings.TrimSpace(req.Color)
+	if req.Color != "" && !hexColorRegex.MatchString(req.Color) {
+		return nil, errors.New("color must be a hex color like #1E90FF")
+	}
+
+	return &req, nil
+}
+
+// ValidateSubscriptionCategoryRequest validates a category assignment. A null category_id clears it.
+func ValidateSubscriptionCategoryRequest(c echo.Context) (*int, error) {
+	var req SubscriptionCategoryRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, err
+	}
+
+	if req.CategoryID != nil && *req.CategoryID <= 0 {
+		return nil, errors.New("category_id must be a positive integer")
+	}
+
+	return req.CategoryID, nil
+}
+
+// ValidateSubscriptionTagsRequest validates the full set of tags for a subscription, removing duplicates
+func ValidateSubscriptionTagsRequest(c echo.Context) ([]int, error) {
+	var req SubscriptionTagsRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, err
+	}
+
+	seen := map[int]bool{}
+	tagIDs := []int{}
+	for _, tagID := range req.TagIDs {
+		if tagID <= 0 {
+			return nil, errors.New("tag_ids must be positive integers")
+		}
+		if !seen[tagID] {
+			seen[tagID] = true
+			tagIDs = append(tagIDs, tagID)
+		}
+	}
+
+	if len(tagIDs) > 50 {
+		return nil, errors.New("a subscription can have at most 50 tags")
+	}
+
+	return tagIDs, nil
+}
@@ -1,9 +1,12 @@
 package validator
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
+
+	"subscritracker/pkg/money"
 )
 
 // Common validation functions that can be used across the application
@@ -75,3 +78,22 @@ func IsValidLength(value, fieldName string, min, max int) error {
 	}
 	return nil
 }
+
+// ParseMoneyAmount parses an exact amount, rejecting negatives, more than 2 decimals
+// and values that do not fit NUMERIC(10, 2)
+func ParseMoneyAmount(value, fieldName string) (money.Amount, error) {
+	amount, err := money.ParseAmount(value)
+	if err != nil {
+		if errors.Is(err, money.ErrTooManyDecimal) {
+			return 0, fmt.Errorf("%s cannot have more than 2 decimal places", fieldName)
+		}
+		return 0, fmt.Errorf("%s must be a valid amount", fieldName)
+	}
+	if amount.IsNegative() {
+		return 0, fmt.Errorf("%s cannot be negative", fieldName)
+	}
+	if amount > money.MaxAmount {
+		return 0, fmt.Errorf("%s must be less than %s", fieldName, money.MaxAmount.String())
+	}
+	return amount, nil
+}
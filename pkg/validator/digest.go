@@ -0,0 +1,80 @@
+package validator
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	validDigestFrequencies = []string{"weekly", "monthly"}
+	validDigestFormats     = []string{"json", "html", "text"}
+)
+
+// DigestSettingsRequest updates the digest settings. Fields that are not given keep their current value.
+type DigestSettingsRequest struct {
+	Enabled     *bool      `json:"enabled" form:"enabled"`
+	Frequency   string     `json:"frequency" form:"frequency"`
+	SendTime    string     `json:"send_time" form:"send_time"`
+	SendWeekday *int       `json:"send_weekday" form:"send_weekday"`
+	ParsedTime  *time.Time `json:"-" form:"-"`
+}
+
+type DigestPreviewRequest struct {
+	Frequency string `query:"frequency"`
+	Format    string `query:"format"`
+}
+
+// ValidateDigestSettingsRequest validates a digest settings update. send_time is a local HH:MM and
+// send_weekday the day weekly digests go out, 0 (Sunday) to 6 (Saturday).
+func ValidateDigestSettingsRequest(c echo.Context) (*DigestSettingsRequest, error) {
+	var req DigestSettingsRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, err
+	}
+
+	req.Frequency = strings.ToLower(strings.TrimSpace(req.Frequency))
+	if req.Frequency != "" && !validateEnum(req.Frequency, validDigestFrequencies) {
+		return nil, errors.New("invalid frequency. Must be one of: weekly, monthly")
+	}
+
+	if req.SendTime != "" {
+		parsed, err := parseTimeOfDay(req.SendTime)
+		if err != nil {
+			return nil, errors.New("invalid send_time. Expected HH:MM (e.g., 08:00)")
+		}
+		req.ParsedTime = parsed
+	}
+
+	if req.SendWeekday != nil && (*req.SendWeekday < 0 || *req.SendWeekday > 6) {
+		return nil, errors.New("send_weekday must be between 0 (Sunday) and 6 (Saturday)")
+	}
+
+	return &req, nil
+}
+
+// ValidateDigestPreviewRequest validates the digest preview parameters. The format defaults to json
+// and an empty frequency means the one in the user's settings.
+func ValidateDigestPreviewRequest(c echo.Context) (*DigestPreviewRequest, error) {
+	var req DigestPreviewRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, errors.New("invalid preview parameters")
+	}
+
+	req.Frequency = strings.ToLower(strings.TrimSpace(req.Frequency))
+	if req.Frequency != "" && !validateEnum(req.Frequency, validDigestFrequencies) {
+		return nil, errors.New("invalid frequency. Must be one of: weekly, monthly")
+	}
+
+	req.Format = strings.ToLower(strings.TrimSpace(req.Format))
+	if req.Format == "" {
+		req.Format = "json"
+	}
+	if !validateEnum(req.Format, validDigestFormats) {
+		return nil, errors.New("invalid format. Must be one of: json, html, text")
+	}
+
+	return &req, nil
+}
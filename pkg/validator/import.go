@@ -0,0 +1,78 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MaxImportFileSize caps uploaded CSV files at 1 MB
+const MaxImportFileSize = 1 << 20
+
+type ImportRequest struct {
+	Data   []byte
+	DryRun bool
+}
+
+// ValidateImportRequest reads a CSV upload sent either as the multipart field `file`
+// or as the raw request body, plus the optional `dry_run` query parameter
+func ValidateImportRequest(c echo.Context) (*ImportRequest, error) {
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := readUpload(c, MaxImportFileSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ImportRequest{Data: data, DryRun: dryRun}, nil
+}
+
+// parseDryRun reads the optional `dry_run` query parameter
+func parseDryRun(c echo.Context) (bool, error) {
+	dryRun := c.QueryParam("dry_run")
+	if dryRun == "" {
+		return false, nil
+	}
+	parsed, err := strconv.ParseBool(dryRun)
+	if err != nil {
+		return false, errors.New("dry_run must be true or false")
+	}
+	return parsed, nil
+}
+
+// readUpload returns the multipart field `file`, or the raw body when no file was sent
+func readUpload(c echo.Context, maxSize int64) ([]byte, error) {
+	var reader io.Reader
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		if fileHeader.Size > maxSize {
+			return nil, fmt.Errorf("file cannot be larger than %d bytes", maxSize)
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return nil, errors.New("failed to read uploaded file")
+		}
+		defer file.Close()
+		reader = file
+	} else {
+		reader = c.Request().Body
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxSize+1))
+	if err != nil {
+		return nil, errors.New("failed to read uploaded file")
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("file cannot be larger than %d bytes", maxSize)
+	}
+	if len(data) == 0 {
+		return nil, errors.New("file is required")
+	}
+
+	return data, nil
+}
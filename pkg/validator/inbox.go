@@ -0,0 +1,39 @@
+package validator
+
+import (
+	"errors"
+
+	"github.com/labstack/echo/v4"
+)
+
+type InboxFilters struct {
+	Unread       bool    `query:"unread"`
+	Type         string  `query:"type"`
+	Cursor       string  `query:"cursor"`
+	Limit        int     `query:"limit"`
+	ParsedCursor *Cursor `query:"-"`
+}
+
+// ValidateInboxFilters parses the inbox filters. Pages are ordered by id, newest first.
+func ValidateInboxFilters(c echo.Context) (*InboxFilters, error) {
+	var filters InboxFilters
+	if err := c.Bind(&filters); err != nil {
+		return nil, errors.New("invalid filter parameters")
+	}
+
+	if filters.Type != "" && !validateEnum(filters.Type, validNotificationEventTypes) {
+		return nil, errors.New("invalid type. Must be one of: reminder, price_change, trial_ending, renewal_failed, digest, budget_alert")
+	}
+
+	limit, err := ValidatePageLimit(filters.Limit)
+	if err != nil {
+		return nil, err
+	}
+	filters.Limit = limit
+
+	if filters.ParsedCursor, err = DecodeCursor(filters.Cursor); err != nil {
+		return nil, err
+	}
+
+	return &filters, nil
+}
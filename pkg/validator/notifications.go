@@ -0,0 +1,296 @@
+package validator
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"subscritracker/pkg/models"
+	"subscritracker/pkg/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+// hostLookupTimeout bounds the DNS lookup of a notification or webhook URL's host
+const hostLookupTimeout = 5 * time.Second
+
+var (
+	validNotificationChannelTypes   = []string{"email", "webhook", "web_push", "slack", "discord"}
+	validNotificationEventTypes     = []string{"reminder", "price_change", "trial_ending", "renewal_failed", "digest", "budget_alert"}
+	validNotificationDispatchStatus = []string{"pending", "sending", "sent", "failed"}
+)
+
+// NotificationChannelRequest is the body for creating or updating a notification channel.
+// Config takes the fields of the channel type: email, url, or a browser push subscription (endpoint and keys).
+type NotificationChannelRequest struct {
+	Type    string                           `json:"type" form:"type"`
+	Name    string                           `json:"name" form:"name"`
+	Config  NotificationChannelConfigRequest `json:"config" form:"config"`
+	Enabled *bool                            `json:"enabled" form:"enabled"`
+}
+
+type NotificationChannelConfigRequest struct {
+	Email    string `json:"email"`
+	URL      string `json:"url"`
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+type NotificationPreferencesRequest struct {
+	Preferences     []NotificationPreferenceRequest `json:"preferences"`
+	QuietHoursStart *string                         `json:"quiet_hours_start"`
+	QuietHoursEnd   *string                         `json:"quiet_hours_end"`
+}
+
+// NotificationPreferenceRequest routes an event type to channels. A null channel_ids resets the event to all channels.
+type NotificationPreferenceRequest struct {
+	EventType  string `json:"event_type"`
+	ChannelIDs *[]int `json:"channel_ids"`
+}
+
+// NotificationPreferences is a validated preferences update. Quiet hours are cleared when both are nil.
+type NotificationPreferences struct {
+	Preferences     []NotificationPreferenceRequest
+	QuietHoursStart *time.Time
+	QuietHoursEnd   *time.Time
+}
+
+type NotificationDispatchFilters struct {
+	Status       string  `query:"status"`
+	ChannelID    int     `query:"channel_id"`
+	EventType    string  `query:"event_type"`
+	Cursor       string  `query:"cursor"`
+	Limit        int     `query:"limit"`
+	ParsedCursor *Cursor `query:"-"`
+}
+
+// ValidateNotificationChannelRequest validates a new channel and returns its stored config
+func ValidateNotificationChannelRequest(c echo.Context) (*NotificationChannelRequest, *models.Notification_Channel_Config, error) {
+	var req NotificationChannelRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, nil, err
+	}
+
+	req.Type = strings.TrimSpace(req.Type)
+	if !validateEnum(req.Type, validNotificationChannelTypes) {
+		return nil, nil, errors.New("invalid type. Must be one of: email, webhook, web_push, slack, discord")
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		req.Name = req.Type
+	}
+	if err := IsValidLength(req.Name, "name", 1, 100); err != nil {
+		return nil, nil, err
+	}
+
+	config, err := validateNotificationChannelConfig(req.Type, req.Config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &req, config, nil
+}
+
+// ValidateNotificationChannelUpdate validates a channel update. The type cannot change; a config is only
+// replaced when one is given.
+func ValidateNotificationChannelUpdate(c echo.Context, channelType string) (*NotificationChannelRequest, *models.Notification_Channel_Config, error) {
+	var req NotificationChannelRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, nil, err
+	}
+
+	if req.Type != "" && req.Type != channelType {
+		return nil, nil, errors.New("the type of a notification channel cannot be changed")
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name != "" {
+		if err := IsValidLength(req.Name, "name", 1, 100); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if req.Config == (NotificationChannelConfigRequest{}) {
+		return &req, nil, nil
+	}
+	config, err := validateNotificationChannelConfig(channelType, req.Config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &req, config, nil
+}
+
+func validateNotificationChannelConfig(channelType string, req NotificationChannelConfigRequest) (*models.Notification_Channel_Config, error) {
+	req.URL = strings.TrimSpace(req.URL)
+	req.Endpoint = strings.TrimSpace(req.Endpoint)
+	switch channelType {
+	case "email":
+		email := strings.TrimSpace(req.Email)
+		if !IsValidEmail(email) {
+			return nil, errors.New("config.email must be a valid email address")
+		}
+		return &models.Notification_Channel_Config{Email: email}, nil
+
+	case "webhook":
+		if err := validateNotificationURL(req.URL, "config.url"); err != nil {
+			return nil, err
+		}
+		return &models.Notification_Channel_Config{URL: req.URL}, nil
+
+	case "slack":
+		if err := validateNotificationURL(req.URL, "config.url", "hooks.slack.com"); err != nil {
+			return nil, err
+		}
+		return &models.Notification_Channel_Config{URL: req.URL}, nil
+
+	case "discord":
+		if err := validateNotificationURL(req.URL, "config.url", "discord.com", "discordapp.com"); err != nil {
+			return nil, err
+		}
+		return &models.Notification_Channel_Config{URL: req.URL}, nil
+
+	case "web_push":
+		if err := validateNotificationURL(req.Endpoint, "config.endpoint"); err != nil {
+			return nil, err
+		}
+		if key, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(req.Keys.P256dh, "=")); err != nil || len(key) != 65 || key[0] != 4 {
+			return nil, errors.New("config.keys.p256dh must be the base64url encoded P-256 key of the push subscription")
+		}
+		if secret, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(req.Keys.Auth, "=")); err != nil || len(secret) != 16 {
+			return nil, errors.New("config.keys.auth must be the base64url encoded auth secret of the push subscription")
+		}
+		return &models.Notification_Channel_Config{
+			Endpoint: req.Endpoint,
+			P256dh:   strings.TrimRight(req.Keys.P256dh, "="),
+			Auth:     strings.TrimRight(req.Keys.Auth, "="),
+		}, nil
+	}
+
+	return nil, errors.New("invalid notification channel type")
+}
+
+// validateNotificationURL requires an https URL, on one of hosts when any are given. The host must
+// resolve to public addresses only, so the server cannot be pointed at internal services.
+func validateNotificationURL(value, field string, hosts ...string) error {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		return errors.New(field + " must be an https URL")
+	}
+
+	allowed := len(hosts) == 0
+	for _, host := range hosts {
+		if parsed.Hostname() == host || strings.HasSuffix(parsed.Hostname(), "."+host) {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return errors.New(field + " must be an incoming webhook URL on " + strings.Join(hosts, " or "))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hostLookupTimeout)
+	defer cancel()
+	if err := utils.CheckPublicHost(ctx, parsed.Hostname()); err != nil {
+		if errors.Is(err, utils.ErrPrivateAddress) {
+			return errors.New(field + " must not point to a private or local address")
+		}
+		return errors.New(field + " host cannot be resolved")
+	}
+
+	return nil
+}
+
+// ValidateNotificationPreferencesRequest validates the event routing and quiet hours of an account.
+// Quiet hours are given as HH:MM and may wrap around midnight; both or neither must be set.
+func ValidateNotificationPreferencesRequest(c echo.Context) (*NotificationPreferences, error) {
+	var req NotificationPreferencesRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for i, preference := range req.Preferences {
+		if !validateEnum(preference.EventType, validNotificationEventTypes) {
+			return nil, errors.New("invalid event_type. Must be one of: reminder, price_change, trial_ending, renewal_failed, digest, budget_alert")
+		}
+		if seen[preference.EventType] {
+			return nil, errors.New("each event_type can only be given once")
+		}
+		seen[preference.EventType] = true
+
+		if preference.ChannelIDs == nil {
+			continue
+		}
+		channelIDs := []int{}
+		unique := map[int]bool{}
+		for _, channelID := range *preference.ChannelIDs {
+			if channelID <= 0 {
+				return nil, errors.New("channel_ids must be positive integers")
+			}
+			if !unique[channelID] {
+				unique[channelID] = true
+				channelIDs = append(channelIDs, channelID)
+			}
+		}
+		req.Preferences[i].ChannelIDs = &channelIDs
+	}
+
+	preferences := &NotificationPreferences{Preferences: req.Preferences}
+	if (req.QuietHoursStart == nil || *req.QuietHoursStart == "") != (req.QuietHoursEnd == nil || *req.QuietHoursEnd == "") {
+		return nil, errors.New("quiet_hours_start and quiet_hours_end must be set together")
+	}
+	if req.QuietHoursStart != nil && *req.QuietHoursStart != "" {
+		start, err := parseTimeOfDay(*req.QuietHoursStart)
+		if err != nil {
+			return nil, errors.New("invalid quiet_hours_start. Expected HH:MM (e.g., 22:00)")
+		}
+		end, err := parseTimeOfDay(*req.QuietHoursEnd)
+		if err != nil {
+			return nil, errors.New("invalid quiet_hours_end. Expected HH:MM (e.g., 07:00)")
+		}
+		if start.Equal(*end) {
+			return nil, errors.New("quiet_hours_start and quiet_hours_end must be different")
+		}
+		preferences.QuietHoursStart = start
+		preferences.QuietHoursEnd = end
+	}
+
+	return preferences, nil
+}
+
+// ValidateNotificationDispatchFilters parses the dispatch log filters. Pages are ordered by id, newest first.
+func ValidateNotificationDispatchFilters(c echo.Context) (*NotificationDispatchFilters, error) {
+	var filters NotificationDispatchFilters
+	if err := c.Bind(&filters); err != nil {
+		return nil, errors.New("invalid filter parameters")
+	}
+
+	if filters.Status != "" && !validateEnum(filters.Status, validNotificationDispatchStatus) {
+		return nil, errors.New("invalid status. Must be one of: pending, sending, sent, failed")
+	}
+	if filters.EventType != "" && !validateEnum(filters.EventType, validNotificationEventTypes) {
+		return nil, errors.New("invalid event_type. Must be one of: reminder, price_change, trial_ending, renewal_failed, digest, budget_alert")
+	}
+	if filters.ChannelID < 0 {
+		return nil, errors.New("channel_id must be a positive integer")
+	}
+
+	limit, err := ValidatePageLimit(filters.Limit)
+	if err != nil {
+		return nil, err
+	}
+	filters.Limit = limit
+
+	if filters.ParsedCursor, err = DecodeCursor(filters.Cursor); err != nil {
+		return nil, err
+	}
+
+	return &filters, nil
+}
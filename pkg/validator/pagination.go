@@ -0,0 +1,57 @@
+package validator
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	DefaultPageLimit = 25
+	MaxPageLimit     = 100
+)
+
+// Cursor points just after the last row of a page for keyset pagination.
+// Key is the sort column value of that row as text, Sort is the ordering the cursor was issued for.
+type Cursor struct {
+	Sort string `json:"s,omitempty"`
+	Key  string `json:"k,omitempty"`
+	ID   int    `json:"id"`
+}
+
+// EncodeCursor serializes a cursor into an opaque url-safe string
+func EncodeCursor(cursor Cursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor created by EncodeCursor. An empty string means the first page.
+func DecodeCursor(value string) (*Cursor, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID <= 0 {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return &cursor, nil
+}
+
+// ValidatePageLimit applies the default page size and rejects out of range limits
+func ValidatePageLimit(limit int) (int, error) {
+	if limit == 0 {
+		return DefaultPageLimit, nil
+	}
+	if limit < 0 || limit > MaxPageLimit {
+		return 0, fmt.Errorf("limit must be between 1 and %d", MaxPageLimit)
+	}
+	return limit, nil
+}
@@ -0,0 +1,219 @@
+package validator
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"subscritracker/pkg/money"
+
+	"github.com/labstack/echo/v4"
+)
+
+var validPaymentMethods = []string{"card", "bank_transfer", "direct_debit", "paypal", "cash", "other"}
+
+// MaxReconciliationDays caps the period a reconciliation covers
+const MaxReconciliationDays = 366
+
+type PaymentRequest struct {
+	SubscriptionDetailsID int         `json:"subscription_details_id" form:"subscription_details_id"`
+	ExpectedChargeID      *int        `json:"expected_charge_id" form:"expected_charge_id"`
+	Amount                json.Number `json:"amount" form:"amount"`
+	Currency              string      `json:"currency" form:"currency"`
+	PaidAt                string      `json:"paid_at" form:"paid_at"`
+	Method                string      `json:"method" form:"method"`
+	ReceiptReference      string      `json:"receipt_reference" form:"receipt_reference"`
+	Notes                 string      `json:"notes" form:"notes"`
+}
+
+type ParsedPayment struct {
+	SubscriptionDetailsID int
+	ExpectedChargeID      *int
+	Amount                money.Amount
+	Currency              string
+	PaidAt                time.Time
+	Method                string
+	ReceiptReference      string
+	Notes                 string
+}
+
+type VoidPaymentRequest struct {
+	Reason string `json:"reason" form:"reason"`
+}
+
+type PaymentFilters struct {
+	SubscriptionDetailsID int        `query:"subscription_details_id"`
+	From                  string     `query:"from"`
+	To                    string     `query:"to"`
+	IncludeVoided         bool       `query:"include_voided"`
+	Cursor                string     `query:"cursor"`
+	Limit                 int        `query:"limit"`
+	ParsedFrom            *time.Time `query:"-"`
+	ParsedTo              *time.Time `query:"-"`
+	ParsedCursor          *Cursor    `query:"-"`
+}
+
+type ReconciliationRequest struct {
+	SubscriptionDetailsID int       `query:"subscription_details_id"`
+	From                  string    `query:"from"`
+	To                    string    `query:"to"`
+	ParsedFrom            time.Time `query:"-"`
+	ParsedTo              time.Time `query:"-"`
+}
+
+// ValidatePaymentRequest validates a payment. paid_at is RFC 3339 or YYYY-MM-DD and defaults to now;
+// the currency defaults to the subscription's and the method to other.
+func ValidatePaymentRequest(c echo.Context) (*ParsedPayment, error) {
+	var req PaymentRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, err
+	}
+
+	if req.SubscriptionDetailsID <= 0 {
+		return nil, errors.New("subscription_details_id must be a positive integer")
+	}
+	if req.ExpectedChargeID != nil && *req.ExpectedChargeID <= 0 {
+		return nil, errors.New("expected_charge_id must be a positive integer")
+	}
+
+	if req.Amount == "" {
+		return nil, errors.New("amount is required")
+	}
+	amount, err := ParseMoneyAmount(req.Amount.String(), "amount")
+	if err != nil {
+		return nil, err
+	}
+	if amount.IsZero() {
+		return nil, errors.New("amount must be greater than 0")
+	}
+
+	parsed := &ParsedPayment{
+		SubscriptionDetailsID: req.SubscriptionDetailsID,
+		ExpectedChargeID:      req.ExpectedChargeID,
+		Amount:                amount,
+		PaidAt:                time.Now(),
+		Method:                defaultIfEmpty(strings.ToLower(strings.TrimSpace(req.Method)), "other"),
+		ReceiptReference:      strings.TrimSpace(req.ReceiptReference),
+		Notes:                 strings.TrimSpace(req.Notes),
+	}
+
+	if req.Currency != "" {
+		if parsed.Currency, err = NormalizeCurrency(req.Currency, "currency"); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.PaidAt != "" {
+		paidAt, err := time.Parse(time.RFC3339, req.PaidAt)
+		if err != nil {
+			date, dateErr := parseDate(req.PaidAt, "paid_at")
+			if dateErr != nil {
+				return nil, errors.New("invalid paid_at format. Expected RFC 3339 or YYYY-MM-DD")
+			}
+			paidAt = *date
+		}
+		if paidAt.After(time.Now().Add(24 * time.Hour)) {
+			return nil, errors.New("paid_at cannot be in the future")
+		}
+		parsed.PaidAt = paidAt
+	}
+
+	if !validateEnum(parsed.Method, validPaymentMethods) {
+		return nil, errors.New("invalid method. Must be one of: card, bank_transfer, direct_debit, paypal, cash, other")
+	}
+	if err := IsValidLength(parsed.ReceiptReference, "receipt_reference", 0, 200); err != nil {
+		return nil, err
+	}
+	if err := IsValidLength(parsed.Notes, "notes", 0, 1000); err != nil {
+		return nil, err
+	}
+
+	return parsed, nil
+}
+
+// ValidateVoidPaymentRequest returns the optional reason a payment is voided
+func ValidateVoidPaymentRequest(c echo.Context) (string, error) {
+	var req VoidPaymentRequest
+	if err := c.Bind(&req); err != nil {
+		return "", err
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if err := IsValidLength(reason, "reason", 0, 500); err != nil {
+		return "", err
+	}
+
+	return reason, nil
+}
+
+// ValidatePaymentFilters parses the payment list filters. from and to filter on the payment date,
+// pages are ordered by id, newest first.
+func ValidatePaymentFilters(c echo.Context) (*PaymentFilters, error) {
+	var filters PaymentFilters
+	if err := c.Bind(&filters); err != nil {
+		return nil, errors.New("invalid filter parameters")
+	}
+
+	if filters.SubscriptionDetailsID < 0 {
+		return nil, errors.New("subscription_details_id must be a positive integer")
+	}
+
+	var err error
+	if filters.ParsedFrom, err = parseDate(filters.From, "from"); err != nil {
+		return nil, err
+	}
+	if filters.ParsedTo, err = parseDate(filters.To, "to"); err != nil {
+		return nil, err
+	}
+	if filters.ParsedFrom != nil && filters.ParsedTo != nil && filters.ParsedTo.Before(*filters.ParsedFrom) {
+		return nil, errors.New("to must not be before from")
+	}
+
+	limit, err := ValidatePageLimit(filters.Limit)
+	if err != nil {
+		return nil, err
+	}
+	filters.Limit = limit
+
+	if filters.ParsedCursor, err = DecodeCursor(filters.Cursor); err != nil {
+		return nil, err
+	}
+
+	return &filters, nil
+}
+
+// ValidateReconciliationRequest parses the reconciliation period, the last 90 days by default
+func ValidateReconciliationRequest(c echo.Context) (*ReconciliationRequest, error) {
+	var req ReconciliationRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, errors.New("invalid reconciliation parameters")
+	}
+
+	if req.SubscriptionDetailsID < 0 {
+		return nil, errors.New("subscription_details_id must be a positive integer")
+	}
+
+	req.ParsedTo = truncateDate(time.Now())
+	if to, err := parseDate(req.To, "to"); err != nil {
+		return nil, err
+	} else if to != nil {
+		req.ParsedTo = *to
+	}
+
+	req.ParsedFrom = req.ParsedTo.AddDate(0, 0, -90)
+	if from, err := parseDate(req.From, "from"); err != nil {
+		return nil, err
+	} else if from != nil {
+		req.ParsedFrom = *from
+	}
+
+	if req.ParsedTo.Before(req.ParsedFrom) {
+		return nil, errors.New("to must not be before from")
+	}
+	if req.ParsedTo.Sub(req.ParsedFrom) > MaxReconciliationDays*24*time.Hour {
+		return nil, errors.New("the period cannot be longer than 366 days")
+	}
+
+	return &req, nil
+}
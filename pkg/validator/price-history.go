@@ -0,0 +1,69 @@
+package validator
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"subscritracker/pkg/money"
+
+	"github.com/labstack/echo/v4"
+)
+
+type PriceChangeRequest struct {
+	MonthlyBill   json.Number `json:"monthly_bill" form:"monthly_bill"`
+	EffectiveDate string      `json:"effective_date" form:"effective_date"`
+}
+
+type ParsedPriceChange struct {
+	MonthlyBill   money.Amount
+	EffectiveDate time.Time
+}
+
+// ValidatePriceChangeRequest validates a price change. effective_date defaults to today,
+// a date in the future schedules the change.
+func ValidatePriceChangeRequest(c echo.Context) (*ParsedPriceChange, error) {
+	var req PriceChangeRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, err
+	}
+
+	if req.MonthlyBill == "" {
+		return nil, errors.New("monthly_bill is required")
+	}
+	monthlyBill, err := ParseMoneyAmount(req.MonthlyBill.String(), "monthly_bill")
+	if err != nil {
+		return nil, err
+	}
+	if monthlyBill.IsZero() {
+		return nil, errors.New("monthly_bill must be greater than 0")
+	}
+
+	effectiveDate, err := parseDate(req.EffectiveDate, "effective_date")
+	if err != nil {
+		return nil, err
+	}
+	if effectiveDate == nil {
+		today := truncateDate(time.Now())
+		effectiveDate = &today
+	}
+
+	return &ParsedPriceChange{
+		MonthlyBill:   monthlyBill,
+		EffectiveDate: *effectiveDate,
+	}, nil
+}
+
+// ValidatePriceIncreaseMonths parses the `months` lookback window, defaulting to def
+func ValidatePriceIncreaseMonths(c echo.Context, def int) (int, error) {
+	monthsStr := c.QueryParam("months")
+	if monthsStr == "" {
+		return def, nil
+	}
+	months, err := strconv.Atoi(monthsStr)
+	if err != nil || months <= 0 || months > 120 {
+		return 0, errors.New("months must be an integer between 1 and 120")
+	}
+	return months, nil
+}
@@ -0,0 +1,47 @@
+package validator
+
+import (
+	"errors"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+var validReminderStatuses = []string{"pending", "processing", "sent", "failed", "skipped"}
+
+type ReminderFilters struct {
+	Status       string    `query:"status"`
+	Cursor       string    `query:"cursor"`
+	Limit        int       `query:"limit"`
+	ParsedCursor *Cursor   `query:"-"`
+	CursorTime   time.Time `query:"-"`
+}
+
+// ValidateReminderFilters parses the reminder list filters. Pages are ordered by remind_at, newest first.
+func ValidateReminderFilters(c echo.Context) (*ReminderFilters, error) {
+	var filters ReminderFilters
+	if err := c.Bind(&filters); err != nil {
+		return nil, errors.New("invalid filter parameters")
+	}
+
+	if filters.Status != "" && !validateEnum(filters.Status, validReminderStatuses) {
+		return nil, errors.New("invalid status. Must be one of: pending, processing, sent, failed, skipped")
+	}
+
+	limit, err := ValidatePageLimit(filters.Limit)
+	if err != nil {
+		return nil, err
+	}
+	filters.Limit = limit
+
+	if filters.ParsedCursor, err = DecodeCursor(filters.Cursor); err != nil {
+		return nil, err
+	}
+	if filters.ParsedCursor != nil {
+		if filters.CursorTime, err = time.Parse(time.RFC3339Nano, filters.ParsedCursor.Key); err != nil {
+			return nil, errors.New("invalid cursor")
+		}
+	}
+
+	return &filters, nil
+}
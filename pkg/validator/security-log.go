@@ -0,0 +1,85 @@
+package validator
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	validSecurityEventTypes = []string{
+		"signup", "login", "oauth_login", "oauth_linked", "logout", "email_verified", "password_reset_requested", "account_updated",
+	}
+	validSecurityEventOutcomes = []string{"success", "failure"}
+)
+
+// SecurityLogFilters filters the security log. Users only see their own account's entries;
+// account_id, email and ip_address are honoured on the admin endpoint only.
+type SecurityLogFilters struct {
+	AccountID    int        `query:"account_id"`
+	Email        string     `query:"email"`
+	IPAddress    string     `query:"ip_address"`
+	EventType    string     `query:"event_type"`
+	Outcome      string     `query:"outcome"`
+	From         string     `query:"from"`
+	To           string     `query:"to"`
+	Cursor       string     `query:"cursor"`
+	Limit        int        `query:"limit"`
+	ParsedFrom   *time.Time `query:"-"`
+	ParsedTo     *time.Time `query:"-"`
+	ParsedCursor *Cursor    `query:"-"`
+}
+
+// ValidateSecurityLogFilters parses the security log filters. Pages are ordered by id, newest first.
+func ValidateSecurityLogFilters(c echo.Context) (*SecurityLogFilters, error) {
+	var filters SecurityLogFilters
+	if err := c.Bind(&filters); err != nil {
+		return nil, errors.New("invalid filter parameters")
+	}
+
+	if filters.AccountID < 0 {
+		return nil, errors.New("invalid account_id")
+	}
+	filters.Email = strings.ToLower(strings.TrimSpace(filters.Email))
+	filters.IPAddress = strings.TrimSpace(filters.IPAddress)
+
+	if filters.EventType != "" && !validateEnum(filters.EventType, validSecurityEventTypes) {
+		return nil, errors.New("invalid event_type. Must be one of: " + strings.Join(validSecurityEventTypes, ", "))
+	}
+	if filters.Outcome != "" && !validateEnum(filters.Outcome, validSecurityEventOutcomes) {
+		return nil, errors.New("invalid outcome. Must be one of: success, failure")
+	}
+
+	// A from date starts at the beginning of that day, a to date ends at its end
+	if filters.From != "" {
+		if from, err := time.Parse(time.RFC3339, filters.From); err == nil {
+			filters.ParsedFrom = &from
+		} else if filters.ParsedFrom, err = parseDate(filters.From, "from"); err != nil {
+			return nil, errors.New("invalid from format. Expected RFC 3339 or YYYY-MM-DD")
+		}
+	}
+	if filters.To != "" {
+		to, err := parseMoment(filters.To, "to")
+		if err != nil {
+			return nil, err
+		}
+		filters.ParsedTo = &to
+	}
+	if filters.ParsedFrom != nil && filters.ParsedTo != nil && filters.ParsedTo.Before(*filters.ParsedFrom) {
+		return nil, errors.New("to must not be before from")
+	}
+
+	limit, err := ValidatePageLimit(filters.Limit)
+	if err != nil {
+		return nil, err
+	}
+	filters.Limit = limit
+
+	if filters.ParsedCursor, err = DecodeCursor(filters.Cursor); err != nil {
+		return nil, err
+	}
+
+	return &filters, nil
+}
@@ -0,0 +1,95 @@
+package validator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MaxStatementFileSize caps uploaded statements at 5 MB
+const MaxStatementFileSize = 5 << 20
+
+// MaxAcceptedSuggestions caps how many suggestions can be accepted in one request
+const MaxAcceptedSuggestions = 100
+
+var validStatementFormats = []string{"ofx", "qfx", "csv"}
+
+type StatementUploadRequest struct {
+	Data     []byte
+	Format   string
+	Currency string
+}
+
+type AcceptedSuggestion struct {
+	SubscriptionChannelID int         `json:"subscription_channel_id"`
+	MonthlyBill           json.Number `json:"monthly_bill"`
+	DueType               string      `json:"due_type"`
+	StartDate             string      `json:"start_date"`
+	NextDueDate           string      `json:"next_due_date"`
+	Currency              string      `json:"currency"`
+	Notes                 string      `json:"notes"`
+}
+
+type StatementAcceptRequest struct {
+	Subscriptions []AcceptedSuggestion `json:"subscriptions"`
+	DryRun        bool                 `json:"-"`
+}
+
+// ValidateStatementUpload reads a statement upload. `format` is detected from the content when not given,
+// `currency` is the currency of the amounts when the file does not say.
+func ValidateStatementUpload(c echo.Context) (*StatementUploadRequest, error) {
+	request := &StatementUploadRequest{
+		Format: strings.ToLower(strings.TrimSpace(c.QueryParam("format"))),
+	}
+	if request.Format != "" && !validateEnum(request.Format, validStatementFormats) {
+		return nil, errors.New("format must be one of: ofx, qfx, csv")
+	}
+
+	if currency := c.QueryParam("currency"); currency != "" {
+		normalized, err := NormalizeCurrency(currency, "currency")
+		if err != nil {
+			return nil, err
+		}
+		request.Currency = normalized
+	}
+
+	data, err := readUpload(c, MaxStatementFileSize)
+	if err != nil {
+		return nil, err
+	}
+	request.Data = data
+
+	return request, nil
+}
+
+// ValidateStatementAcceptRequest checks the shape of accepted suggestions. Each one is fully
+// validated like a subscription details request when it is imported.
+func ValidateStatementAcceptRequest(c echo.Context) (*StatementAcceptRequest, error) {
+	var req StatementAcceptRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, err
+	}
+
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		return nil, err
+	}
+	req.DryRun = dryRun
+
+	if len(req.Subscriptions) == 0 {
+		return nil, errors.New("subscriptions is required")
+	}
+	if len(req.Subscriptions) > MaxAcceptedSuggestions {
+		return nil, fmt.Errorf("cannot accept more than %d subscriptions at once", MaxAcceptedSuggestions)
+	}
+	for i, suggestion := range req.Subscriptions {
+		if suggestion.SubscriptionChannelID <= 0 {
+			return nil, fmt.Errorf("subscriptions[%d].subscription_channel_id is required", i)
+		}
+	}
+
+	return &req, nil
+}
@@ -1,17 +1,34 @@
 package validator
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"subscritracker/pkg/money"
+
 	"github.com/labstack/echo/v4"
 )
 
 // --- Helpers & Constants ---
 
 var (
-	validStatuses   = []string{"active", "inactive", "paused", "cancelled"}
+	validStatuses  = []string{"active", "inactive", "paused", "cancelled"}
+	validDueTypes  = []string{"monthly", "yearly", "weekly", "daily"}
+	dueTypeAliases = map[string]string{
+		"month":    "monthly",
+		"monthly":  "monthly",
+		"year":     "yearly",
+		"yearly":   "yearly",
+		"annual":   "yearly",
+		"annually": "yearly",
+		"week":     "weekly",
+		"weekly":   "weekly",
+		"day":      "daily",
+		"daily":    "daily",
+	}
 	validSortFields = map[string]string{
 		"monthly_bill":  "sd.monthly_bill",
 		"next_due_date": "sd.next_due_date",
@@ -46,6 +63,11 @@ func parseDate(dateStr, field string) (*time.Time, error) {
 	return &t, nil
 }
 
+// truncateDate drops the time of day so dates compare like parseDate output.
+func truncateDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
 // validateEnum checks if val is in allowed list.
 func validateEnum(val string, allowed []string) bool {
 	for _, v := range allowed {
@@ -56,48 +78,82 @@ func validateEnum(val string, allowed []string) bool {
 	return false
 }
 
+// NormalizeDueType maps billing cycle spellings like "Annual" or "month" to a due_type.
+// Unknown values are returned lowercased so validation reports them.
+func NormalizeDueType(cycle string) string {
+	cycle = strings.ToLower(strings.TrimSpace(cycle))
+	if dueType, ok := dueTypeAliases[cycle]; ok {
+		return dueType
+	}
+	return cycle
+}
+
 // --- Request Structs ---
 
 type SubscriptionDetailsRequest struct {
-	SubscriptionChannelID int     `json:"subscription_channel_id" form:"subscription_channel_id" validate:"required"`
-	StartDate             string  `json:"start_date" form:"start_date"`
-	NextDueDate           string  `json:"next_due_date" form:"next_due_date"`
-	DueType               string  `json:"due_type" form:"due_type"`
-	DueDayOfMonth         int     `json:"due_day_of_month" form:"due_day_of_month"`
-	EndDate               string  `json:"end_date" form:"end_date"`
-	Status                string  `json:"status" form:"status"`
-	StartTime             string  `json:"start_time" form:"start_time"`
-	DueTime               string  `json:"due_time" form:"due_time"`
-	MonthlyBill           float64 `json:"monthly_bill" form:"monthly_bill"`
-	ReminderDate          string  `json:"reminder_date" form:"reminder_date"`
-	ReminderTime          string  `json:"reminder_time" form:"reminder_time"`
+	SubscriptionChannelID int         `json:"subscription_channel_id" form:"subscription_channel_id" validate:"required"`
+	CategoryID            *int        `json:"category_id" form:"category_id"`
+	StartDate             string      `json:"start_date" form:"start_date"`
+	NextDueDate           string      `json:"next_due_date" form:"next_due_date"`
+	DueType               string      `json:"due_type" form:"due_type"`
+	DueDayOfMonth         int         `json:"due_day_of_month" form:"due_day_of_month"`
+	EndDate               string      `json:"end_date" form:"end_date"`
+	Status                string      `json:"status" form:"status"`
+	StartTime             string      `json:"start_time" form:"start_time"`
+	DueTime               string      `json:"due_time" form:"due_time"`
+	MonthlyBill           json.Number `json:"monthly_bill" form:"monthly_bill"`
+	Currency              string      `json:"currency" form:"currency"`
+	TrialEndDate          string      `json:"trial_end_date" form:"trial_end_date"`
+	IntroPrice            json.Number `json:"intro_price" form:"intro_price"`
+	IntroPriceUntil       string      `json:"intro_price_until" form:"intro_price_until"`
+	Notes                 string      `json:"notes" form:"notes"`
+	ReminderDate          string      `json:"reminder_date" form:"reminder_date"`
+	ReminderDaysBefore    *int        `json:"reminder_days_before" form:"reminder_days_before"`
+	ReminderTime          string      `json:"reminder_time" form:"reminder_time"`
+}
+
+type SubscriptionStatusRequest struct {
+	Status string `json:"status" form:"status"`
 }
 
 type ParsedSubscriptionDetails struct {
 	SubscriptionChannelID int
+	CategoryID            *int
 	StartDate             *time.Time
 	NextDueDate           *time.Time
 	EndDate               *time.Time
 	Status                string
 	DueType               string
 	DueDayOfMonth         int
-	MonthlyBill           float64
+	MonthlyBill           money.Amount
+	Currency              string
+	TrialEndDate          *time.Time
+	IntroPrice            *money.Amount
+	IntroPriceUntil       *time.Time
+	Notes                 string
 	StartTime             *time.Time
 	DueTime               *time.Time
 	ReminderDate          *time.Time
 	ReminderTime          *time.Time
+	ReminderDaysBefore    *int
 }
 
 type FilterOptions struct {
-	Status          string     `json:"status" query:"status"`
-	SortBy          string     `json:"sort_by" query:"sort_by"`
-	SortOrder       string     `json:"sort_order" query:"sort_order"`
-	MinCost         *float64   `json:"min_cost" query:"min_cost"`
-	MaxCost         *float64   `json:"max_cost" query:"max_cost"`
-	StartDateFrom   *time.Time `json:"start_date_from" query:"start_date_from"`
-	StartDateTo     *time.Time `json:"start_date_to" query:"start_date_to"`
-	NextDueDateFrom *time.Time `json:"next_due_date_from" query:"next_due_date_from"`
-	NextDueDateTo   *time.Time `json:"next_due_date_to" query:"next_due_date_to"`
+	Status          string        `json:"status" query:"status"`
+	SortBy          string        `json:"sort_by" query:"sort_by"`
+	SortOrder       string        `json:"sort_order" query:"sort_order"`
+	MinCost         *money.Amount `json:"min_cost" query:"min_cost"`
+	MaxCost         *money.Amount `json:"max_cost" query:"max_cost"`
+	StartDateFrom   *time.Time    `json:"start_date_from" query:"start_date_from"`
+	StartDateTo     *time.Time    `json:"start_date_to" query:"start_date_to"`
+	NextDueDateFrom *time.Time    `json:"next_due_date_from" query:"next_due_date_from"`
+	NextDueDateTo   *time.Time    `json:"next_due_date_to" query:"next_due_date_to"`
+	CategoryID      *int          `json:"category_id" query:"category_id"`
+	TagIDs          []int         `json:"tag_id" query:"tag_id"`
+	Q               string        `json:"q" query:"q"`
+	Cursor          string        `json:"cursor" query:"cursor"`
+	Limit           int           `json:"limit" query:"limit"`
+	ParsedCursor    *Cursor       `json:"-" query:"-"`
 }
 
 // --- Validators ---
@@ -108,9 +164,13 @@ func ValidateSubscriptionDetailsRequest(c echo.Context) (*ParsedSubscriptionDeta
 		return nil, err
 	}
 
+	return ValidateSubscriptionDetails(req)
+}
+
+// ValidateSubscriptionDetails validates a subscription that did not come from a request body, e.g. an imported CSV row
+func ValidateSubscriptionDetails(req SubscriptionDetailsRequest) (*ParsedSubscriptionDetails, error) {
 	parsed := &ParsedSubscriptionDetails{
 		SubscriptionChannelID: req.SubscriptionChannelID,
-		MonthlyBill:           req.MonthlyBill,
 		Status:                defaultIfEmpty(req.Status, "active"),
 		DueType:               defaultIfEmpty(req.DueType, "monthly"),
 		DueDayOfMonth:         defaultIfZero(req.DueDayOfMonth, 1),
@@ -121,6 +181,11 @@ func ValidateSubscriptionDetailsRequest(c echo.Context) (*ParsedSubscriptionDeta
 		return nil, errors.New("invalid status. Must be one of: active, inactive, paused, cancelled")
 	}
 
+	// Validate due type
+	if !validateEnum(parsed.DueType, validDueTypes) {
+		return nil, errors.New("invalid due_type. Must be one of: monthly, yearly, weekly, daily")
+	}
+
 	// Dates
 	var err error
 	if parsed.StartDate, err = parseDate(req.StartDate, "start_date"); err != nil {
@@ -139,6 +204,12 @@ func ValidateSubscriptionDetailsRequest(c echo.Context) (*ParsedSubscriptionDeta
 	if parsed.ReminderDate, err = parseDate(req.ReminderDate, "reminder_date"); err != nil {
 		return nil, err
 	}
+	if parsed.TrialEndDate, err = parseDate(req.TrialEndDate, "trial_end_date"); err != nil {
+		return nil, err
+	}
+	if parsed.IntroPriceUntil, err = parseDate(req.IntroPriceUntil, "intro_price_until"); err != nil {
+		return nil, err
+	}
 
 	// Times
 	if parsed.StartTime, err = parseTimeOfDay(req.StartTime); err != nil {
@@ -156,15 +227,64 @@ func ValidateSubscriptionDetailsRequest(c echo.Context) (*ParsedSubscriptionDeta
 		return nil, errors.New("subscription_channel_id is required")
 	}
 
+	// Category Id, ownership is checked by the handler
+	if req.CategoryID != nil && *req.CategoryID <= 0 {
+		return nil, errors.New("category_id must be a positive integer")
+	}
+	parsed.CategoryID = req.CategoryID
+
+	// Relative reminders fire that many days before each due date
+	if req.ReminderDaysBefore != nil && (*req.ReminderDaysBefore < 0 || *req.ReminderDaysBefore > 365) {
+		return nil, errors.New("reminder_days_before must be between 0 and 365")
+	}
+	parsed.ReminderDaysBefore = req.ReminderDaysBefore
+
 	// Validate time logic
 	if parsed.StartTime != nil && parsed.DueTime != nil && parsed.StartTime.After(*parsed.DueTime) {
 		return nil, errors.New("start_time cannot be after due_time")
 	}
 
 	// Validate bill
-	if parsed.MonthlyBill == 0 {
+	if req.MonthlyBill == "" {
 		return nil, errors.New("monthly_bill is required")
 	}
+	if parsed.MonthlyBill, err = ParseMoneyAmount(req.MonthlyBill.String(), "monthly_bill"); err != nil {
+		return nil, err
+	}
+	if parsed.MonthlyBill.IsZero() {
+		return nil, errors.New("monthly_bill is required")
+	}
+
+	// Validate notes
+	parsed.Notes = strings.TrimSpace(req.Notes)
+	if err := IsValidLength(parsed.Notes, "notes", 0, 2000); err != nil {
+		return nil, err
+	}
+
+	// Validate currency. Empty means the account's default currency
+	if req.Currency != "" {
+		if parsed.Currency, err = NormalizeCurrency(req.Currency, "currency"); err != nil {
+			return nil, err
+		}
+	}
+
+	// Validate trial and introductory pricing
+	if parsed.TrialEndDate != nil && parsed.TrialEndDate.Before(truncateDate(*parsed.StartDate)) {
+		return nil, errors.New("trial_end_date cannot be before start_date")
+	}
+	if req.IntroPrice != "" {
+		introPrice, err := ParseMoneyAmount(req.IntroPrice.String(), "intro_price")
+		if err != nil {
+			return nil, err
+		}
+		parsed.IntroPrice = &introPrice
+	}
+	if (parsed.IntroPrice == nil) != (parsed.IntroPriceUntil == nil) {
+		return nil, errors.New("intro_price and intro_price_until must be provided together")
+	}
+	if parsed.IntroPriceUntil != nil && !parsed.IntroPriceUntil.After(truncateDate(*parsed.StartDate)) {
+		return nil, errors.New("intro_price_until must be after start_date")
+	}
 
 	return parsed, nil
 }
@@ -186,6 +306,9 @@ func ValidateSubscriptionDetailsFilters(c echo.Context) (*FilterOptions, error)
 	}
 
 	// Validate cost range
+	if (filters.MinCost != nil && filters.MinCost.IsNegative()) || (filters.MaxCost != nil && filters.MaxCost.IsNegative()) {
+		return nil, errors.New("min_cost and max_cost cannot be negative")
+	}
 	if filters.MinCost != nil && filters.MaxCost != nil && *filters.MinCost > *filters.MaxCost {
 		return nil, errors.New("min_cost cannot be greater than max_cost")
 	}
@@ -207,9 +330,47 @@ func ValidateSubscriptionDetailsFilters(c echo.Context) (*FilterOptions, error)
 		return nil, errors.New("next_due_date_from cannot be after next_due_date_to")
 	}
 
+	// Validate category and tag filters
+	if filters.CategoryID != nil && *filters.CategoryID <= 0 {
+		return nil, errors.New("category_id must be a positive integer")
+	}
+	for _, tagID := range filters.TagIDs {
+		if tagID <= 0 {
+			return nil, errors.New("tag_id must be a positive integer")
+		}
+	}
+
+	// Validate search and pagination
+	filters.Q = strings.TrimSpace(filters.Q)
+	if err := IsValidLength(filters.Q, "q", 0, 200); err != nil {
+		return nil, err
+	}
+	var err error
+	if filters.Limit, err = ValidatePageLimit(filters.Limit); err != nil {
+		return nil, err
+	}
+	if filters.ParsedCursor, err = DecodeCursor(filters.Cursor); err != nil {
+		return nil, err
+	}
+	if filters.ParsedCursor != nil && filters.ParsedCursor.Sort != filters.SortSignature() {
+		return nil, errors.New("cursor does not match sort_by and sort_order")
+	}
+
 	return &filters, nil
 }
 
+// SortSignature identifies the ordering a cursor was issued for
+func (f *FilterOptions) SortSignature() string {
+	if f.SortBy == "" {
+		return ""
+	}
+	order := f.SortOrder
+	if order == "" {
+		order = "asc"
+	}
+	return f.SortBy + ":" + order
+}
+
 // --- Utility defaults ---
 
 func defaultIfEmpty(val, def string) string {
@@ -224,3 +385,18 @@ func defaultIfZero(val, def int) int {
 	}
 	return val
 }
+
+// ValidateSubscriptionStatusRequest validates a change of a subscription's status
+func ValidateSubscriptionStatusRequest(c echo.Context) (string, error) {
+	var req SubscriptionStatusRequest
+	if err := c.Bind(&req); err != nil {
+		return "", err
+	}
+
+	status := strings.ToLower(strings.TrimSpace(req.Status))
+	if !validateEnum(status, validStatuses) {
+		return "", errors.New("invalid status. Must be one of: active, inactive, paused, cancelled")
+	}
+
+	return status, nil
+}
@@ -7,9 +7,28 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
+// userSubscriptionEventType is the only event type users can post; the others are recorded by the system
+const userSubscriptionEventType = "note"
+
+var (
+	validSubscriptionEventTypes     = []string{"created", "price_changed", "paused", "resumed", "cancelled", "charged", "reminder_sent", "note"}
+	errInvalidSubscriptionEventType = errors.New("invalid type. Must be one of: created, price_changed, paused, resumed, cancelled, charged, reminder_sent, note")
+	errSystemSubscriptionEventType  = errors.New("invalid type. Only note events can be posted; other events are recorded by the system")
+)
+
+// SubscriptionEventRequest posts a note to a subscription's history. type defaults to note.
 type SubscriptionEventRequest struct {
-	SubscriptionDetailsID int `json:"subscription_details_id"`
-	AccountID             int `json:"account_id"`
+	SubscriptionDetailsID int                    `json:"subscription_details_id"`
+	AccountID             int                    `json:"account_id"`
+	Type                  string                 `json:"type"`
+	Payload               map[string]interface{} `json:"payload"`
+}
+
+type SubscriptionEventFilters struct {
+	Type         string  `query:"type"`
+	Cursor       string  `query:"cursor"`
+	Limit        int     `query:"limit"`
+	ParsedCursor *Cursor `query:"-"`
 }
 
 func ValidateSubscriptionEventRequest(c echo.Context) (SubscriptionEventRequest, error) {
@@ -24,6 +43,9 @@ func ValidateSubscriptionEventRequest(c echo.Context) (SubscriptionEventRequest,
 		if request.AccountID <= 0 {
 			return SubscriptionEventRequest{}, errors.New("account_id must be a positive integer")
 		}
+		if err := validateUserSubscriptionEventType(&request); err != nil {
+			return SubscriptionEventRequest{}, err
+		}
 		return request, nil
 	}
 
@@ -52,6 +74,45 @@ func ValidateSubscriptionEventRequest(c echo.Context) (SubscriptionEventRequest,
 
 	request.SubscriptionDetailsID = subscriptionDetailsID
 	request.AccountID = accountID
+	request.Type = c.FormValue("type")
+	if err := validateUserSubscriptionEventType(&request); err != nil {
+		return SubscriptionEventRequest{}, err
+	}
 
 	return request, nil
 }
+
+func validateUserSubscriptionEventType(request *SubscriptionEventRequest) error {
+	request.Type = defaultIfEmpty(request.Type, userSubscriptionEventType)
+	if request.Type == userSubscriptionEventType {
+		return nil
+	}
+	if validateEnum(request.Type, validSubscriptionEventTypes) {
+		return errSystemSubscriptionEventType
+	}
+	return errInvalidSubscriptionEventType
+}
+
+// ValidateSubscriptionEventFilters parses the event list filters. Pages are ordered by id, newest first.
+func ValidateSubscriptionEventFilters(c echo.Context) (*SubscriptionEventFilters, error) {
+	var filters SubscriptionEventFilters
+	if err := c.Bind(&filters); err != nil {
+		return nil, errors.New("invalid filter parameters")
+	}
+
+	if filters.Type != "" && !validateEnum(filters.Type, validSubscriptionEventTypes) {
+		return nil, errInvalidSubscriptionEventType
+	}
+
+	limit, err := ValidatePageLimit(filters.Limit)
+	if err != nil {
+		return nil, err
+	}
+	filters.Limit = limit
+
+	if filters.ParsedCursor, err = DecodeCursor(filters.Cursor); err != nil {
+		return nil, err
+	}
+
+	return &filters, nil
+}
@@ -0,0 +1,110 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SubscriptionVersionFilters struct {
+	Cursor       string  `query:"cursor"`
+	Limit        int     `query:"limit"`
+	ParsedCursor *Cursor `query:"-"`
+}
+
+type AsOfRequest struct {
+	At       string    `query:"at"`
+	ParsedAt time.Time `query:"-"`
+}
+
+type VersionDiffRequest struct {
+	From       string    `query:"from"`
+	To         string    `query:"to"`
+	ParsedFrom time.Time `query:"-"`
+	ParsedTo   time.Time `query:"-"`
+}
+
+// ValidateSubscriptionVersionFilters parses the version list filters. Pages are ordered by version, newest first.
+func ValidateSubscriptionVersionFilters(c echo.Context) (*SubscriptionVersionFilters, error) {
+	var filters SubscriptionVersionFilters
+	if err := c.Bind(&filters); err != nil {
+		return nil, errors.New("invalid filter parameters")
+	}
+
+	limit, err := ValidatePageLimit(filters.Limit)
+	if err != nil {
+		return nil, err
+	}
+	filters.Limit = limit
+
+	if filters.ParsedCursor, err = DecodeCursor(filters.Cursor); err != nil {
+		return nil, err
+	}
+
+	return &filters, nil
+}
+
+// ValidateAsOfRequest parses the moment to reconstruct subscriptions at, now by default
+func ValidateAsOfRequest(c echo.Context) (*AsOfRequest, error) {
+	var req AsOfRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, errors.New("invalid as-of parameters")
+	}
+
+	req.ParsedAt = time.Now()
+	if req.At != "" {
+		at, err := parseMoment(req.At, "at")
+		if err != nil {
+			return nil, err
+		}
+		req.ParsedAt = at
+	}
+
+	return &req, nil
+}
+
+// ValidateVersionDiffRequest parses the two moments to compare subscriptions between; to defaults to now
+func ValidateVersionDiffRequest(c echo.Context) (*VersionDiffRequest, error) {
+	var req VersionDiffRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, errors.New("invalid diff parameters")
+	}
+
+	if req.From == "" {
+		return nil, errors.New("from is required")
+	}
+	from, err := parseMoment(req.From, "from")
+	if err != nil {
+		return nil, err
+	}
+	req.ParsedFrom = from
+
+	req.ParsedTo = time.Now()
+	if req.To != "" {
+		if req.ParsedTo, err = parseMoment(req.To, "to"); err != nil {
+			return nil, err
+		}
+	}
+
+	if !req.ParsedTo.After(req.ParsedFrom) {
+		return nil, errors.New("to must be after from")
+	}
+
+	return &req, nil
+}
+
+// parseMoment parses an RFC 3339 timestamp, or a YYYY-MM-DD date meaning the end of that day in UTC,
+// so "as of March 3rd" includes every change made on March 3rd
+func parseMoment(value, field string) (time.Time, error) {
+	if moment, err := time.Parse(time.RFC3339, value); err == nil {
+		return moment, nil
+	}
+
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s format. Expected RFC 3339 or YYYY-MM-DD", field)
+	}
+	return date.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
@@ -0,0 +1,104 @@
+package validator
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	validWebhookEventTypes = []string{
+		"subscription.created", "subscription.price_changed", "subscription.paused", "subscription.resumed",
+		"subscription.cancelled", "subscription.charged", "subscription.reminder_sent",
+	}
+	validWebhookDeliveryStatuses = []string{"pending", "sending", "delivered", "dead"}
+)
+
+// WebhookEndpointRequest creates or updates a webhook endpoint. An empty event_types subscribes to every event;
+// on update, fields that are not given keep their current value.
+type WebhookEndpointRequest struct {
+	URL         *string   `json:"url" form:"url"`
+	Description *string   `json:"description" form:"description"`
+	EventTypes  *[]string `json:"event_types" form:"event_types"`
+	Enabled     *bool     `json:"enabled" form:"enabled"`
+}
+
+type WebhookDeliveryFilters struct {
+	Status       string  `query:"status"`
+	Cursor       string  `query:"cursor"`
+	Limit        int     `query:"limit"`
+	ParsedCursor *Cursor `query:"-"`
+}
+
+// ValidateWebhookEndpointRequest validates a webhook endpoint. The url is required when creating one.
+func ValidateWebhookEndpointRequest(c echo.Context, creating bool) (*WebhookEndpointRequest, error) {
+	var req WebhookEndpointRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, err
+	}
+
+	if req.URL == nil && creating {
+		return nil, errors.New("url is required")
+	}
+	if req.URL != nil {
+		trimmed := strings.TrimSpace(*req.URL)
+		req.URL = &trimmed
+		if err := validateNotificationURL(trimmed, "url"); err != nil {
+			return nil, err
+		}
+		if err := IsValidLength(trimmed, "url", 1, 2000); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Description != nil {
+		trimmed := strings.TrimSpace(*req.Description)
+		req.Description = &trimmed
+		if err := IsValidLength(trimmed, "description", 0, 200); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.EventTypes != nil {
+		seen := map[string]bool{}
+		eventTypes := []string{}
+		for _, eventType := range *req.EventTypes {
+			eventType = strings.ToLower(strings.TrimSpace(eventType))
+			if !validateEnum(eventType, validWebhookEventTypes) {
+				return nil, errors.New("invalid event type " + eventType + ". Must be one of: " + strings.Join(validWebhookEventTypes, ", "))
+			}
+			if !seen[eventType] {
+				seen[eventType] = true
+				eventTypes = append(eventTypes, eventType)
+			}
+		}
+		req.EventTypes = &eventTypes
+	}
+
+	return &req, nil
+}
+
+// ValidateWebhookDeliveryFilters parses the delivery log filters. Pages are ordered by id, newest first.
+func ValidateWebhookDeliveryFilters(c echo.Context) (*WebhookDeliveryFilters, error) {
+	var filters WebhookDeliveryFilters
+	if err := c.Bind(&filters); err != nil {
+		return nil, errors.New("invalid filter parameters")
+	}
+
+	if filters.Status != "" && !validateEnum(filters.Status, validWebhookDeliveryStatuses) {
+		return nil, errors.New("invalid status. Must be one of: pending, sending, delivered, dead")
+	}
+
+	limit, err := ValidatePageLimit(filters.Limit)
+	if err != nil {
+		return nil, err
+	}
+	filters.Limit = limit
+
+	if filters.ParsedCursor, err = DecodeCursor(filters.Cursor); err != nil {
+		return nil, err
+	}
+
+	return &filters, nil
+}
@@ -0,0 +1,183 @@
+package webhooks
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/models"
+	"subscritracker/pkg/validator"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetEndpointsHandler lists the user's webhook endpoints
+func GetEndpointsHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	endpoints, err := GetEndpoints(app, accountID)
+	if err != nil {
+		log.Println("Error getting webhook endpoints:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get webhook endpoints"})
+	}
+
+	return c.JSON(http.StatusOK, endpoints)
+}
+
+// CreateEndpointHandler registers a webhook endpoint and returns its signing secret
+func CreateEndpointHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	request, err := validator.ValidateWebhookEndpointRequest(c, true)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	endpoint, err := CreateEndpoint(app, accountID, request)
+	if err != nil {
+		log.Println("Error creating webhook endpoint:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create webhook endpoint"})
+	}
+
+	return c.JSON(http.StatusCreated, endpoint)
+}
+
+// UpdateEndpointHandler changes the url, description, event types or enabled flag of an endpoint
+func UpdateEndpointHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+
+	endpoint, response := endpointFromParam(c)
+	if endpoint == nil {
+		return response
+	}
+
+	request, err := validator.ValidateWebhookEndpointRequest(c, false)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	if err := UpdateEndpoint(app, endpoint, request); err != nil {
+		log.Println("Error updating webhook endpoint:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update webhook endpoint"})
+	}
+
+	return c.JSON(http.StatusOK, endpoint)
+}
+
+// DeleteEndpointHandler removes an endpoint and its delivery log
+func DeleteEndpointHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	endpointID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid webhook endpoint ID"})
+	}
+
+	if err := DeleteEndpoint(app, accountID, endpointID); err != nil {
+		if errors.Is(err, ErrEndpointNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error deleting webhook endpoint:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete webhook endpoint"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RotateSecretHandler replaces the signing secret of an endpoint and returns the new one
+func RotateSecretHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+
+	endpoint, response := endpointFromParam(c)
+	if endpoint == nil {
+		return response
+	}
+
+	rotated, err := RotateSecret(app, endpoint)
+	if err != nil {
+		log.Println("Error rotating webhook secret:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to rotate webhook secret"})
+	}
+
+	return c.JSON(http.StatusOK, rotated)
+}
+
+// GetDeliveriesHandler lists the delivery log of an endpoint, newest first
+func GetDeliveriesHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+
+	endpoint, response := endpointFromParam(c)
+	if endpoint == nil {
+		return response
+	}
+
+	filters, err := validator.ValidateWebhookDeliveryFilters(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	page, err := GetDeliveries(app, endpoint.ID, filters)
+	if err != nil {
+		log.Println("Error getting webhook deliveries:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get webhook deliveries"})
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+// RedeliverHandler queues a delivered or dead-lettered event to be sent to the endpoint again
+func RedeliverHandler(c echo.Context) error {
+	app := c.Get("app").(*application.App)
+
+	endpoint, response := endpointFromParam(c)
+	if endpoint == nil {
+		return response
+	}
+
+	deliveryID, err := strconv.ParseInt(c.Param("delivery_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid webhook delivery ID"})
+	}
+
+	delivery, err := Redeliver(app, endpoint.ID, deliveryID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrDeliveryNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		case errors.Is(err, ErrDeliveryInProgress):
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error redelivering webhook:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to redeliver webhook"})
+	}
+
+	return c.JSON(http.StatusAccepted, delivery)
+}
+
+// endpointFromParam loads the user's endpoint named by the id path parameter. When it returns nil
+// the error response has already been written and is returned as the second value.
+func endpointFromParam(c echo.Context) (*models.Webhook_Endpoint, error) {
+	app := c.Get("app").(*application.App)
+	accountID := c.Get("user_id").(int)
+
+	endpointID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid webhook endpoint ID"})
+	}
+
+	endpoint, err := GetEndpoint(app, accountID, endpointID)
+	if err != nil {
+		if errors.Is(err, ErrEndpointNotFound) {
+			return nil, c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		log.Println("Error getting webhook endpoint:", err)
+		return nil, c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get webhook endpoint"})
+	}
+
+	return endpoint, nil
+}
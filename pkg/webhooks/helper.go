@@ -0,0 +1,460 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/models"
+	subscriptionevents "subscritracker/pkg/subscription-events"
+	"subscritracker/pkg/utils"
+	"subscritracker/pkg/validator"
+
+	"github.com/uptrace/bun"
+)
+
+// httpClient delivers to user-registered URLs, so it refuses to connect to private and local addresses;
+// otherwise the delivery log would hand internal responses back to the user
+var httpClient = newHTTPClient()
+
+func newHTTPClient() *http.Client {
+	client := utils.NewOutboundHTTPClient(deliveryTimeout)
+	// A redirect could send the signed payload somewhere the user did not register
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	return client
+}
+
+// GenerateSecret returns a new random signing secret
+func GenerateSecret() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return secretPrefix + hex.EncodeToString(key), nil
+}
+
+// Sign returns the signature header value of a body sent at timestamp
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix + "."))
+	mac.Write(body)
+	return "t=" + unix + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Enqueue queues an event for every enabled endpoint of the account subscribed to its type and
+// returns how many deliveries were queued
+func Enqueue(ctx context.Context, db bun.IDB, accountID int, eventType, eventID string, data map[string]interface{}, occurredAt time.Time) (int64, error) {
+	payload, err := json.Marshal(Envelope{ID: eventID, Type: eventType, CreatedAt: occurredAt.UTC(), Data: data})
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	result, err := db.NewRaw(`
+		INSERT INTO webhook_deliveries (webhook_endpoint_id, account_id, event_id, event_type, payload, status, next_attempt_at, created_at, updated_at)
+		SELECT id, account_id, ?, ?, ?::jsonb, ?, ?, ?, ?
+		FROM webhook_endpoints
+		WHERE account_id = ? AND enabled AND (cardinality(event_types) = 0 OR ? = ANY(event_types))
+	`, eventID, eventType, string(payload), StatusPending, now, now, now, accountID, eventType).Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// EnqueueSubscriptionEvent is the outbox consumer that queues a recorded subscription event
+// for the account's endpoints. Notes the user posted are not delivered; endpoints only get system events.
+func EnqueueSubscriptionEvent(ctx context.Context, tx bun.Tx, message models.Outbox_Message) error {
+	var event models.Subscription_Event
+	if err := json.Unmarshal(message.Payload, &event); err != nil {
+		return err
+	}
+	if event.Type == subscriptionevents.EventNote {
+		return nil
+	}
+
+	_, err := Enqueue(ctx, tx, event.AccountID, "subscription."+event.Type, fmt.Sprintf("evt_%d", event.ID), map[string]interface{}{
+		"subscription_event_id":   event.ID,
+		"subscription_details_id": event.SubscriptionDetailsID,
+		"actor":                   event.Actor,
+		"payload":                 event.Payload,
+	}, event.CreatedAt)
+	return err
+}
+
+func GetEndpoints(app *application.App, accountID int) ([]models.Webhook_Endpoint, error) {
+	endpoints := []models.Webhook_Endpoint{}
+	err := app.Database.NewSelect().
+		Model(&endpoints).
+		Where("account_id = ?", accountID).
+		Order("id ASC").
+		Scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return endpoints, nil
+}
+
+func GetEndpoint(app *application.App, accountID, endpointID int) (*models.Webhook_Endpoint, error) {
+	endpoint := &models.Webhook_Endpoint{}
+	err := app.Database.NewSelect().
+		Model(endpoint).
+		Where("id = ? AND account_id = ?", endpointID, accountID).
+		Scan(context.Background())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEndpointNotFound
+		}
+		return nil, err
+	}
+
+	return endpoint, nil
+}
+
+// CreateEndpoint registers an endpoint with a new signing secret
+func CreateEndpoint(app *application.App, accountID int, request *validator.WebhookEndpointRequest) (*EndpointWithSecret, error) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := models.Webhook_Endpoint{
+		AccountID:  accountID,
+		URL:        *request.URL,
+		Secret:     secret,
+		EventTypes: []string{},
+		Enabled:    true,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	if request.Description != nil {
+		endpoint.Description = *request.Description
+	}
+	if request.EventTypes != nil {
+		endpoint.EventTypes = *request.EventTypes
+	}
+	if request.Enabled != nil {
+		endpoint.Enabled = *request.Enabled
+	}
+
+	if _, err := app.Database.NewInsert().Model(&endpoint).Exec(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return &EndpointWithSecret{Webhook_Endpoint: endpoint, Secret: secret}, nil
+}
+
+// UpdateEndpoint applies the fields given in the request to the endpoint
+func UpdateEndpoint(app *application.App, endpoint *models.Webhook_Endpoint, request *validator.WebhookEndpointRequest) error {
+	if request.URL != nil {
+		endpoint.URL = *request.URL
+	}
+	if request.Description != nil {
+		endpoint.Description = *request.Description
+	}
+	if request.EventTypes != nil {
+		endpoint.EventTypes = *request.EventTypes
+	}
+	if request.Enabled != nil {
+		endpoint.Enabled = *request.Enabled
+	}
+	endpoint.UpdatedAt = time.Now()
+
+	_, err := app.Database.NewUpdate().
+		Model(endpoint).
+		Column("url", "description", "event_types", "enabled", "updated_at").
+		WherePK().
+		Exec(context.Background())
+	return err
+}
+
+// DeleteEndpoint removes an endpoint of the account along with its delivery log
+func DeleteEndpoint(app *application.App, accountID, endpointID int) error {
+	result, err := app.Database.NewDelete().
+		Model((*models.Webhook_Endpoint)(nil)).
+		Where("id = ? AND account_id = ?", endpointID, accountID).
+		Exec(context.Background())
+	if err != nil {
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrEndpointNotFound
+	}
+	return nil
+}
+
+// RotateSecret replaces the endpoint's signing secret. Deliveries sent from now on use the new one.
+func RotateSecret(app *application.App, endpoint *models.Webhook_Endpoint) (*EndpointWithSecret, error) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint.Secret = secret
+	endpoint.UpdatedAt = time.Now()
+	_, err = app.Database.NewUpdate().
+		Model(endpoint).
+		Column("secret", "updated_at").
+		WherePK().
+		Exec(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return &EndpointWithSecret{Webhook_Endpoint: *endpoint, Secret: secret}, nil
+}
+
+// GetDeliveries lists the delivery log of an endpoint, newest first
+func GetDeliveries(app *application.App, endpointID int, filters *validator.WebhookDeliveryFilters) (*DeliveryPage, error) {
+	deliveries := []models.Webhook_Delivery{}
+	query := app.Database.NewSelect().
+		Model(&deliveries).
+		Where("webhook_endpoint_id = ?", endpointID)
+
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.ParsedCursor != nil {
+		query = query.Where("id < ?", filters.ParsedCursor.ID)
+	}
+
+	err := query.
+		Order("id DESC").
+		Limit(filters.Limit + 1).
+		Scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	page := &DeliveryPage{Data: deliveries}
+	if len(deliveries) > filters.Limit {
+		page.Data = deliveries[:filters.Limit]
+		nextCursor := validator.EncodeCursor(validator.Cursor{ID: int(page.Data[len(page.Data)-1].ID)})
+		page.NextCursor = &nextCursor
+	}
+
+	return page, nil
+}
+
+// Redeliver queues a new delivery of the same event to the endpoint. Deliveries still in the
+// queue cannot be redelivered; they will be sent anyway.
+func Redeliver(app *application.App, endpointID int, deliveryID int64) (*models.Webhook_Delivery, error) {
+	original := &models.Webhook_Delivery{}
+	err := app.Database.NewSelect().
+		Model(original).
+		Where("id = ? AND webhook_endpoint_id = ?", deliveryID, endpointID).
+		Scan(context.Background())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+	if original.Status == StatusPending || original.Status == StatusSending {
+		return nil, ErrDeliveryInProgress
+	}
+
+	now := time.Now()
+	delivery := &models.Webhook_Delivery{
+		WebhookEndpointID: original.WebhookEndpointID,
+		AccountID:         original.AccountID,
+		EventID:           original.EventID,
+		EventType:         original.EventType,
+		Payload:           original.Payload,
+		Status:            StatusPending,
+		NextAttemptAt:     now,
+		RedeliveryOf:      &original.ID,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if _, err := app.Database.NewInsert().Model(delivery).Exec(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// ClaimDueDeliveries locks a batch of deliveries that are due for this worker. SKIP LOCKED lets replicas
+// claim different rows, and deliveries stuck in sending past lockTimeout are claimed again.
+func ClaimDueDeliveries(ctx context.Context, db bun.IDB, now time.Time) ([]models.Webhook_Delivery, error) {
+	claimed := []models.Webhook_Delivery{}
+	err := db.NewRaw(`
+		UPDATE webhook_deliveries
+		SET status = ?, locked_at = ?, attempts = attempts + 1, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE (status = ? AND next_attempt_at <= ?)
+				OR (status = ? AND locked_at < ?)
+			ORDER BY next_attempt_at ASC
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`, StatusSending, now, now, StatusPending, now, StatusSending, now.Add(-lockTimeout), claimBatchSize).Scan(ctx, &claimed)
+	if err != nil {
+		return nil, err
+	}
+
+	return claimed, nil
+}
+
+// ProcessDueDeliveries claims due deliveries and posts them, recording the outcome of each
+func ProcessDueDeliveries(ctx context.Context, db bun.IDB, now time.Time) (int, error) {
+	claimed, err := ClaimDueDeliveries(ctx, db, now)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, delivery := range claimed {
+		endpoint := &models.Webhook_Endpoint{}
+		err := db.NewSelect().
+			Model(endpoint).
+			Where("id = ?", delivery.WebhookEndpointID).
+			Scan(ctx)
+		if err != nil {
+			retryDelivery(ctx, db, delivery, nil, "", err, now)
+			continue
+		}
+		if !endpoint.Enabled {
+			markDelivery(ctx, db, delivery.ID, StatusDead, nil, "", "webhook endpoint is disabled", now)
+			continue
+		}
+
+		responseStatus, responseBody, err := post(ctx, *endpoint, delivery, now)
+		if err != nil {
+			retryDelivery(ctx, db, delivery, responseStatus, responseBody, err, now)
+			continue
+		}
+		markDelivery(ctx, db, delivery.ID, StatusDelivered, responseStatus, responseBody, "", now)
+		delivered++
+	}
+
+	return delivered, nil
+}
+
+// post sends a delivery to its endpoint. Any response other than 2xx is an error.
+func post(ctx context.Context, endpoint models.Webhook_Endpoint, delivery models.Webhook_Delivery, now time.Time) (*int, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return nil, "", err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "subscritracker-webhooks")
+	request.Header.Set(EventHeader, delivery.EventType)
+	request.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	request.Header.Set(SignatureHeader, Sign(endpoint.Secret, now, delivery.Payload))
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, "", err
+	}
+	defer response.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(response.Body, maxResponseBody))
+	status := response.StatusCode
+	if status < 200 || status >= 300 {
+		return &status, string(body), fmt.Errorf("endpoint responded %d", status)
+	}
+	return &status, string(body), nil
+}
+
+func markDelivery(ctx context.Context, db bun.IDB, deliveryID int64, status string, responseStatus *int, responseBody, reason string, now time.Time) {
+	query := db.NewUpdate().
+		Model((*models.Webhook_Delivery)(nil)).
+		Set("status = ?", status).
+		Set("locked_at = NULL").
+		Set("response_status = ?", responseStatus).
+		Set("response_body = ?", responseBody).
+		Set("updated_at = ?", now).
+		Where("id = ?", deliveryID)
+	if status == StatusDelivered {
+		query = query.Set("delivered_at = ?", now).Set("last_error = NULL")
+	}
+	if reason != "" {
+		query = query.Set("last_error = ?", reason)
+	}
+	if _, err := query.Exec(ctx); err != nil {
+		log.Printf("Error marking webhook delivery %d as %s: %v", deliveryID, status, err)
+	}
+}
+
+// retryDelivery puts a failed delivery back in the queue with exponential backoff, or dead-letters it
+// when it is out of attempts or the endpoint answered 410 Gone
+func retryDelivery(ctx context.Context, db bun.IDB, delivery models.Webhook_Delivery, responseStatus *int, responseBody string, cause error, now time.Time) {
+	log.Printf("Error delivering webhook delivery %d (attempt %d): %v", delivery.ID, delivery.Attempts, cause)
+	if delivery.Attempts >= maxAttempts || (responseStatus != nil && *responseStatus == http.StatusGone) {
+		markDelivery(ctx, db, delivery.ID, StatusDead, responseStatus, responseBody, cause.Error(), now)
+		return
+	}
+
+	_, err := db.NewUpdate().
+		Model((*models.Webhook_Delivery)(nil)).
+		Set("status = ?", StatusPending).
+		Set("locked_at = NULL").
+		Set("next_attempt_at = ?", now.Add(backoff(delivery.Attempts))).
+		Set("response_status = ?", responseStatus).
+		Set("response_body = ?", responseBody).
+		Set("last_error = ?", cause.Error()).
+		Set("updated_at = ?", now).
+		Where("id = ?", delivery.ID).
+		Exec(ctx)
+	if err != nil {
+		log.Printf("Error rescheduling webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// backoff returns the delay before the next attempt after the given number of attempts
+func backoff(attempts int) time.Duration {
+	delay := firstRetryDelay << max(attempts-1, 0)
+	if delay <= 0 || delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
+
+// StartDeliveryWorker sends due webhook deliveries once at startup and then every interval
+func StartDeliveryWorker(ctx context.Context, app *application.App, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if delivered, err := ProcessDueDeliveries(ctx, app.Database, time.Now()); err != nil {
+				log.Printf("Error processing webhook deliveries: %v", err)
+			} else if delivered > 0 {
+				log.Printf("Delivered %d webhooks", delivered)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
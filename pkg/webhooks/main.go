@@ -0,0 +1,16 @@
+package webhooks
+
+import (
+	"subscritracker/pkg/application"
+	"subscritracker/pkg/utils"
+)
+
+func RegisterRoutes(app *application.App) {
+	app.Echo.GET("/v1/webhooks", GetEndpointsHandler, utils.AuthMiddleware)
+	app.Echo.POST("/v1/webhooks", CreateEndpointHandler, utils.AuthMiddleware)
+	app.Echo.PUT("/v1/webhooks/:id", UpdateEndpointHandler, utils.AuthMiddleware)
+	app.Echo.DELETE("/v1/webhooks/:id", DeleteEndpointHandler, utils.AuthMiddleware)
+	app.Echo.POST("/v1/webhooks/:id/rotate-secret", RotateSecretHandler, utils.AuthMiddleware)
+	app.Echo.GET("/v1/webhooks/:id/deliveries", GetDeliveriesHandler, utils.AuthMiddleware)
+	app.Echo.POST("/v1/webhooks/:id/deliveries/:delivery_id/redeliver", RedeliverHandler, utils.AuthMiddleware)
+}
@@ -0,0 +1,68 @@
+package webhooks
+
+import (
+	"errors"
+	"time"
+
+	"subscritracker/pkg/models"
+)
+
+// Delivery statuses
+const (
+	StatusPending   = "pending"
+	StatusSending   = "sending"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead"
+)
+
+// Headers sent with every delivery. The signature is "t=<unix time>,v1=<hex HMAC-SHA256>" of
+// "<unix time>.<body>" keyed with the endpoint's secret, so receivers can reject old or forged requests.
+const (
+	SignatureHeader = "X-Subscritracker-Signature"
+	EventHeader     = "X-Subscritracker-Event"
+	DeliveryHeader  = "X-Subscritracker-Delivery"
+)
+
+const (
+	// maxAttempts before a delivery is dead-lettered; with backoff this spans about 15 hours
+	maxAttempts = 12
+	// firstRetryDelay doubles after every failed attempt, up to maxRetryDelay
+	firstRetryDelay = 30 * time.Second
+	maxRetryDelay   = 6 * time.Hour
+	// lockTimeout releases deliveries claimed by a worker that died before finishing
+	lockTimeout = 10 * time.Minute
+	// claimBatchSize is how many deliveries one worker tick claims
+	claimBatchSize = 50
+	// deliveryTimeout bounds a single request to an endpoint
+	deliveryTimeout = 10 * time.Second
+	// maxResponseBody is how much of the endpoint's response is kept in the delivery log
+	maxResponseBody = 1024
+	// secretPrefix marks webhook signing secrets
+	secretPrefix = "whsec_"
+)
+
+var (
+	ErrEndpointNotFound   = errors.New("webhook endpoint not found")
+	ErrDeliveryNotFound   = errors.New("webhook delivery not found")
+	ErrDeliveryInProgress = errors.New("webhook delivery is still queued")
+)
+
+// Envelope is the JSON body posted to endpoints. ID identifies the event, so a redelivery
+// or a retry after a timeout carries the same id and receivers can ignore repeats.
+type Envelope struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	CreatedAt time.Time              `json:"created_at"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// EndpointWithSecret is returned when the secret is created or rotated, the only times it is shown
+type EndpointWithSecret struct {
+	models.Webhook_Endpoint
+	Secret string `json:"secret"`
+}
+
+type DeliveryPage struct {
+	Data       []models.Webhook_Delivery `json:"data"`
+	NextCursor *string                   `json:"next_cursor"`
+}